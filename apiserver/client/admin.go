@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// The admin endpoints require an api key with the admin scope
+
+// AdminListStatusPages returns every status page with its full definition, including the headers
+func (c *Client) AdminListStatusPages(ctx context.Context) (*AdminStatusPagesResponse, error) {
+	var response AdminStatusPagesResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/statusPages", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminCreateStatusPage creates a status page and returns it as stored
+func (c *Client) AdminCreateStatusPage(ctx context.Context, request AdminStatusPageRequest) (*AdminStatusPage, error) {
+	var response AdminStatusPage
+	if err := c.do(ctx, http.MethodPost, "/admin/statusPages", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminUpdateStatusPage replaces the definition of the status page with the url of the request and returns it as stored
+func (c *Client) AdminUpdateStatusPage(ctx context.Context, request AdminStatusPageRequest) (*AdminStatusPage, error) {
+	var response AdminStatusPage
+	if err := c.do(ctx, http.MethodPut, "/admin/statusPages", url.Values{"statusPageUrl": {request.URL}}, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminDeleteStatusPage deletes a status page, its incidents are kept
+func (c *Client) AdminDeleteStatusPage(ctx context.Context, statusPageUrl string) error {
+	return c.do(ctx, http.MethodDelete, "/admin/statusPages", url.Values{"statusPageUrl": {statusPageUrl}}, nil, nil)
+}
+
+// AdminTestScrape scrapes the current incidents of the status page definition without storing anything
+func (c *Client) AdminTestScrape(ctx context.Context, request AdminStatusPageRequest) (*TestScrapeResponse, error) {
+	var response TestScrapeResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/statusPages/testScrape", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminTestScrapeStored scrapes the current incidents of a stored status page without storing anything
+func (c *Client) AdminTestScrapeStored(ctx context.Context, statusPageUrl string) (*TestScrapeResponse, error) {
+	var response TestScrapeResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/statusPages/testScrape", url.Values{"statusPageUrl": {statusPageUrl}}, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminListApiKeys returns every api key, including the revoked ones, without the keys themselves
+func (c *Client) AdminListApiKeys(ctx context.Context) (*AdminApiKeysResponse, error) {
+	var response AdminApiKeysResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/apiKeys", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminCreateApiKey creates an api key, the key is only part of this response
+func (c *Client) AdminCreateApiKey(ctx context.Context, request AdminApiKeyRequest) (*AdminApiKeyResponse, error) {
+	var response AdminApiKeyResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/apiKeys", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminRevokeApiKey revokes an api key, apiservers that cached it accept it for up to a minute
+func (c *Client) AdminRevokeApiKey(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/apiKeys/%d", id), nil, nil, nil)
+}
+
+// AdminCreateServiceGroup creates a service group, every status page of its members must be known to statusphere
+func (c *Client) AdminCreateServiceGroup(ctx context.Context, request AdminServiceGroupRequest) (*ServiceGroup, error) {
+	var response ServiceGroupResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/groups", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response.ServiceGroup, nil
+}
+
+// AdminUpdateServiceGroup replaces the description, aggregation and members of the service group with the name of the request
+func (c *Client) AdminUpdateServiceGroup(ctx context.Context, request AdminServiceGroupRequest) (*ServiceGroup, error) {
+	var response ServiceGroupResponse
+	if err := c.do(ctx, http.MethodPut, "/admin/groups/"+url.PathEscape(request.Name), nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response.ServiceGroup, nil
+}
+
+// AdminDeleteServiceGroup deletes a service group
+func (c *Client) AdminDeleteServiceGroup(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/admin/groups/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// AdminListSubscriptions returns the status pages the workspace of the api key subscribed to
+func (c *Client) AdminListSubscriptions(ctx context.Context) (*AdminSubscriptionsResponse, error) {
+	var response AdminSubscriptionsResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/subscriptions", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminAddSubscriptions subscribes the workspace of the api key to status pages known to statusphere
+func (c *Client) AdminAddSubscriptions(ctx context.Context, statusPageUrls []string) (*AdminSubscriptionsResponse, error) {
+	var response AdminSubscriptionsResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/subscriptions", nil, AdminSubscriptionsRequest{StatusPageUrls: statusPageUrls}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminRemoveSubscription unsubscribes the workspace of the api key from a status page
+func (c *Client) AdminRemoveSubscription(ctx context.Context, statusPageUrl string) error {
+	return c.do(ctx, http.MethodDelete, "/admin/subscriptions", url.Values{"statusPageUrl": {statusPageUrl}}, nil, nil)
+}
+
+// AdminListNotificationChannels returns the notification channels of the workspace of the api key
+func (c *Client) AdminListNotificationChannels(ctx context.Context) (*AdminNotificationChannelsResponse, error) {
+	var response AdminNotificationChannelsResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/notificationChannels", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminCreateNotificationChannel creates a notification channel in the workspace of the api key
+func (c *Client) AdminCreateNotificationChannel(ctx context.Context, request AdminNotificationChannelRequest) (*NotificationChannel, error) {
+	var response AdminNotificationChannelResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/notificationChannels", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response.NotificationChannel, nil
+}
+
+// AdminDeleteNotificationChannel deletes a notification channel of the workspace of the api key
+func (c *Client) AdminDeleteNotificationChannel(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/notificationChannels/%d", id), nil, nil, nil)
+}
+
+// AdminListWorkspaces returns every workspace, only the default workspace can manage workspaces
+func (c *Client) AdminListWorkspaces(ctx context.Context) (*AdminWorkspacesResponse, error) {
+	var response AdminWorkspacesResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/workspaces", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AdminCreateWorkspace creates a workspace without status pages
+func (c *Client) AdminCreateWorkspace(ctx context.Context, name string) (*Workspace, error) {
+	var response AdminWorkspaceResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/workspaces", nil, AdminWorkspaceRequest{Name: name}, &response); err != nil {
+		return nil, err
+	}
+	return &response.Workspace, nil
+}
+
+// AdminDeleteWorkspace deletes a workspace with its subscriptions, service groups, notification channels and api keys
+func (c *Client) AdminDeleteWorkspace(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/admin/workspaces/"+url.PathEscape(name), nil, nil, nil)
+}
@@ -0,0 +1,457 @@
+// Package client is a typed Go client of the statusphere apiserver.
+// It follows the OpenAPI specification served at /api/v1/openapi.json, the contract tests of the apiserver check that they agree.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithApiKey authenticates the requests with the api key, requests are anonymous without it
+func WithApiKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHTTPClient sets the http client that makes the requests, the default is http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient returns a client of the apiserver at baseURL, e.g. https://api.statusphere.tech
+func NewClient(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Error is returned when the apiserver responds with an error status code
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is how long to wait before the next request when the rate limit is exceeded
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("statusphere: %d %s", e.StatusCode, e.Message)
+}
+
+// newRequest returns a request of the path relative to /api/v1
+func (c *Client) newRequest(ctx context.Context, method string, path string, query url.Values, body interface{}) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
+	requestUrl := c.baseURL + apiPrefix + path
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// do sends the request and decodes the JSON response into response, which can be nil for responses without a body
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, response interface{}) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return responseError(resp)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+func responseError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error != "" {
+		apiErr.Message = body.Error
+	}
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(retryAfter) * time.Second
+	}
+	return apiErr
+}
+
+// setTimeRange sets the from and to query parameters, zero times are left out
+func setTimeRange(query url.Values, from time.Time, to time.Time) {
+	if !from.IsZero() {
+		query.Set("from", from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format(time.RFC3339))
+	}
+}
+
+func setImpacts(query url.Values, impacts []Impact) {
+	if len(impacts) == 0 {
+		return
+	}
+	impactStrs := make([]string, 0, len(impacts))
+	for _, impact := range impacts {
+		impactStrs = append(impactStrs, string(impact))
+	}
+	query.Set("impact", strings.Join(impactStrs, ","))
+}
+
+type IncidentsParams struct {
+	StatusPageUrl string
+	// Impacts are the impacts to return, every impact is returned if it is empty
+	Impacts []Impact
+	// From and To only return the incidents overlapping that window, they are unbounded if zero
+	From time.Time
+	To   time.Time
+	// Ongoing only returns the incidents that have not ended
+	Ongoing   bool
+	Component string
+	Query     string
+	// Limit is 0 for the default of 100
+	Limit int
+	// Cursor is the NextCursor of the previous response
+	Cursor string
+}
+
+// ListIncidents returns the incidents of a status page, newest first
+func (c *Client) ListIncidents(ctx context.Context, params IncidentsParams) (*IncidentsResponse, error) {
+	var response IncidentsResponse
+	if err := c.do(ctx, http.MethodGet, "/incidents", incidentsQuery(params), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func incidentsQuery(params IncidentsParams) url.Values {
+	query := url.Values{}
+	if params.StatusPageUrl != "" {
+		query.Set("statusPageUrl", params.StatusPageUrl)
+	}
+	setImpacts(query, params.Impacts)
+	setTimeRange(query, params.From, params.To)
+	if params.Ongoing {
+		query.Set("ongoing", "true")
+	}
+	if params.Component != "" {
+		query.Set("component", params.Component)
+	}
+	if params.Query != "" {
+		query.Set("query", params.Query)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Cursor != "" {
+		query.Set("cursor", params.Cursor)
+	}
+	return query
+}
+
+type IncidentSearchParams struct {
+	// Query supports the web search syntax e.g. "us-east-1" or kafka -maintenance
+	Query string
+	// StatusPageUrls are the status pages to search, every status page is searched if it is empty
+	StatusPageUrls []string
+	Impacts        []Impact
+	From           time.Time
+	To             time.Time
+	// Limit is 0 for the default of 25
+	Limit  int
+	Offset int
+}
+
+// SearchIncidents searches the incidents of every status page, best matches first
+func (c *Client) SearchIncidents(ctx context.Context, params IncidentSearchParams) (*IncidentSearchResponse, error) {
+	query := url.Values{}
+	query.Set("query", params.Query)
+	for _, statusPageUrl := range params.StatusPageUrls {
+		query.Add("statusPageUrl", statusPageUrl)
+	}
+	setImpacts(query, params.Impacts)
+	setTimeRange(query, params.From, params.To)
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	var response IncidentSearchResponse
+	if err := c.do(ctx, http.MethodGet, "/incidents/search", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetIncidentHistory returns the incident with every observed change of it, oldest first
+func (c *Client) GetIncidentHistory(ctx context.Context, id int64) (*IncidentHistoryResponse, error) {
+	var response IncidentHistoryResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/incidents/%d/history", id), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetCurrentStatus returns the current status of a status page
+func (c *Client) GetCurrentStatus(ctx context.Context, statusPageUrl string) (*CurrentStatusResponse, error) {
+	var response CurrentStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/currentStatus", url.Values{"statusPageUrl": {statusPageUrl}}, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetCurrentStatusBatch returns the current status of up to 200 status pages, in the order they are given
+func (c *Client) GetCurrentStatusBatch(ctx context.Context, statusPageUrls []string) (*CurrentStatusBatchResponse, error) {
+	var response CurrentStatusBatchResponse
+	request := CurrentStatusBatchRequest{StatusPageUrls: statusPageUrls}
+	if err := c.do(ctx, http.MethodPost, "/currentStatus/batch", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetStatusPage returns the status page with the url
+func (c *Client) GetStatusPage(ctx context.Context, statusPageUrl string) (*StatusPageResponse, error) {
+	return c.getStatusPage(ctx, url.Values{"statusPageUrl": {statusPageUrl}})
+}
+
+// GetStatusPageByName returns the status page with the name, ignoring case
+func (c *Client) GetStatusPageByName(ctx context.Context, statusPageName string) (*StatusPageResponse, error) {
+	return c.getStatusPage(ctx, url.Values{"statusPageName": {statusPageName}})
+}
+
+func (c *Client) getStatusPage(ctx context.Context, query url.Values) (*StatusPageResponse, error) {
+	var response StatusPageResponse
+	if err := c.do(ctx, http.MethodGet, "/statusPage", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListStatusPages returns every status page, by name
+func (c *Client) ListStatusPages(ctx context.Context) (*StatusPagesResponse, error) {
+	var response StatusPagesResponse
+	if err := c.do(ctx, http.MethodGet, "/statusPages", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SearchStatusPages returns the status pages whose name or url match the query, best matches first
+func (c *Client) SearchStatusPages(ctx context.Context, query string) (*StatusPageSearchResponse, error) {
+	var response StatusPageSearchResponse
+	if err := c.do(ctx, http.MethodGet, "/statusPages/search", url.Values{"query": {query}}, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CountStatusPages returns the number of status pages
+func (c *Client) CountStatusPages(ctx context.Context) (*StatusPageCountResponse, error) {
+	var response StatusPageCountResponse
+	if err := c.do(ctx, http.MethodGet, "/statusPages/count", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type UptimeParams struct {
+	StatusPageUrl string
+	// From and To are the days of the uptime, the default is the last 30 days
+	From time.Time
+	To   time.Time
+	// Granularity is empty for the default of day
+	Granularity Granularity
+	// Component is empty for the whole status page
+	Component string
+}
+
+// GetUptime returns the uptime of a status page from the daily rollups
+func (c *Client) GetUptime(ctx context.Context, params UptimeParams) (*UptimeResponse, error) {
+	query := url.Values{}
+	query.Set("statusPageUrl", params.StatusPageUrl)
+	setTimeRange(query, params.From, params.To)
+	if params.Granularity != "" {
+		query.Set("granularity", string(params.Granularity))
+	}
+	if params.Component != "" {
+		query.Set("component", params.Component)
+	}
+	var response UptimeResponse
+	if err := c.do(ctx, http.MethodGet, "/uptime", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type StatsParams struct {
+	StatusPageUrl string
+	// From and To are the period of the stats, the default is the last 90 days
+	From time.Time
+	To   time.Time
+}
+
+// GetStats returns the incident statistics of a status page
+func (c *Client) GetStats(ctx context.Context, params StatsParams) (*StatsResponse, error) {
+	query := url.Values{}
+	query.Set("statusPageUrl", params.StatusPageUrl)
+	setTimeRange(query, params.From, params.To)
+	var response StatsResponse
+	if err := c.do(ctx, http.MethodGet, "/stats", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type LeaderboardParams struct {
+	// From and To are the period of the leaderboard, the default is the last 90 days, it is at most 366 days
+	From time.Time
+	To   time.Time
+	// Sort is empty for the default of downtime
+	Sort LeaderboardSort
+	// Worst ranks the least reliable status pages first
+	Worst bool
+	// Limit is 0 for the default of 25
+	Limit int
+}
+
+// GetLeaderboard ranks the indexed status pages by reliability, the most reliable first
+func (c *Client) GetLeaderboard(ctx context.Context, params LeaderboardParams) (*LeaderboardResponse, error) {
+	query := url.Values{}
+	setTimeRange(query, params.From, params.To)
+	if params.Sort != "" {
+		query.Set("sort", string(params.Sort))
+	}
+	if params.Worst {
+		query.Set("order", "worst")
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	var response LeaderboardResponse
+	if err := c.do(ctx, http.MethodGet, "/stats/leaderboard", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListServiceGroups returns every service group ordered by name
+func (c *Client) ListServiceGroups(ctx context.Context) (*ServiceGroupsResponse, error) {
+	var response ServiceGroupsResponse
+	if err := c.do(ctx, http.MethodGet, "/groups", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetServiceGroup returns the definition of a service group
+func (c *Client) GetServiceGroup(ctx context.Context, name string) (*ServiceGroupResponse, error) {
+	var response ServiceGroupResponse
+	if err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(name), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetServiceGroupStatus returns the composite status of a service group, aggregation is empty for the aggregation of the group
+func (c *Client) GetServiceGroupStatus(ctx context.Context, name string, aggregation ServiceGroupAggregation) (*ServiceGroupStatusResponse, error) {
+	query := url.Values{}
+	if aggregation != "" {
+		query.Set("aggregation", string(aggregation))
+	}
+	var response ServiceGroupStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(name)+"/status", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListServiceGroupIncidents returns the incidents of every member of a service group merged, newest first
+// The StatusPageUrl of the params is not used
+func (c *Client) ListServiceGroupIncidents(ctx context.Context, name string, params IncidentsParams) (*ServiceGroupIncidentsResponse, error) {
+	params.StatusPageUrl = ""
+	var response ServiceGroupIncidentsResponse
+	if err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(name)+"/incidents", incidentsQuery(params), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GraphQL executes a GraphQL query, the errors of single fields are returned in the response along with the data
+// A query that cannot be executed returns an *Error with the message of its first error
+func (c *Client) GraphQL(ctx context.Context, request GraphqlRequest) (*GraphqlResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/graphql", nil, request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var response GraphqlResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || len(response.Errors) == 0 {
+			return nil, &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		}
+		return nil, &Error{StatusCode: resp.StatusCode, Message: response.Errors[0].Message}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, responseError(resp)
+	}
+	var response GraphqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	StreamEventIncidentCreated  = "incident.created"
+	StreamEventIncidentUpdated  = "incident.updated"
+	StreamEventIncidentResolved = "incident.resolved"
+	StreamEventStatusChanged    = "status.changed"
+)
+
+type StreamParams struct {
+	// StatusPageUrls are the status pages to receive the events of, events of every status page are received if it is empty
+	StatusPageUrls []string
+	// Impacts are the impacts of the incident events to receive, they do not filter the status.changed events
+	Impacts []Impact
+}
+
+type StreamEvent struct {
+	// Name is one of incident.created, incident.updated, incident.resolved and status.changed
+	Name string
+	// Incident is set for the incident events
+	Incident *IncidentStreamEvent
+	// Status is set for the status.changed events
+	Status *StatusStreamEvent
+}
+
+// Stream calls handle with each event of the /stream endpoint until the context is done, the stream ends or handle returns an error
+// The apiserver disconnects clients that fall too far behind and does not replay events, callers should reconnect
+// Events of unknown names are skipped, so newer apiservers can add events
+func (c *Client) Stream(ctx context.Context, params StreamParams, handle func(StreamEvent) error) error {
+	query := url.Values{}
+	for _, statusPageUrl := range params.StatusPageUrls {
+		query.Add("statusPageUrl", statusPageUrl)
+	}
+	setImpacts(query, params.Impacts)
+	req, err := c.newRequest(ctx, http.MethodGet, "/stream", query, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return responseError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var name string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends the event
+			if name != "" {
+				if err := dispatchStreamEvent(name, data.String(), handle); err != nil {
+					return err
+				}
+			}
+			name = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// Comments keep the connection alive
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteString("\n")
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+func dispatchStreamEvent(name string, data string, handle func(StreamEvent) error) error {
+	event := StreamEvent{Name: name}
+	switch name {
+	case StreamEventIncidentCreated, StreamEventIncidentUpdated, StreamEventIncidentResolved:
+		event.Incident = &IncidentStreamEvent{}
+		if err := json.Unmarshal([]byte(data), event.Incident); err != nil {
+			return errors.Wrapf(err, "failed to decode the %s event", name)
+		}
+	case StreamEventStatusChanged:
+		event.Status = &StatusStreamEvent{}
+		if err := json.Unmarshal([]byte(data), event.Status); err != nil {
+			return errors.Wrapf(err, "failed to decode the %s event", name)
+		}
+	default:
+		return nil
+	}
+	return handle(event)
+}
@@ -0,0 +1,365 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/stats"
+)
+
+// The models stored by statusphere are shared with the apiserver
+type (
+	Impact           = api.Impact
+	Incident         = api.Incident
+	IncidentEvent    = api.IncidentEvent
+	IncidentState    = api.IncidentState
+	IncidentRevision = api.IncidentRevision
+	FieldChange      = api.FieldChange
+	StatusPage       = api.StatusPage
+	AdminStatusPage  = api.AdminStatusPage
+	ApiKey           = api.ApiKey
+	ApiKeyScope      = api.ApiKeyScope
+
+	ServiceGroup            = api.ServiceGroup
+	ServiceGroupMember      = api.ServiceGroupMember
+	ServiceGroupAggregation = api.ServiceGroupAggregation
+
+	Workspace               = api.Workspace
+	NotificationChannel     = api.NotificationChannel
+	NotificationChannelType = api.NotificationChannelType
+	IncidentChangeEventType = api.IncidentChangeEventType
+)
+
+// The statistics are computed by the stats package of statusphere
+type (
+	StatsReport    = stats.Report
+	StatsSummary   = stats.Summary
+	ImpactCounts   = stats.ImpactCounts
+	Outage         = stats.Outage
+	ComponentStats = stats.ComponentStats
+	MonthStats     = stats.MonthStats
+)
+
+type Status string
+
+const (
+	StatusUp       Status = "UP"
+	StatusDegraded Status = "DEGRADED"
+	StatusUnknown  Status = "UNKNOWN"
+)
+
+type StatusLevel string
+
+const (
+	StatusLevelOperational    StatusLevel = "OPERATIONAL"
+	StatusLevelMaintenance    StatusLevel = "MAINTENANCE"
+	StatusLevelMinorOutage    StatusLevel = "MINOR_OUTAGE"
+	StatusLevelMajorOutage    StatusLevel = "MAJOR_OUTAGE"
+	StatusLevelCriticalOutage StatusLevel = "CRITICAL_OUTAGE"
+	StatusLevelStale          StatusLevel = "STALE"
+)
+
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityMonth Granularity = "month"
+)
+
+type IncidentChangeType string
+
+const (
+	IncidentChangeCreated  IncidentChangeType = "created"
+	IncidentChangeUpdated  IncidentChangeType = "updated"
+	IncidentChangeResolved IncidentChangeType = "resolved"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type IncidentsResponse struct {
+	Incidents []Incident `json:"incidents"`
+	IsIndexed bool       `json:"isIndexed"`
+	// NextCursor is set when there are more incidents, pass it as the cursor of the next request to get them
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type IncidentSearchResult struct {
+	Incident Incident `json:"incident"`
+	// Rank is the relevance of the incident to the search query, higher is more relevant
+	Rank float64 `json:"rank"`
+	// Snippet is the part of the incident that matches the search query as html, with the matches wrapped in <mark> tags
+	// The text of the incident is escaped
+	Snippet string `json:"snippet"`
+}
+
+type IncidentSearchResponse struct {
+	Results []IncidentSearchResult `json:"results"`
+}
+
+type IncidentHistoryResponse struct {
+	Incident  Incident           `json:"incident"`
+	Revisions []IncidentRevision `json:"revisions"`
+}
+
+type CurrentStatusResponse struct {
+	Status    Status      `json:"status"`
+	IsIndexed bool        `json:"isIndexed"`
+	Level     StatusLevel `json:"level"`
+	Reason    string      `json:"reason"`
+}
+
+type CurrentStatusBatchRequest struct {
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type StatusPageCurrentStatus struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	// Known is false if the status page is not known to statusphere, no other field is set then
+	Known                bool        `json:"known"`
+	Status               Status      `json:"status,omitempty"`
+	Level                StatusLevel `json:"level,omitempty"`
+	Reason               string      `json:"reason,omitempty"`
+	IsIndexed            bool        `json:"isIndexed"`
+	OngoingIncidentCount int         `json:"ongoingIncidentCount"`
+	HighestOngoingImpact *Impact     `json:"highestOngoingImpact"`
+	// LastSuccessfullyScraped is nil if the status page has never been scraped successfully
+	LastSuccessfullyScraped *time.Time `json:"lastSuccessfullyScraped"`
+}
+
+type CurrentStatusBatchResponse struct {
+	Statuses []StatusPageCurrentStatus `json:"statuses"`
+}
+
+type StatusPageResponse struct {
+	StatusPage StatusPage `json:"statusPage"`
+}
+
+type StatusPagesResponse struct {
+	StatusPages []StatusPage `json:"statusPages"`
+}
+
+type StatusPageSearchResponse struct {
+	StatusPages []StatusPage `json:"statusPages"`
+}
+
+type StatusPageCountResponse struct {
+	StatusPageCount int `json:"statusPageCount"`
+}
+
+type UptimeBucket struct {
+	Start                time.Time `json:"start"`
+	PeriodSeconds        float64   `json:"periodSeconds"`
+	FullOutageSeconds    float64   `json:"fullOutageSeconds"`
+	PartialOutageSeconds float64   `json:"partialOutageSeconds"`
+	UptimePercentage     float64   `json:"uptimePercentage"`
+	// SlaBreached is only set when the status page has an sla target
+	SlaBreached *bool `json:"slaBreached,omitempty"`
+}
+
+type UptimeResponse struct {
+	StatusPageUrl    string         `json:"statusPageUrl"`
+	Component        string         `json:"component"`
+	Granularity      Granularity    `json:"granularity"`
+	From             time.Time      `json:"from"`
+	To               time.Time      `json:"to"`
+	SlaTarget        *float64       `json:"slaTarget"`
+	UptimePercentage float64        `json:"uptimePercentage"`
+	SlaBreached      *bool          `json:"slaBreached,omitempty"`
+	Buckets          []UptimeBucket `json:"buckets"`
+}
+
+type IncidentStreamEvent struct {
+	Type     IncidentChangeType `json:"type"`
+	Incident Incident           `json:"incident"`
+}
+
+type StatusStreamEvent struct {
+	StatusPageUrl string      `json:"statusPageUrl"`
+	Status        Status      `json:"status"`
+	Level         StatusLevel `json:"level"`
+	Reason        string      `json:"reason"`
+}
+
+type StatsResponse struct {
+	StatusPageUrl string      `json:"statusPageUrl"`
+	From          time.Time   `json:"from"`
+	To            time.Time   `json:"to"`
+	Stats         StatsReport `json:"stats"`
+}
+
+type LeaderboardSort string
+
+const (
+	LeaderboardSortDowntime  LeaderboardSort = "downtime"
+	LeaderboardSortIncidents LeaderboardSort = "incidents"
+	LeaderboardSortMttr      LeaderboardSort = "mttr"
+)
+
+type LeaderboardEntry struct {
+	Rank          int          `json:"rank"`
+	StatusPageUrl string       `json:"statusPageUrl"`
+	Name          string       `json:"name"`
+	Stats         StatsSummary `json:"stats"`
+}
+
+type LeaderboardResponse struct {
+	From    time.Time          `json:"from"`
+	To      time.Time          `json:"to"`
+	Sort    LeaderboardSort    `json:"sort"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+type ServiceGroupsResponse struct {
+	ServiceGroups []ServiceGroup `json:"serviceGroups"`
+}
+
+type ServiceGroupResponse struct {
+	ServiceGroup ServiceGroup `json:"serviceGroup"`
+}
+
+type ServiceGroupMemberStatus struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	// StatusPageName is empty if the status page is not known to statusphere anymore
+	StatusPageName string      `json:"statusPageName"`
+	Component      string      `json:"component,omitempty"`
+	Weight         float64     `json:"weight"`
+	Level          StatusLevel `json:"level"`
+	Reason         string      `json:"reason"`
+}
+
+type ServiceGroupStatusResponse struct {
+	Name        string                  `json:"name"`
+	Aggregation ServiceGroupAggregation `json:"aggregation"`
+	Level       StatusLevel             `json:"level"`
+	Reason      string                  `json:"reason"`
+	// Score is only set for the weighted aggregation, from 0 when no member has an outage to 3 when all have a critical outage
+	Score   *float64                   `json:"score,omitempty"`
+	Members []ServiceGroupMemberStatus `json:"members"`
+}
+
+type ServiceGroupIncidentsResponse struct {
+	Incidents []Incident `json:"incidents"`
+	// NextCursor is set when there are more incidents, pass it as the cursor of the next request to get them
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type GraphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphqlErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphqlError struct {
+	Message   string                 `json:"message"`
+	Locations []GraphqlErrorLocation `json:"locations,omitempty"`
+	// Path is the path of the field that failed, made of field names and list indexes
+	Path []interface{} `json:"path,omitempty"`
+}
+
+type GraphqlResponse struct {
+	// Data is the result of the query, decode it into a type matching the selected fields
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphqlError  `json:"errors,omitempty"`
+}
+
+// AdminStatusPageRequest is the definition of a status page, the scraping state is managed by statusphere
+type AdminStatusPageRequest struct {
+	URL                   string                 `json:"url"`
+	Name                  string                 `json:"name"`
+	PreferredScraper      string                 `json:"preferredScraper"`
+	Method                api.HttpMethod         `json:"httpMethod"`
+	Headers               map[string]string      `json:"headers"`
+	RequestPayload        map[string]interface{} `json:"payload"`
+	ValidationRules       map[string]interface{} `json:"rules"`
+	SlaTarget             *float64               `json:"slaTarget"`
+	ScrapeIntervalSeconds int                    `json:"scrapeIntervalSeconds"`
+}
+
+type AdminStatusPagesResponse struct {
+	StatusPages []AdminStatusPage `json:"statusPages"`
+}
+
+type TestScrapeResponse struct {
+	// Provider is the provider that produced the incidents, or the last one tried if every provider failed
+	Provider  string     `json:"provider"`
+	Incidents []Incident `json:"incidents"`
+	// Error is set if the status page could not be scraped
+	Error string `json:"error,omitempty"`
+}
+
+type AdminApiKeyRequest struct {
+	Name   string        `json:"name"`
+	Scopes []ApiKeyScope `json:"scopes"`
+	// RateLimitPerMinute is 0 for the default rate limit of the apiserver
+	RateLimitPerMinute int `json:"rateLimitPerMinute"`
+	// Workspace is the name of the workspace of the api key, empty for the workspace of the caller
+	Workspace string `json:"workspace,omitempty"`
+}
+
+type AdminApiKeyResponse struct {
+	ApiKey ApiKey `json:"apiKey"`
+	// Key is only returned when the api key is created, it cannot be retrieved later
+	Key string `json:"key,omitempty"`
+}
+
+type AdminApiKeysResponse struct {
+	ApiKeys []ApiKey `json:"apiKeys"`
+}
+
+type AdminServiceGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Aggregation is empty for worst
+	Aggregation ServiceGroupAggregation `json:"aggregation"`
+	Members     []ServiceGroupMember    `json:"members"`
+}
+
+type AdminWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type AdminWorkspaceResponse struct {
+	Workspace Workspace `json:"workspace"`
+}
+
+type AdminWorkspacesResponse struct {
+	Workspaces []Workspace `json:"workspaces"`
+}
+
+type AdminSubscriptionsRequest struct {
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type AdminSubscriptionsResponse struct {
+	// AllStatusPages is true for the default workspace, which sees every status page without subscribing
+	AllStatusPages bool     `json:"allStatusPages"`
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type AdminNotificationChannelRequest struct {
+	Name string                  `json:"name"`
+	Type NotificationChannelType `json:"type"`
+	// WebhookUrl is required unless SlackBotToken and SlackChannel are set
+	WebhookUrl string `json:"webhookUrl"`
+	// SlackBotToken and SlackChannel notify a slack channel with a bot token, which threads the updates of an incident
+	SlackBotToken string `json:"slackBotToken,omitempty"`
+	SlackChannel  string `json:"slackChannel,omitempty"`
+	// Events are the types of incident changes the channel is notified of, only new incidents when empty
+	Events []IncidentChangeEventType `json:"events,omitempty"`
+}
+
+type AdminNotificationChannelResponse struct {
+	NotificationChannel NotificationChannel `json:"notificationChannel"`
+}
+
+type AdminNotificationChannelsResponse struct {
+	NotificationChannels []NotificationChannel `json:"notificationChannels"`
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	// ListenAddress is the address the apiserver listens on
+	ListenAddress string `envconfig:"LISTEN_ADDRESS" default:":8888"`
+	// ShutdownTimeout is how long the requests in flight have to finish when the apiserver is asked to stop
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// AdminToken is a key with the admin scope that is not stored in the database, to create the first api keys
+	// There is no such key when it is empty
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+	// CorsAllowedOrigins are the origins browsers can call the api from
+	CorsAllowedOrigins []string `envconfig:"CORS_ALLOWED_ORIGINS" default:"http://localhost:3000,https://direct.pykaso.net,http://127.0.0.1:8888"`
+	// AnonymousRead lets requests without an api key use the read endpoints, rate limited per client ip
+	AnonymousRead bool `envconfig:"ANONYMOUS_READ" default:"true"`
+	// AnonymousRateLimitPerMinute is the number of requests a client ip can make per minute without an api key
+	AnonymousRateLimitPerMinute int `envconfig:"ANONYMOUS_RATE_LIMIT_PER_MINUTE" default:"120"`
+	// ApiKeyRateLimitPerMinute is the number of requests an api key without its own rate limit can make per minute
+	ApiKeyRateLimitPerMinute int `envconfig:"API_KEY_RATE_LIMIT_PER_MINUTE" default:"1200"`
+	// InvalidApiKeyRateLimitPerMinute is the number of unknown api keys a client ip can send per minute, the keys are
+	// looked up in the database
+	InvalidApiKeyRateLimitPerMinute int `envconfig:"INVALID_API_KEY_RATE_LIMIT_PER_MINUTE" default:"10"`
+	// TestScrapeAllowPrivateTargets lets the test scrapes of the admin api reach private, loopback and link-local addresses
+	TestScrapeAllowPrivateTargets bool `envconfig:"TEST_SCRAPE_ALLOW_PRIVATE_TARGETS"`
+	// NotificationAllowPrivateTargets lets the webhooks of the notification channels be private, loopback and link-local
+	// addresses, the jobrunner must allow them too
+	NotificationAllowPrivateTargets bool `envconfig:"NOTIFICATION_ALLOW_PRIVATE_TARGETS"`
+	// TrustedProxies are the ips and cidrs of the proxies whose X-Forwarded-For header gives the client ip
+	// No proxy is trusted when it is empty, the client ip is then the address of the connection
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	if err := envconfig.Process("STATUSPHERE", &config); err != nil {
+		return config, err
+	}
+	for _, proxy := range config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return config, errors.Errorf("invalid trusted proxy %q", proxy)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+type AdminApiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// RateLimitPerMinute is 0 for the default rate limit of the apiserver
+	RateLimitPerMinute int `json:"rateLimitPerMinute"`
+	// Workspace is the name of the workspace of the api key, empty for the workspace of the caller
+	// Only the default workspace can create api keys for other workspaces
+	Workspace string `json:"workspace,omitempty"`
+}
+
+type AdminApiKeyResponse struct {
+	ApiKey api.ApiKey `json:"apiKey"`
+	// Key is only returned when the api key is created, it cannot be retrieved later
+	Key string `json:"key,omitempty"`
+}
+
+type AdminApiKeysResponse struct {
+	ApiKeys []api.ApiKey `json:"apiKeys"`
+}
+
+// adminListApiKeys is a handler for the GET /admin/apiKeys endpoint.
+// It returns every api key of the workspace, including the revoked ones, without the keys themselves
+func (s *Server) adminListApiKeys(context *gin.Context) {
+	apiKeys, err := s.dbClient.ListApiKeys(context.Request.Context(), getWorkspaceScope(context).workspaceID)
+	if err != nil {
+		s.logger.Error("failed to list api keys", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+	if apiKeys == nil {
+		apiKeys = []api.ApiKey{}
+	}
+	context.JSON(http.StatusOK, AdminApiKeysResponse{ApiKeys: apiKeys})
+}
+
+// adminCreateApiKey is a handler for the POST /admin/apiKeys endpoint.
+// The body is an AdminApiKeyRequest, the scopes are read and admin.
+// The key is only part of this response, statusphere stores its hash
+func (s *Server) adminCreateApiKey(context *gin.Context) {
+	var request AdminApiKeyRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if len(request.Scopes) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "at least one scope is required"})
+		return
+	}
+	scopes := make(api.ApiKeyScopes, 0, len(request.Scopes))
+	for _, scopeStr := range request.Scopes {
+		scope, err := api.ParseApiKeyScope(scopeStr)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+	if request.RateLimitPerMinute < 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "rateLimitPerMinute must be 0 for the default or positive"})
+		return
+	}
+	workspaceID, ok := s.getApiKeyWorkspaceID(context, strings.TrimSpace(request.Workspace))
+	if !ok {
+		return
+	}
+
+	key, keyPrefix, keyHash, err := generateApiKey()
+	if err != nil {
+		s.logger.Error("failed to generate api key", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate api key"})
+		return
+	}
+	apiKey := api.ApiKey{
+		WorkspaceID:        workspaceID,
+		Name:               name,
+		KeyPrefix:          keyPrefix,
+		KeyHash:            keyHash,
+		Scopes:             scopes,
+		RateLimitPerMinute: request.RateLimitPerMinute,
+	}
+	if err := s.dbClient.CreateApiKey(context.Request.Context(), &apiKey); err != nil {
+		s.logger.Error("failed to create api key", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+	context.JSON(http.StatusCreated, AdminApiKeyResponse{ApiKey: apiKey, Key: key})
+}
+
+// adminRevokeApiKey is a handler for the DELETE /admin/apiKeys/:id endpoint.
+// The api key is kept but cannot be used anymore, apiservers that cached it accept it for up to a minute
+func (s *Server) adminRevokeApiKey(context *gin.Context) {
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return
+	}
+	revoked, err := s.dbClient.RevokeApiKey(context.Request.Context(), getWorkspaceScope(context).workspaceID, id)
+	if err != nil {
+		s.logger.Error("failed to revoke api key", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+	if !revoked {
+		context.JSON(http.StatusNotFound, gin.H{"error": "api key not found or already revoked"})
+		return
+	}
+	context.Status(http.StatusNoContent)
+}
+
+// getApiKeyWorkspaceID returns the id of the workspace an api key is created in, the workspace of the caller if name is empty
+// It responds with an error and returns false if the caller cannot create api keys in the workspace or it does not exist
+func (s *Server) getApiKeyWorkspaceID(context *gin.Context, name string) (int64, bool) {
+	scope := getWorkspaceScope(context)
+	if name == "" {
+		return scope.workspaceID, true
+	}
+	if !scope.isDefault() {
+		context.JSON(http.StatusForbidden, gin.H{"error": "only the default workspace can create api keys for other workspaces"})
+		return 0, false
+	}
+	workspace, err := s.dbClient.GetWorkspaceByName(context.Request.Context(), name)
+	if err != nil {
+		s.logger.Error("failed to get workspace", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workspace"})
+		return 0, false
+	}
+	if workspace == nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+		return 0, false
+	}
+	return workspace.ID, true
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type AdminServiceGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Aggregation is worst or weighted, empty for worst
+	Aggregation string                   `json:"aggregation"`
+	Members     []api.ServiceGroupMember `json:"members"`
+}
+
+// adminCreateServiceGroup is a handler for the POST /admin/groups endpoint.
+// The body is an AdminServiceGroupRequest, it returns a 409 if a service group with the name already exists in the workspace
+// The group belongs to the workspace of the api key, its members must be status pages the workspace sees
+func (s *Server) adminCreateServiceGroup(context *gin.Context) {
+	group, ok := s.bindServiceGroup(context)
+	if !ok {
+		return
+	}
+
+	err := s.dbClient.CreateServiceGroup(context.Request.Context(), &group)
+	if errors.Is(err, db.ErrServiceGroupExists) {
+		context.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to create service group", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create service group"})
+		return
+	}
+	context.JSON(http.StatusCreated, ServiceGroupResponse{ServiceGroup: group})
+}
+
+// adminUpdateServiceGroup is a handler for the PUT /admin/groups/:name endpoint.
+// The body is an AdminServiceGroupRequest with the same name or none, the description, aggregation and members are replaced
+func (s *Server) adminUpdateServiceGroup(context *gin.Context) {
+	group, ok := s.bindServiceGroup(context)
+	if !ok {
+		return
+	}
+	if group.Name != context.Param("name") {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "the name of a service group cannot be changed"})
+		return
+	}
+
+	found, err := s.dbClient.UpdateServiceGroup(context.Request.Context(), &group)
+	if err != nil {
+		s.logger.Error("failed to update service group", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service group"})
+		return
+	}
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "service group not found"})
+		return
+	}
+	s.serviceGroup(context)
+}
+
+// adminDeleteServiceGroup is a handler for the DELETE /admin/groups/:name endpoint.
+func (s *Server) adminDeleteServiceGroup(context *gin.Context) {
+	deleted, err := s.dbClient.DeleteServiceGroup(context.Request.Context(), getWorkspaceScope(context).workspaceID, context.Param("name"))
+	if err != nil {
+		s.logger.Error("failed to delete service group", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete service group"})
+		return
+	}
+	if !deleted {
+		context.JSON(http.StatusNotFound, gin.H{"error": "service group not found"})
+		return
+	}
+	context.Status(http.StatusNoContent)
+}
+
+// bindServiceGroup reads and validates the AdminServiceGroupRequest of the body, the name defaults to the name path parameter
+// It responds with a 400 and returns false if the request is invalid
+func (s *Server) bindServiceGroup(context *gin.Context) (api.ServiceGroup, bool) {
+	var request AdminServiceGroupRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return api.ServiceGroup{}, false
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		name = context.Param("name")
+	}
+	if !nameRegex.MatchString(name) {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to 63 lower case letters, digits and dashes, starting with a letter or a digit"})
+		return api.ServiceGroup{}, false
+	}
+	aggregation := api.ServiceGroupAggregationWorst
+	if request.Aggregation != "" {
+		var err error
+		aggregation, err = api.ParseServiceGroupAggregation(request.Aggregation)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return api.ServiceGroup{}, false
+		}
+	}
+	scope := getWorkspaceScope(context)
+	members, err := s.validateServiceGroupMembers(scope, request.Members)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return api.ServiceGroup{}, false
+	}
+
+	return api.ServiceGroup{
+		WorkspaceID: scope.workspaceID,
+		Name:        name,
+		Description: strings.TrimSpace(request.Description),
+		Aggregation: aggregation,
+		Members:     members,
+	}, true
+}
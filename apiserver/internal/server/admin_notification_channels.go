@@ -0,0 +1,165 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/publicnet"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type AdminNotificationChannelRequest struct {
+	Name string `json:"name"`
+	// Type is slack or twitter
+	Type string `json:"type"`
+	// WebhookUrl is required unless SlackBotToken and SlackChannel are set
+	WebhookUrl string `json:"webhookUrl"`
+	// SlackBotToken and SlackChannel notify a slack channel with a bot token instead of a webhook
+	SlackBotToken string `json:"slackBotToken,omitempty"`
+	SlackChannel  string `json:"slackChannel,omitempty"`
+	// Events are the types of incident changes the channel is notified of, only new incidents when empty
+	Events []string `json:"events,omitempty"`
+}
+
+type AdminNotificationChannelResponse struct {
+	NotificationChannel api.NotificationChannel `json:"notificationChannel"`
+}
+
+type AdminNotificationChannelsResponse struct {
+	NotificationChannels []api.NotificationChannel `json:"notificationChannels"`
+}
+
+// adminListNotificationChannels is a handler for the GET /admin/notificationChannels endpoint.
+// It returns the notification channels of the workspace
+func (s *Server) adminListNotificationChannels(context *gin.Context) {
+	channels, err := s.dbClient.ListNotificationChannels(context.Request.Context(), getWorkspaceScope(context).workspaceID)
+	if err != nil {
+		s.logger.Error("failed to list notification channels", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list notification channels"})
+		return
+	}
+	if channels == nil {
+		channels = []api.NotificationChannel{}
+	}
+	context.JSON(http.StatusOK, AdminNotificationChannelsResponse{NotificationChannels: channels})
+}
+
+// adminCreateNotificationChannel is a handler for the POST /admin/notificationChannels endpoint.
+// The body is an AdminNotificationChannelRequest, the channel is notified of the changes it opted in to of the incidents
+// of the status pages the workspace sees
+// Slack channels are notified through a webhook, or with a bot token and the id of the slack channel
+func (s *Server) adminCreateNotificationChannel(context *gin.Context) {
+	var request AdminNotificationChannelRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	channelType, err := api.ParseNotificationChannelType(request.Type)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := parseIncidentChangeEventTypes(request.Events)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	channel := api.NotificationChannel{
+		WorkspaceID:   getWorkspaceScope(context).workspaceID,
+		Name:          name,
+		Type:          channelType,
+		WebhookUrl:    strings.TrimSpace(request.WebhookUrl),
+		SlackBotToken: strings.TrimSpace(request.SlackBotToken),
+		SlackChannel:  strings.TrimSpace(request.SlackChannel),
+		Events:        events,
+	}
+	if err := validateNotificationChannel(channel); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The jobrunner refuses to connect to private addresses too, this reports the ones known now
+	if channel.WebhookUrl != "" && !s.config.NotificationAllowPrivateTargets {
+		if err := publicnet.CheckUrl(context.Request.Context(), channel.WebhookUrl); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "webhookUrl must not be a private address, " + err.Error()})
+			return
+		}
+	}
+	if err := s.dbClient.CreateNotificationChannel(context.Request.Context(), &channel); err != nil {
+		s.logger.Error("failed to create notification channel", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create notification channel"})
+		return
+	}
+	context.JSON(http.StatusCreated, AdminNotificationChannelResponse{NotificationChannel: channel})
+}
+
+// adminDeleteNotificationChannel is a handler for the DELETE /admin/notificationChannels/:id endpoint.
+func (s *Server) adminDeleteNotificationChannel(context *gin.Context) {
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return
+	}
+	deleted, err := s.dbClient.DeleteNotificationChannel(context.Request.Context(), getWorkspaceScope(context).workspaceID, id)
+	if err != nil {
+		s.logger.Error("failed to delete notification channel", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete notification channel"})
+		return
+	}
+	if !deleted {
+		context.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+		return
+	}
+	context.Status(http.StatusNoContent)
+}
+
+// validateNotificationChannel checks that the channel has an https webhook url, or for slack a bot token and a slack channel
+func validateNotificationChannel(channel api.NotificationChannel) error {
+	if channel.SlackBotToken != "" || channel.SlackChannel != "" {
+		if channel.Type != api.NotificationChannelTypeSlack {
+			return errors.New("slackBotToken and slackChannel are only supported by slack channels")
+		}
+		if channel.WebhookUrl != "" {
+			return errors.New("either webhookUrl or slackBotToken and slackChannel must be set, not both")
+		}
+		if !strings.HasPrefix(channel.SlackBotToken, "xoxb-") {
+			return errors.New("slackBotToken must be a bot token starting with xoxb-")
+		}
+		if channel.SlackChannel == "" {
+			return errors.New("slackChannel is required with slackBotToken")
+		}
+		return nil
+	}
+	parsedUrl, err := url.Parse(channel.WebhookUrl)
+	if err != nil || parsedUrl.Scheme != "https" || parsedUrl.Host == "" {
+		return errors.New("webhookUrl must be an https url")
+	}
+	return nil
+}
+
+// parseIncidentChangeEventTypes parses the event types a channel opts in to, a channel is notified of new incidents by default
+func parseIncidentChangeEventTypes(eventTypes []string) (api.IncidentChangeEventTypeArray, error) {
+	if len(eventTypes) == 0 {
+		return api.IncidentChangeEventTypeArray{api.IncidentChangeEventCreated}, nil
+	}
+	events := make(api.IncidentChangeEventTypeArray, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		event, err := api.ParseIncidentChangeEventType(eventType)
+		if err != nil {
+			return nil, err
+		}
+		if !events.Contains(event) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/publicnet"
+	"github.com/metoro-io/statusphere/common/status_pages"
+	"github.com/metoro-io/statusphere/scraper/providerset"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// testScrapeTimeout bounds how long a test scrape can take, every provider may be tried in turn
+const testScrapeTimeout = 30 * time.Second
+
+// statusPageScraper scrapes the current incidents of a status page like the scraper does
+type statusPageScraper interface {
+	ScrapeStatusPageCurrent(ctx context.Context, page api.StatusPage) ([]api.Incident, string, error)
+}
+
+// AdminStatusPageRequest is the definition of a status page, the scraping state is managed by statusphere
+type AdminStatusPageRequest struct {
+	URL                   string         `json:"url"`
+	Name                  string         `json:"name"`
+	PreferredScraper      string         `json:"preferredScraper"`
+	Method                api.HttpMethod `json:"httpMethod"`
+	Headers               api.JSONMap    `json:"headers"`
+	RequestPayload        api.JSONStruct `json:"payload"`
+	ValidationRules       api.JSONStruct `json:"rules"`
+	SlaTarget             *float64       `json:"slaTarget"`
+	ScrapeIntervalSeconds int            `json:"scrapeIntervalSeconds"`
+}
+
+func (r AdminStatusPageRequest) toStatusPage() api.StatusPage {
+	return api.StatusPage{
+		URL:                   strings.TrimSpace(r.URL),
+		Name:                  strings.TrimSpace(r.Name),
+		PreferredScraper:      r.PreferredScraper,
+		Method:                r.Method,
+		Headers:               r.Headers,
+		RequestPayload:        r.RequestPayload,
+		ValidationRules:       r.ValidationRules,
+		SlaTarget:             r.SlaTarget,
+		ScrapeIntervalSeconds: r.ScrapeIntervalSeconds,
+		Source:                api.StatusPageSourceAdmin,
+	}
+}
+
+type AdminStatusPagesResponse struct {
+	StatusPages []api.AdminStatusPage `json:"statusPages"`
+}
+
+type TestScrapeResponse struct {
+	// Provider is the provider that produced the incidents, or the last one tried if every provider failed
+	Provider  string         `json:"provider"`
+	Incidents []api.Incident `json:"incidents"`
+	// Error is set if the status page could not be scraped, the REST provider still returns the incident it would record
+	Error string `json:"error,omitempty"`
+}
+
+// adminListStatusPages is a handler for the GET /admin/statusPages endpoint.
+// It returns every status page with its full definition, including the headers
+func (s *Server) adminListStatusPages(context *gin.Context) {
+	statusPages, err := s.dbClient.GetAllStatusPages(context.Request.Context())
+	if err != nil {
+		s.logger.Error("failed to get status pages", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status pages"})
+		return
+	}
+	response := AdminStatusPagesResponse{StatusPages: make([]api.AdminStatusPage, 0, len(statusPages))}
+	for _, statusPage := range statusPages {
+		response.StatusPages = append(response.StatusPages, api.NewAdminStatusPage(statusPage))
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// adminCreateStatusPage is a handler for the POST /admin/statusPages endpoint.
+// The body is an AdminStatusPageRequest, it returns a 409 if a status page with the url already exists
+// The status page is scraped by the scraper on its next poll
+func (s *Server) adminCreateStatusPage(context *gin.Context) {
+	statusPage, ok := s.bindStatusPageDefinition(context)
+	if !ok {
+		return
+	}
+
+	err := s.dbClient.CreateStatusPage(context.Request.Context(), statusPage)
+	if errors.Is(err, db.ErrStatusPageExists) {
+		context.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to create status page", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create status page"})
+		return
+	}
+	s.respondWithStoredStatusPage(context, http.StatusCreated, statusPage.URL)
+}
+
+// adminUpdateStatusPage is a handler for the PUT /admin/statusPages endpoint.
+// It has a required query parameter of statusPageUrl, the body is an AdminStatusPageRequest with the same url
+// The whole definition is replaced and the status page is managed by the admin api from then on,
+// so it is not removed when it leaves common/status_pages
+func (s *Server) adminUpdateStatusPage(context *gin.Context) {
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+	statusPage, ok := s.bindStatusPageDefinition(context)
+	if !ok {
+		return
+	}
+	if statusPage.URL != statusPageUrl {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "the url of a status page cannot be changed"})
+		return
+	}
+
+	found, err := s.dbClient.UpdateStatusPageDefinition(context.Request.Context(), statusPage)
+	if err != nil {
+		s.logger.Error("failed to update status page", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status page"})
+		return
+	}
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+	s.respondWithStoredStatusPage(context, http.StatusOK, statusPage.URL)
+}
+
+// adminDeleteStatusPage is a handler for the DELETE /admin/statusPages endpoint.
+// It has a required query parameter of statusPageUrl, the incidents of the status page are kept
+// A status page defined in common/status_pages comes back on the next start of the scraper
+func (s *Server) adminDeleteStatusPage(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+
+	statusPage, err := s.dbClient.GetStatusPage(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get status page", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+		return
+	}
+	if statusPage == nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+	if err := s.dbClient.DeleteStatusPage(ctx, statusPageUrl); err != nil {
+		s.logger.Error("failed to delete status page", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete status page"})
+		return
+	}
+	s.statusPageCache.Delete(statusPageUrl)
+	s.invalidateIncidentCaches(statusPageUrl)
+	context.Status(http.StatusNoContent)
+}
+
+// adminTestScrape is a handler for the POST /admin/statusPages/testScrape endpoint.
+// The body is an AdminStatusPageRequest, or the query parameter statusPageUrl selects a stored status page.
+// It scrapes the current incidents of the status page like the scraper would, without storing anything
+// Environment placeholders in the headers are sent as they are, the environment of the apiserver is never sent
+// Status pages on private, loopback and link-local addresses are refused unless the config allows them
+func (s *Server) adminTestScrape(context *gin.Context) {
+	var statusPage api.StatusPage
+	if statusPageUrl := context.Query("statusPageUrl"); statusPageUrl != "" {
+		stored, err := s.dbClient.GetStatusPage(context.Request.Context(), statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to get status page", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+			return
+		}
+		if stored == nil {
+			context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+			return
+		}
+		statusPage = *stored
+	} else {
+		var ok bool
+		statusPage, ok = s.bindStatusPageDefinition(context)
+		if !ok {
+			return
+		}
+	}
+
+	if !s.config.TestScrapeAllowPrivateTargets {
+		if err := publicnet.CheckUrl(context.Request.Context(), statusPage.URL); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "test scrapes of private addresses are not allowed, " + err.Error()})
+			return
+		}
+	}
+
+	context.JSON(http.StatusOK, s.testScrape(context.Request.Context(), statusPage))
+}
+
+func (s *Server) testScrape(ctx context.Context, statusPage api.StatusPage) TestScrapeResponse {
+	ctx, cancel := context.WithTimeout(ctx, testScrapeTimeout)
+	defer cancel()
+	incidents, provider, err := s.scraper.ScrapeStatusPageCurrent(ctx, statusPage)
+	response := TestScrapeResponse{Provider: provider, Incidents: incidents}
+	if response.Incidents == nil {
+		response.Incidents = []api.Incident{}
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	return response
+}
+
+// bindStatusPageDefinition parses and validates the status page definition of the request body
+// It responds with a 400 and returns false if the definition is invalid
+func (s *Server) bindStatusPageDefinition(context *gin.Context) (api.StatusPage, bool) {
+	var request AdminStatusPageRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return api.StatusPage{}, false
+	}
+	statusPage := request.toStatusPage()
+	if err := status_pages.Validate(statusPage, providerset.ProviderNames()); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return api.StatusPage{}, false
+	}
+	return statusPage, true
+}
+
+// respondWithStoredStatusPage responds with the status page as stored, and caches it right away for this apiserver
+func (s *Server) respondWithStoredStatusPage(context *gin.Context, code int, statusPageUrl string) {
+	statusPage, err := s.dbClient.GetStatusPage(context.Request.Context(), statusPageUrl)
+	if err != nil || statusPage == nil {
+		s.logger.Error("failed to get stored status page", zap.Error(err), zap.String("url", statusPageUrl))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stored status page"})
+		return
+	}
+	s.statusPageCache.Set(statusPageUrl, *statusPage, cache.NoExpiration)
+	context.JSON(code, api.NewAdminStatusPage(*statusPage))
+}
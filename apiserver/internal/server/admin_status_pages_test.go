@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
+	"go.uber.org/zap"
+)
+
+func postTestScrape(t *testing.T, s *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, apiV1Prefix+"/admin/statusPages/testScrape", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	recorder := httptest.NewRecorder()
+	s.router().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestTestScrapeDoesNotExpandEnvironmentPlaceholders(t *testing.T) {
+	t.Setenv("STATUSPHERE_TEST_SECRET", "secret")
+	var authorization string
+	statusPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"status": "OK"}`))
+	}))
+	defer statusPage.Close()
+
+	// The status page is on the loopback address
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}, AdminToken: "admin-token", TestScrapeAllowPrivateTargets: true}, nil)
+	recorder := postTestScrape(t, s, `{"url": "`+statusPage.URL+`", "name": "Example", "preferredScraper": "REST", "httpMethod": "GET",
+		"headers": {"Authorization": "Bearer {env.STATUSPHERE_TEST_SECRET}"}}`)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var response TestScrapeResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Error != "" {
+		t.Fatalf("unexpected response %s: %v", recorder.Body, err)
+	}
+	if authorization != "Bearer {env.STATUSPHERE_TEST_SECRET}" {
+		t.Errorf("expected the placeholder to be sent as it is, got %q", authorization)
+	}
+}
+
+func TestTestScrapeRefusesPrivateTargets(t *testing.T) {
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}, AdminToken: "admin-token"}, nil)
+	for _, target := range []string{"http://127.0.0.1:8888/health", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5", "http://[::1]:5432"} {
+		recorder := postTestScrape(t, s, `{"url": "`+target+`", "name": "Example", "preferredScraper": "REST", "httpMethod": "GET"}`)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected %s to be refused, got %d: %s", target, recorder.Code, recorder.Body)
+		}
+	}
+}
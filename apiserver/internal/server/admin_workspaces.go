@@ -0,0 +1,203 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type AdminWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type AdminWorkspaceResponse struct {
+	Workspace api.Workspace `json:"workspace"`
+}
+
+type AdminWorkspacesResponse struct {
+	Workspaces []api.Workspace `json:"workspaces"`
+}
+
+type AdminSubscriptionsRequest struct {
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type AdminSubscriptionsResponse struct {
+	// AllStatusPages is true for the default workspace, which sees every status page without subscribing
+	AllStatusPages bool     `json:"allStatusPages"`
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+// adminListWorkspaces is a handler for the GET /admin/workspaces endpoint.
+func (s *Server) adminListWorkspaces(context *gin.Context) {
+	workspaces, err := s.dbClient.ListWorkspaces(context.Request.Context())
+	if err != nil {
+		s.logger.Error("failed to list workspaces", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list workspaces"})
+		return
+	}
+	if workspaces == nil {
+		workspaces = []api.Workspace{}
+	}
+	context.JSON(http.StatusOK, AdminWorkspacesResponse{Workspaces: workspaces})
+}
+
+// adminCreateWorkspace is a handler for the POST /admin/workspaces endpoint.
+// The body is an AdminWorkspaceRequest, it returns a 409 if a workspace with the name already exists
+// The workspace starts without status pages, api keys for it are created with the workspace field of AdminApiKeyRequest
+func (s *Server) adminCreateWorkspace(context *gin.Context) {
+	var request AdminWorkspaceRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if !nameRegex.MatchString(name) {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to 63 lower case letters, digits and dashes, starting with a letter or a digit"})
+		return
+	}
+
+	workspace := api.Workspace{Name: name}
+	err := s.dbClient.CreateWorkspace(context.Request.Context(), &workspace)
+	if errors.Is(err, db.ErrWorkspaceExists) {
+		context.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to create workspace", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create workspace"})
+		return
+	}
+	context.JSON(http.StatusCreated, AdminWorkspaceResponse{Workspace: workspace})
+}
+
+// adminDeleteWorkspace is a handler for the DELETE /admin/workspaces/:name endpoint.
+// The subscriptions, service groups, notification channels and api keys of the workspace are deleted with it
+// The default workspace cannot be deleted
+func (s *Server) adminDeleteWorkspace(context *gin.Context) {
+	name := context.Param("name")
+	if name == api.DefaultWorkspaceName {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "the default workspace cannot be deleted"})
+		return
+	}
+	workspace, err := s.dbClient.GetWorkspaceByName(context.Request.Context(), name)
+	if err != nil {
+		s.logger.Error("failed to get workspace", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workspace"})
+		return
+	}
+	if workspace == nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+		return
+	}
+
+	deleted, err := s.dbClient.DeleteWorkspace(context.Request.Context(), workspace.ID)
+	if err != nil {
+		s.logger.Error("failed to delete workspace", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete workspace"})
+		return
+	}
+	if !deleted {
+		context.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+		return
+	}
+	s.workspaceStatusPageCache.Delete(strconv.FormatInt(workspace.ID, 10))
+	context.Status(http.StatusNoContent)
+}
+
+// adminListSubscriptions is a handler for the GET /admin/subscriptions endpoint.
+// It returns the urls of the status pages the workspace subscribed to
+func (s *Server) adminListSubscriptions(context *gin.Context) {
+	scope := getWorkspaceScope(context)
+	if scope.isDefault() {
+		context.JSON(http.StatusOK, AdminSubscriptionsResponse{AllStatusPages: true, StatusPageUrls: []string{}})
+		return
+	}
+	statusPageUrls, err := s.dbClient.GetWorkspaceStatusPageUrls(context.Request.Context(), scope.workspaceID)
+	if err != nil {
+		s.logger.Error("failed to get subscriptions", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subscriptions"})
+		return
+	}
+	if statusPageUrls == nil {
+		statusPageUrls = []string{}
+	}
+	context.JSON(http.StatusOK, AdminSubscriptionsResponse{StatusPageUrls: statusPageUrls})
+}
+
+// adminAddSubscriptions is a handler for the POST /admin/subscriptions endpoint.
+// The body is an AdminSubscriptionsRequest, every status page must be known to statusphere
+// The status pages are not scraped again, every workspace shares the incidents statusphere already has
+func (s *Server) adminAddSubscriptions(context *gin.Context) {
+	scope := getWorkspaceScope(context)
+	if scope.isDefault() {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "the default workspace sees every status page"})
+		return
+	}
+	var request AdminSubscriptionsRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if len(request.StatusPageUrls) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "at least one status page url is required"})
+		return
+	}
+	statusPageUrls := make([]string, 0, len(request.StatusPageUrls))
+	for _, statusPageUrl := range request.StatusPageUrls {
+		statusPageUrl = strings.TrimSpace(statusPageUrl)
+		_, found, err := s.getStatusPageFromCache(statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to get status page from cache", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+			return
+		}
+		if !found {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "status page " + statusPageUrl + " not known to statusphere"})
+			return
+		}
+		statusPageUrls = append(statusPageUrls, statusPageUrl)
+	}
+
+	if err := s.dbClient.AddWorkspaceStatusPages(context.Request.Context(), scope.workspaceID, statusPageUrls); err != nil {
+		s.logger.Error("failed to add subscriptions", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add subscriptions"})
+		return
+	}
+	s.workspaceStatusPageCache.Delete(strconv.FormatInt(scope.workspaceID, 10))
+	s.adminListSubscriptions(context)
+}
+
+// adminRemoveSubscription is a handler for the DELETE /admin/subscriptions endpoint.
+// The status page is given by the statusPageUrl query parameter, the service groups of the workspace keep it as a STALE member
+func (s *Server) adminRemoveSubscription(context *gin.Context) {
+	scope := getWorkspaceScope(context)
+	if scope.isDefault() {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "the default workspace sees every status page"})
+		return
+	}
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+
+	removed, err := s.dbClient.RemoveWorkspaceStatusPage(context.Request.Context(), scope.workspaceID, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to remove subscription", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove subscription"})
+		return
+	}
+	if !removed {
+		context.JSON(http.StatusNotFound, gin.H{"error": "the workspace is not subscribed to the status page"})
+		return
+	}
+	s.workspaceStatusPageCache.Delete(strconv.FormatInt(scope.workspaceID, 10))
+	context.Status(http.StatusNoContent)
+}
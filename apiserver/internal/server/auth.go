@@ -0,0 +1,175 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+)
+
+const principalContextKey = "principal"
+
+// apiKeyPrefix starts every api key, so leaked keys are easy to recognise
+const apiKeyPrefix = "sp_"
+
+// apiKeyPrefixLength is how much of a key is stored in clear to recognise it
+const apiKeyPrefixLength = 8
+
+// principal is who makes a request
+type principal struct {
+	// name identifies the principal in the logs, and its rate limit bucket
+	name   string
+	scopes api.ApiKeyScopes
+	// workspaceID is the workspace the requests of the principal are scoped to
+	workspaceID int64
+	// rateLimitPerMinute is 0 for principals that are not rate limited
+	rateLimitPerMinute int
+}
+
+// generateApiKey returns a new random api key along with the prefix and the hash that are stored
+func generateApiKey() (string, string, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	return key, key[:apiKeyPrefixLength], hashApiKey(key), nil
+}
+
+func hashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// getRequestApiKey returns the api key of the request, from the Authorization bearer token or the X-API-Key header
+func getRequestApiKey(context *gin.Context) string {
+	if token, found := strings.CutPrefix(context.GetHeader("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(context.GetHeader("X-API-Key"))
+}
+
+// setCacheControl lets the response of the request be cached for maxAge
+// Responses to requests with an api key depend on its workspace, so only the client can cache them
+func setCacheControl(context *gin.Context, maxAge time.Duration) {
+	visibility := "public"
+	if getRequestApiKey(context) != "" {
+		visibility = "private"
+	}
+	context.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds())))
+	context.Header("Vary", "Authorization, X-API-Key")
+}
+
+// authenticate finds the principal of the request and applies its rate limit
+// Requests without an api key are anonymous if the anonymous read tier is enabled, they are rate limited per client ip
+// Known api keys are cached for a minute, so a revoked key can keep working for that long
+// Client ips that send too many unknown api keys are rate limited before their keys are looked up
+func (s *Server) authenticate() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		p, ok := s.getPrincipal(context)
+		if !ok {
+			return
+		}
+		context.Set(principalContextKey, p)
+
+		if p.rateLimitPerMinute > 0 {
+			allowed, remaining, retryAfter := s.rateLimiter.allow(p.name, p.rateLimitPerMinute, time.Now())
+			context.Header("X-RateLimit-Limit", strconv.Itoa(p.rateLimitPerMinute))
+			context.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !allowed {
+				context.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				context.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+				return
+			}
+		}
+		context.Next()
+	}
+}
+
+// getPrincipal returns the principal of the request, it responds with a 401 and returns false if there is none
+func (s *Server) getPrincipal(context *gin.Context) (principal, bool) {
+	key := getRequestApiKey(context)
+	if key == "" {
+		if !s.config.AnonymousRead {
+			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "an api key is required"})
+			return principal{}, false
+		}
+		return principal{
+			name:               "anonymous:" + context.ClientIP(),
+			scopes:             api.ApiKeyScopes{api.ApiKeyScopeRead},
+			workspaceID:        api.DefaultWorkspaceID,
+			rateLimitPerMinute: s.config.AnonymousRateLimitPerMinute,
+		}, true
+	}
+
+	if s.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.config.AdminToken)) == 1 {
+		return principal{name: "admin-token", scopes: api.ApiKeyScopes{api.ApiKeyScopeAdmin}, workspaceID: api.DefaultWorkspaceID}, true
+	}
+
+	keyHash := hashApiKey(key)
+	if cached, found := s.apiKeyCache.Get(keyHash); found {
+		if p, ok := cached.(principal); ok {
+			return p, true
+		}
+	}
+	// The keys that are not cached are looked up in the database, a client ip that keeps sending unknown keys is
+	// refused before the lookup. There is no such limit when it is 0.
+	invalidKeyClient := "invalid-key:" + context.ClientIP()
+	invalidKeyLimit := s.config.InvalidApiKeyRateLimitPerMinute
+	if invalidKeyLimit > 0 {
+		if wait := s.rateLimiter.wait(invalidKeyClient, invalidKeyLimit, time.Now()); wait > 0 {
+			context.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			context.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many invalid api keys"})
+			return principal{}, false
+		}
+	}
+	apiKey, err := s.dbClient.GetApiKeyByHash(context.Request.Context(), keyHash)
+	if err != nil {
+		s.logger.Error("failed to get api key", zap.Error(err))
+		context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check the api key"})
+		return principal{}, false
+	}
+	if apiKey == nil {
+		if invalidKeyLimit > 0 {
+			s.rateLimiter.allow(invalidKeyClient, invalidKeyLimit, time.Now())
+		}
+		context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		return principal{}, false
+	}
+	p := principal{
+		name:               fmt.Sprintf("key:%d", apiKey.ID),
+		scopes:             apiKey.Scopes,
+		workspaceID:        apiKey.WorkspaceID,
+		rateLimitPerMinute: apiKey.RateLimitPerMinute,
+	}
+	if p.rateLimitPerMinute == 0 {
+		p.rateLimitPerMinute = s.config.ApiKeyRateLimitPerMinute
+	}
+	s.apiKeyCache.Set(keyHash, p, cache.DefaultExpiration)
+	return p, true
+}
+
+// requireScope rejects the requests whose principal does not have the scope, it must run after authenticate
+func requireScope(scope api.ApiKeyScope) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, _ := context.Get(principalContextKey)
+		p, ok := value.(principal)
+		if !ok || !p.scopes.Has(scope) {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("the %s scope is required", scope)})
+			return
+		}
+		context.Next()
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+func TestRateLimiterRefillsOverTime(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		if allowed, _, _ := limiter.allow("client", 60, now); !allowed {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+
+	allowed, _, retryAfter := limiter.allow("client", 60, now)
+	if allowed {
+		t.Fatal("expected the bucket to be empty")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("expected to retry after a second, got %s", retryAfter)
+	}
+	if allowed, _, _ := limiter.allow("other", 60, now); !allowed {
+		t.Error("expected another client to have its own bucket")
+	}
+	if allowed, _, _ := limiter.allow("client", 60, now.Add(time.Second)); !allowed {
+		t.Error("expected a token after a second")
+	}
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	if !(api.ApiKeyScopes{api.ApiKeyScopeAdmin}).Has(api.ApiKeyScopeRead) {
+		t.Error("expected the admin scope to give read access")
+	}
+	if (api.ApiKeyScopes{api.ApiKeyScopeRead}).Has(api.ApiKeyScopeAdmin) {
+		t.Error("expected the read scope not to give admin access")
+	}
+}
+
+func TestGenerateApiKey(t *testing.T) {
+	key, prefix, hash, err := generateApiKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) || !strings.HasPrefix(key, prefix) || hash != hashApiKey(key) {
+		t.Errorf("unexpected key %q, prefix %q and hash %q", key, prefix, hash)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := NewServer(nil, config.Config{AdminToken: "secret", AnonymousRead: true, AnonymousRateLimitPerMinute: 1}, nil)
+	r := gin.New()
+	r.Use(s.authenticate())
+	r.GET("/read", requireScope(api.ApiKeyScopeRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/admin", requireScope(api.ApiKeyScopeAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(path string, token string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if code := request("/admin", "secret").Code; code != http.StatusOK {
+		t.Errorf("expected the admin token to reach the admin endpoints, got %d", code)
+	}
+	if code := request("/admin", "").Code; code != http.StatusForbidden {
+		t.Errorf("expected anonymous requests to be forbidden on the admin endpoints, got %d", code)
+	}
+	// The forbidden request above used the only token of the anonymous client
+	if code := request("/read", "").Code; code != http.StatusTooManyRequests {
+		t.Errorf("expected the second anonymous request to be rate limited, got %d", code)
+	}
+	if retryAfter := request("/read", "").Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("expected to retry after 60 seconds, got %q", retryAfter)
+	}
+}
+
+func TestInvalidApiKeysAreRateLimitedBeforeTheirLookup(t *testing.T) {
+	// The server has no database, a lookup would fail the test
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}, AdminToken: "admin-token", InvalidApiKeyRateLimitPerMinute: 2}, nil)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		s.rateLimiter.allow("invalid-key:192.0.2.1", 2, now)
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", apiV1Prefix+"/statusPages", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Authorization", "Bearer sp_unknown")
+	s.router().ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") != "30" {
+		t.Errorf("expected the unknown key to be rate limited, got %d: %s", recorder.Code, recorder.Body)
+	}
+
+	// The admin token is not looked up
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", apiV1Prefix+"/statusPages", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Authorization", "Bearer admin-token")
+	s.router().ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected the admin token to be accepted, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestAnonymousRateLimitIgnoresForwardedForFromUntrustedProxies(t *testing.T) {
+	request := func(r http.Handler, forwardedFor string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", apiV1Prefix+"/statusPages", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		r.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	cfg := config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}, AnonymousRead: true, AnonymousRateLimitPerMinute: 1}
+
+	r := NewServer(zap.NewNop(), cfg, nil).router()
+	request(r, "203.0.113.1")
+	if code := request(r, "203.0.113.2"); code != http.StatusTooManyRequests {
+		t.Errorf("expected a forged X-Forwarded-For to share the bucket of the connection, got %d", code)
+	}
+
+	cfg.TrustedProxies = []string{"192.0.2.1"}
+	r = NewServer(zap.NewNop(), cfg, nil).router()
+	request(r, "203.0.113.1")
+	if code := request(r, "203.0.113.2"); code == http.StatusTooManyRequests {
+		t.Errorf("expected the clients behind a trusted proxy to have their own buckets, got %d", code)
+	}
+}
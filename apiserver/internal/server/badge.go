@@ -0,0 +1,247 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/uptime"
+	"go.uber.org/zap"
+)
+
+// statusBadgeMaxAge is how long badge proxies and browsers can cache a status badge, the status changes at most every scrape
+const statusBadgeMaxAge = time.Minute
+
+// uptimeBadgeMaxAge is how long an uptime badge can be cached, the uptime is rolled up once a day
+const uptimeBadgeMaxAge = time.Hour
+
+const defaultUptimeBadgeDays = 30
+
+const (
+	badgeColorBrightGreen = "#4c1"
+	badgeColorGreen       = "#97ca00"
+	badgeColorYellow      = "#dfb317"
+	badgeColorOrange      = "#fe7d37"
+	badgeColorRed         = "#e05d44"
+	badgeColorBlue        = "#007ec6"
+	badgeColorGrey        = "#9f9f9f"
+)
+
+var statusLevelBadges = map[StatusLevel]struct {
+	message string
+	color   string
+}{
+	StatusLevelOperational:    {"operational", badgeColorBrightGreen},
+	StatusLevelMaintenance:    {"maintenance", badgeColorBlue},
+	StatusLevelMinorOutage:    {"minor outage", badgeColorYellow},
+	StatusLevelMajorOutage:    {"major outage", badgeColorOrange},
+	StatusLevelCriticalOutage: {"critical outage", badgeColorRed},
+	StatusLevelStale:          {"stale", badgeColorGrey},
+}
+
+// statusBadge is a handler for the /badge.svg endpoint.
+// It has a required query parameter of statusPageUrl
+// It has an optional query parameter of component, to only take the incidents affecting the component into account
+// It has an optional query parameter of label (default is the name of the status page and the component)
+// It renders the level of the currentStatus endpoint as a shields.io style badge
+func (s *Server) statusBadge(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+	component := context.Query("component")
+
+	statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+
+	var incidents []api.Incident
+	if statusPage.IsIndexed {
+		incidents, found, err = s.getCurrentIncidents(ctx, statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to get current incidents", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get current incidents"})
+			return
+		}
+		if !found {
+			context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+			return
+		}
+	}
+	level, _ := computeStatusLevel(statusPage, componentIncidents(incidents, component), time.Now())
+
+	label := statusPage.Name
+	if component != "" {
+		label += " " + component
+	}
+	badge := statusLevelBadges[level]
+	s.respondWithBadge(context, statusBadgeMaxAge, context.DefaultQuery("label", label), badge.message, badge.color)
+}
+
+// uptimeBadge is a handler for the /badge/uptime.svg endpoint.
+// It has a required query parameter of statusPageUrl
+// It has an optional query parameter of days (default is 30), the uptime is the one of the last days up to today
+// It has optional query parameters of component (default is the whole status page) and label (default is "uptime 30d")
+// The uptime is served from the daily rollups like the uptime endpoint
+func (s *Server) uptimeBadge(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+	days := defaultUptimeBadgeDays
+	if daysStr := context.Query("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 || days > maxUptimeDays {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be an integer between 1 and %d", maxUptimeDays)})
+			return
+		}
+	}
+	component := context.Query("component")
+
+	statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+
+	to := uptime.StartOfDay(time.Now())
+	from := to.AddDate(0, 0, -(days - 1))
+	uptimeDays, err := s.getUptimeDays(ctx, statusPageUrl, component, from, to)
+	if err != nil {
+		s.logger.Error("failed to get uptime from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get uptime from database"})
+		return
+	}
+
+	message, color := "no data", badgeColorGrey
+	if len(uptimeDays) > 0 {
+		_, _, _, percentage := uptime.Aggregate(uptimeDays)
+		message, color = formatUptimePercentage(percentage), uptimeBadgeColor(percentage, statusPage.SlaTarget)
+	}
+	label := fmt.Sprintf("uptime %dd", days)
+	s.respondWithBadge(context, uptimeBadgeMaxAge, context.DefaultQuery("label", label), message, color)
+}
+
+// componentIncidents returns the incidents affecting the component, or every incident if the component is empty
+func componentIncidents(incidents []api.Incident, component string) []api.Incident {
+	if component == "" {
+		return incidents
+	}
+	var filtered []api.Incident
+	for _, incident := range incidents {
+		for _, incidentComponent := range incident.Components {
+			if incidentComponent == component {
+				filtered = append(filtered, incident)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// formatUptimePercentage rounds the percentage down to two decimals, so a page with any outage is never shown at 100%
+func formatUptimePercentage(percentage float64) string {
+	return strconv.FormatFloat(math.Floor(percentage*100)/100, 'f', -1, 64) + "%"
+}
+
+// uptimeBadgeColor is red when the sla target of the status page is breached, otherwise it goes from green to red as the uptime drops
+func uptimeBadgeColor(percentage float64, slaTarget *float64) string {
+	if breached := slaBreached(percentage, slaTarget); breached != nil && *breached {
+		return badgeColorRed
+	}
+	switch {
+	case percentage >= 99.9:
+		return badgeColorBrightGreen
+	case percentage >= 99:
+		return badgeColorGreen
+	case percentage >= 95:
+		return badgeColorYellow
+	case percentage >= 90:
+		return badgeColorOrange
+	default:
+		return badgeColorRed
+	}
+}
+
+// respondWithBadge renders the badge with cache headers, it responds with a 304 if the client has the same badge already
+func (s *Server) respondWithBadge(context *gin.Context, maxAge time.Duration, label string, message string, color string) {
+	badge := renderBadge(label, message, color)
+	hash := sha256.Sum256(badge)
+	etag := `"` + hex.EncodeToString(hash[:8]) + `"`
+
+	setCacheControl(context, maxAge)
+	context.Header("ETag", etag)
+	if context.GetHeader("If-None-Match") == etag {
+		context.Status(http.StatusNotModified)
+		return
+	}
+	context.Data(http.StatusOK, "image/svg+xml; charset=utf-8", badge)
+}
+
+// badgeCharacterWidths are the widths of characters in 11px Verdana, which the badges are rendered with
+var badgeCharacterWidths = map[rune]float64{
+	'a': 6.6, 'b': 6.9, 'c': 5.7, 'd': 6.9, 'e': 6.6, 'f': 3.9, 'g': 6.9, 'h': 7.0, 'i': 3.0, 'j': 3.8, 'k': 6.5, 'l': 3.0, 'm': 10.7,
+	'n': 7.0, 'o': 6.7, 'p': 6.9, 'q': 6.9, 'r': 4.7, 's': 5.7, 't': 4.3, 'u': 7.0, 'v': 6.5, 'w': 9.0, 'x': 6.5, 'y': 6.5, 'z': 5.8,
+	'A': 7.5, 'B': 7.5, 'C': 7.7, 'D': 8.5, 'E': 6.9, 'F': 6.3, 'G': 8.5, 'H': 8.3, 'I': 4.6, 'J': 5.0, 'K': 7.6, 'L': 6.1, 'M': 9.3,
+	'N': 8.2, 'O': 8.7, 'P': 6.6, 'Q': 8.7, 'R': 7.7, 'S': 7.5, 'T': 6.8, 'U': 8.1, 'V': 7.5, 'W': 10.9, 'X': 7.5, 'Y': 6.8, 'Z': 7.5,
+	' ': 3.9, '.': 3.9, ',': 3.9, ':': 4.5, '-': 5.0, '_': 7.0, '/': 5.0, '%': 12.0, '(': 4.6, ')': 4.6,
+}
+
+// badgeTextWidth approximates the rendered width of the text, characters not in the table are as wide as a digit
+func badgeTextWidth(text string) float64 {
+	width := 0.0
+	for _, character := range text {
+		if characterWidth, ok := badgeCharacterWidths[character]; ok {
+			width += characterWidth
+		} else {
+			width += 7.0
+		}
+	}
+	return width
+}
+
+// renderBadge returns a flat shields.io style badge, the label on grey on the left and the message on the color on the right
+func renderBadge(label string, message string, color string) []byte {
+	const padding = 10
+	labelTextWidth := math.Round(badgeTextWidth(label))
+	messageTextWidth := math.Round(badgeTextWidth(message))
+	labelWidth := labelTextWidth + padding
+	messageWidth := messageTextWidth + padding
+	totalWidth := labelWidth + messageWidth
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]g" height="20" role="img" aria-label="%[2]s: %[3]s">`+
+		`<title>%[2]s: %[3]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]g" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[4]g" height="20" fill="#555"/><rect x="%[4]g" width="%[5]g" height="20" fill="%[6]s"/><rect width="%[1]g" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="11">`+
+		`<text x="%[7]g" y="15" fill="#010101" fill-opacity=".3" textLength="%[8]g">%[2]s</text><text x="%[7]g" y="14" textLength="%[8]g">%[2]s</text>`+
+		`<text x="%[9]g" y="15" fill="#010101" fill-opacity=".3" textLength="%[10]g">%[3]s</text><text x="%[9]g" y="14" textLength="%[10]g">%[3]s</text>`+
+		`</g></svg>`,
+		totalWidth, label, message, labelWidth, messageWidth, html.EscapeString(color),
+		labelWidth/2, labelTextWidth, labelWidth+messageWidth/2, messageTextWidth))
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestRenderBadgeEscapesText(t *testing.T) {
+	badge := string(renderBadge("<script>", "a & b", badgeColorRed))
+	if strings.Contains(badge, "<script>") || !strings.Contains(badge, "&lt;script&gt;") || !strings.Contains(badge, "a &amp; b") {
+		t.Errorf("expected the label and message to be escaped, got %s", badge)
+	}
+	if !strings.Contains(badge, `fill="#e05d44"`) {
+		t.Errorf("expected the message to be on the color, got %s", badge)
+	}
+}
+
+func TestComponentIncidents(t *testing.T) {
+	incidents := []api.Incident{
+		{Title: "api", Components: []string{"API", "Webhooks"}},
+		{Title: "dashboard", Components: []string{"Dashboard"}},
+		{Title: "everything"},
+	}
+	if got := componentIncidents(incidents, ""); len(got) != 3 {
+		t.Errorf("expected every incident without a component, got %d", len(got))
+	}
+	got := componentIncidents(incidents, "Webhooks")
+	if len(got) != 1 || got[0].Title != "api" {
+		t.Errorf("expected the incident affecting webhooks, got %+v", got)
+	}
+}
+
+func TestFormatUptimePercentage(t *testing.T) {
+	for percentage, expected := range map[float64]string{100: "100%", 99.999: "99.99%", 99.5: "99.5%", 87.126: "87.12%"} {
+		if got := formatUptimePercentage(percentage); got != expected {
+			t.Errorf("expected %v to be formatted as %s, got %s", percentage, expected, got)
+		}
+	}
+}
+
+func TestUptimeBadgeColor(t *testing.T) {
+	slaTarget := 99.95
+	if got := uptimeBadgeColor(99.99, nil); got != badgeColorBrightGreen {
+		t.Errorf("expected bright green, got %s", got)
+	}
+	if got := uptimeBadgeColor(99.92, &slaTarget); got != badgeColorRed {
+		t.Errorf("expected red when the sla target is breached, got %s", got)
+	}
+	if got := uptimeBadgeColor(96, nil); got != badgeColorYellow {
+		t.Errorf("expected yellow, got %s", got)
+	}
+}
+
+func TestStatusBadge(t *testing.T) {
+	router := newContractTestServer(t).router()
+	get := func(target string, ifNoneMatch string, apiKey string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := get("/api/v1/badge.svg?statusPageUrl=https://status.example.com", "", "")
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "image/svg+xml") {
+		t.Fatalf("expected an svg badge, got %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if recorder.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("unexpected cache control %q", recorder.Header().Get("Cache-Control"))
+	}
+	if recorder.Header().Get("Vary") != "Authorization, X-API-Key" {
+		t.Errorf("expected the badge to vary with the api key, got %q", recorder.Header().Get("Vary"))
+	}
+	if cacheControl := get("/api/v1/badge.svg?statusPageUrl=https://status.example.com", "", "admin-token").Header().Get("Cache-Control"); cacheControl != "private, max-age=60" {
+		t.Errorf("expected the badges of requests with an api key to be private, got %q", cacheControl)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, "Example: major outage") || !strings.Contains(body, badgeColorOrange) {
+		t.Errorf("expected a major outage badge, got %s", body)
+	}
+
+	recorder = get("/api/v1/badge.svg?statusPageUrl=https://status.example.com&component=Dashboard&label=dashboard", "", "")
+	if body := recorder.Body.String(); !strings.Contains(body, "dashboard: operational") {
+		t.Errorf("expected the component to be operational with the label, got %s", body)
+	}
+
+	etag := recorder.Header().Get("ETag")
+	recorder = get("/api/v1/badge.svg?statusPageUrl=https://status.example.com&component=Dashboard&label=dashboard", etag, "")
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("expected a 304 for the same etag, got %d", recorder.Code)
+	}
+}
@@ -2,36 +2,128 @@ package server
 
 import (
 	"context"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/db"
 	"github.com/patrickmn/go-cache"
 	"go.uber.org/zap"
-	"time"
 )
 
+// StartCaches keeps the caches up to date with the database
+// The affected keys are invalidated when the database publishes a change, the periodic reconcile only catches up on missed changes
 func (s *Server) StartCaches(ctx context.Context) {
 	go s.updateStatusPageCache(ctx)
+	go s.listenChanges(ctx)
 }
 
-const statusPageCacheRefreshInterval = 1 * time.Minute
+const statusPageCacheReconcileInterval = 15 * time.Minute
+
+// changeListenerRetryInterval is how long to wait before listening again after the listening connection failed
+const changeListenerRetryInterval = 5 * time.Second
 
 func (s *Server) updateStatusPageCache(ctx context.Context) {
-	ticker := time.NewTicker(statusPageCacheRefreshInterval)
-	s.updateStatusPageCacheInner(ctx)
+	ticker := time.NewTicker(statusPageCacheReconcileInterval)
+	s.reconcileStatusPageCache(ctx)
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case <-ticker.C:
-			s.updateStatusPageCacheInner(ctx)
+			s.reconcileStatusPageCache(ctx)
 		}
 	}
 }
 
-func (s *Server) updateStatusPageCacheInner(ctx context.Context) {
+// reconcileStatusPageCache replaces the content of the status page cache with the status pages in the database
+func (s *Server) reconcileStatusPageCache(ctx context.Context) {
 	statusPages, err := s.dbClient.GetAllStatusPages(ctx)
 	if err != nil {
 		s.logger.Error("failed to get status pages", zap.Error(err))
 		return
 	}
 
+	known := make(map[string]bool, len(statusPages))
 	for _, statusPage := range statusPages {
-		s.statusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
+		known[statusPage.URL] = true
+		s.statusPageCache.Set(statusPage.URL, statusPage, cache.NoExpiration)
+	}
+	for url := range s.statusPageCache.Items() {
+		if !known[url] {
+			s.statusPageCache.Delete(url)
+			s.invalidateIncidentCaches(url)
+		}
+	}
+	s.statusPageCacheLoaded.Store(true)
+}
+
+func (s *Server) listenChanges(ctx context.Context) {
+	incidentChanges := make(chan db.IncidentChange, incidentChangeBatchSize)
+	defer close(incidentChanges)
+	go batchIncidentChanges(incidentChanges, incidentChangeBatchWindow, incidentChangeBatchSize, func(changes []db.IncidentChange) {
+		s.publishIncidentChanges(ctx, changes)
+	})
+
+	for {
+		err := s.dbClient.ListenChanges(ctx, db.ChangeHandlers{
+			OnListening: func() {
+				// Changes made while nobody was listening were missed
+				s.incidentCache.Flush()
+				s.currentIncidentCache.Flush()
+				s.reconcileStatusPageCache(ctx)
+			},
+			OnIncidentChange: func(change db.IncidentChange) {
+				s.invalidateIncidentCaches(change.StatusPageUrl)
+				incidentChanges <- change
+			},
+			OnStatusPageChange: func(change db.StatusPageChange) {
+				s.handleStatusPageChange(ctx, change)
+			},
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Error("stopped listening for changes", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(changeListenerRetryInterval):
+		}
+	}
+}
+
+func (s *Server) handleStatusPageChange(ctx context.Context, change db.StatusPageChange) {
+	if change.Operation == db.StatusPageTruncated {
+		s.incidentCache.Flush()
+		s.currentIncidentCache.Flush()
+		s.reconcileStatusPageCache(ctx)
+		return
+	}
+
+	if change.Operation != db.StatusPageDeleted {
+		statusPage, err := s.dbClient.GetStatusPage(ctx, change.Url)
+		if err != nil {
+			s.logger.Error("failed to get changed status page", zap.Error(err), zap.String("url", change.Url))
+			// Better to serve nothing than an outdated status page
+			s.statusPageCache.Delete(change.Url)
+			return
+		}
+		if statusPage != nil {
+			s.statusPageCache.Set(change.Url, *statusPage, cache.NoExpiration)
+			return
+		}
+	}
+	s.statusPageCache.Delete(change.Url)
+	s.invalidateIncidentCaches(change.Url)
+}
+
+// invalidateIncidentCaches removes the cached incidents of the status page
+func (s *Server) invalidateIncidentCaches(statusPageUrl string) {
+	s.currentIncidentCache.Delete(statusPageUrl)
+	prefix := incidentCacheKey(statusPageUrl, nil)
+	for key := range s.incidentCache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			s.incidentCache.Delete(key)
+		}
 	}
 }
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+)
+
+// maintenanceCalendarPastDays is how long maintenance windows stay in the calendar after they ended,
+// calendars remove the events that leave the feed
+const maintenanceCalendarPastDays = 7
+
+const maxMaintenanceCalendarEvents = 500
+
+// defaultMaintenanceDuration is the length of the maintenance windows whose end is not announced
+const defaultMaintenanceDuration = time.Hour
+
+const icsDateTimeFormat = "20060102T150405Z"
+
+// icsMaxLineOctets is the longest a line of an iCalendar file can be before it is folded
+const icsMaxLineOctets = 75
+
+// maintenanceCalendar is a handler for the /maintenance.ics endpoint.
+// It has a required query parameter of statusPageUrl, which can be repeated to get a calendar of several status pages
+// It returns an iCalendar feed with an event per maintenance window that is upcoming, ongoing or ended in the last 7 days
+func (s *Server) maintenanceCalendar(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPages, ok := s.getRequestedStatusPages(context)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -maintenanceCalendarPastDays)
+	filter := db.IncidentFilter{
+		Impacts: []api.Impact{api.ImpactMaintenance},
+		From:    &from,
+		// The maintenance windows whose end is never announced would otherwise stay in the calendar forever
+		OpenEndedDuration: defaultMaintenanceDuration,
+		Limit:             maxMaintenanceCalendarEvents,
+	}
+	for _, statusPage := range statusPages {
+		filter.StatusPageUrls = append(filter.StatusPageUrls, statusPage.URL)
+	}
+	incidents, err := s.dbClient.ListIncidents(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to get maintenance incidents from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get maintenance incidents from database"})
+		return
+	}
+
+	setCacheControl(context, feedMaxAge)
+	context.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(renderMaintenanceCalendar(statusPages, incidents, now)))
+}
+
+func renderMaintenanceCalendar(statusPages []api.StatusPage, incidents []api.Incident, now time.Time) string {
+	names := make(map[string]string)
+	for _, statusPage := range statusPages {
+		names[statusPage.URL] = statusPage.Name
+	}
+	calendarName := "Statusphere maintenance"
+	if len(statusPages) == 1 {
+		calendarName = statusPages[0].Name + " maintenance"
+	}
+
+	var b strings.Builder
+	writeIcsLine(&b, "BEGIN", "VCALENDAR")
+	writeIcsLine(&b, "VERSION", "2.0")
+	writeIcsLine(&b, "PRODID", "-//Metoro//Statusphere//EN")
+	writeIcsLine(&b, "CALSCALE", "GREGORIAN")
+	writeIcsLine(&b, "METHOD", "PUBLISH")
+	writeIcsLine(&b, "X-WR-CALNAME", escapeIcsText(calendarName))
+	// Calendars that support it poll the feed as often as it can be cached
+	writeIcsLine(&b, "REFRESH-INTERVAL;VALUE=DURATION", fmt.Sprintf("PT%dM", int(feedMaxAge.Minutes())))
+	writeIcsLine(&b, "X-PUBLISHED-TTL", fmt.Sprintf("PT%dM", int(feedMaxAge.Minutes())))
+	from := now.AddDate(0, 0, -maintenanceCalendarPastDays)
+	for _, incident := range incidents {
+		if maintenanceEnd(incident).Before(from) {
+			continue
+		}
+		writeMaintenanceEvent(&b, incident, names[incident.StatusPageUrl], now)
+	}
+	writeIcsLine(&b, "END", "VCALENDAR")
+	return b.String()
+}
+
+// maintenanceEnd returns the end of a maintenance window, the default duration after its start when it is not announced
+func maintenanceEnd(incident api.Incident) time.Time {
+	if incident.EndTime != nil && incident.EndTime.After(incident.StartTime) {
+		return *incident.EndTime
+	}
+	return incident.StartTime.Add(defaultMaintenanceDuration)
+}
+
+func writeMaintenanceEvent(b *strings.Builder, incident api.Incident, statusPageName string, now time.Time) {
+	end := maintenanceEnd(incident)
+
+	description := ""
+	if incident.Description != nil {
+		description = *incident.Description
+	}
+	if len(incident.Components) > 0 {
+		description = strings.TrimSpace(description + "\n\nComponents: " + strings.Join(incident.Components, ", "))
+	}
+	if incident.DeepLink != "" {
+		description = strings.TrimSpace(description + "\n\n" + incident.DeepLink)
+	}
+
+	writeIcsLine(b, "BEGIN", "VEVENT")
+	writeIcsLine(b, "UID", fmt.Sprintf("incident-%d@statusphere.metoro.io", incident.ID))
+	writeIcsLine(b, "DTSTAMP", now.UTC().Format(icsDateTimeFormat))
+	writeIcsLine(b, "LAST-MODIFIED", incidentLastUpdated(incident).UTC().Format(icsDateTimeFormat))
+	writeIcsLine(b, "DTSTART", incident.StartTime.UTC().Format(icsDateTimeFormat))
+	writeIcsLine(b, "DTEND", end.UTC().Format(icsDateTimeFormat))
+	writeIcsLine(b, "SUMMARY", escapeIcsText(fmt.Sprintf("[%s] %s", statusPageName, incident.Title)))
+	if description != "" {
+		writeIcsLine(b, "DESCRIPTION", escapeIcsText(description))
+	}
+	if incident.DeepLink != "" {
+		writeIcsLine(b, "URL", incident.DeepLink)
+	}
+	writeIcsLine(b, "STATUS", "CONFIRMED")
+	writeIcsLine(b, "TRANSP", "TRANSPARENT")
+	writeIcsLine(b, "END", "VEVENT")
+}
+
+// escapeIcsText escapes a TEXT value of an iCalendar property
+func escapeIcsText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(text)
+}
+
+// writeIcsLine writes a content line, folded into lines of at most 75 octets without splitting a character
+func writeIcsLine(b *strings.Builder, name string, value string) {
+	line := name + ":" + value
+	octets := 0
+	for _, character := range line {
+		size := len(string(character))
+		if octets+size > icsMaxLineOctets {
+			// The continuation lines start with a space, which counts towards their length
+			b.WriteString("\r\n ")
+			octets = 1
+		}
+		b.WriteRune(character)
+		octets += size
+	}
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,579 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/apiserver/client"
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/stats"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// The contract tests check that the routes, the types and the responses of the handlers agree with openapi.json
+
+type openAPIDocument map[string]interface{}
+
+func loadOpenAPIDocument(t *testing.T) openAPIDocument {
+	t.Helper()
+	var document openAPIDocument
+	if err := json.Unmarshal(openAPISpecJSON, &document); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return document
+}
+
+func (d openAPIDocument) object(path ...string) map[string]interface{} {
+	var current interface{} = map[string]interface{}(d)
+	for _, key := range path {
+		object, _ := current.(map[string]interface{})
+		current = object[key]
+	}
+	object, _ := current.(map[string]interface{})
+	return object
+}
+
+// resolve follows the $ref of a schema, response or parameter
+func (d openAPIDocument) resolve(value map[string]interface{}) map[string]interface{} {
+	for {
+		ref, ok := value["$ref"].(string)
+		if !ok {
+			return value
+		}
+		value = d.object(strings.Split(strings.TrimPrefix(ref, "#/"), "/")...)
+	}
+}
+
+var ginParamRegex = regexp.MustCompile(`:([A-Za-z]+)`)
+
+func TestOpenAPISpecCoversEveryRoute(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}}, nil)
+
+	routes := map[string]bool{}
+	for _, route := range s.router().Routes() {
+		path, found := strings.CutPrefix(route.Path, apiV1Prefix)
+		if !found {
+			continue
+		}
+		routes[strings.ToLower(route.Method)+" "+ginParamRegex.ReplaceAllString(path, "{$1}")] = true
+	}
+
+	operations := map[string]bool{}
+	for path, item := range document.object("paths") {
+		for method := range item.(map[string]interface{}) {
+			operations[method+" "+path] = true
+		}
+	}
+
+	for route := range routes {
+		if !operations[route] {
+			t.Errorf("route %s is missing from openapi.json", route)
+		}
+	}
+	for operation := range operations {
+		if !routes[operation] {
+			t.Errorf("openapi.json has %s which is not a route", operation)
+		}
+	}
+}
+
+// responseSchemaTypes are the types of the JSON bodies, by the name of their schema
+var responseSchemaTypes = map[string][]interface{}{
+	"Incident":                          {api.Incident{}},
+	"IncidentEvent":                     {api.IncidentEvent{}},
+	"StatusPage":                        {api.StatusPage{}},
+	"AdminStatusPage":                   {api.AdminStatusPage{}},
+	"FieldChange":                       {api.FieldChange{}},
+	"IncidentRevision":                  {api.IncidentRevision{}},
+	"ApiKey":                            {api.ApiKey{}},
+	"ServiceGroup":                      {api.ServiceGroup{}},
+	"ServiceGroupMember":                {api.ServiceGroupMember{}},
+	"Workspace":                         {api.Workspace{}},
+	"NotificationChannel":               {api.NotificationChannel{}},
+	"StatsReport":                       {stats.Report{}},
+	"StatsSummary":                      {stats.Summary{}},
+	"ImpactCounts":                      {stats.ImpactCounts{}},
+	"Outage":                            {stats.Outage{}},
+	"ComponentStats":                    {stats.ComponentStats{}},
+	"MonthStats":                        {stats.MonthStats{}},
+	"IncidentsResponse":                 {IncidentsResponse{}, client.IncidentsResponse{}},
+	"IncidentSearchResult":              {db.IncidentSearchResult{}, client.IncidentSearchResult{}},
+	"IncidentSearchResponse":            {IncidentSearchResponse{}, client.IncidentSearchResponse{}},
+	"IncidentHistoryResponse":           {IncidentHistoryResponse{}, client.IncidentHistoryResponse{}},
+	"CurrentStatusResponse":             {CurrentStatusResponse{}, client.CurrentStatusResponse{}},
+	"StatusPageCurrentStatus":           {StatusPageCurrentStatus{}, client.StatusPageCurrentStatus{}},
+	"CurrentStatusBatchResponse":        {CurrentStatusBatchResponse{}, client.CurrentStatusBatchResponse{}},
+	"StatusPageResponse":                {StatusPageResponse{}, client.StatusPageResponse{}},
+	"StatusPagesResponse":               {StatusPagesResponse{}, client.StatusPagesResponse{}},
+	"StatusPageSearchResponse":          {StatusPageSearchResponse{}, client.StatusPageSearchResponse{}},
+	"StatusPageCountResponse":           {StatusPageCountResponse{}, client.StatusPageCountResponse{}},
+	"UptimeBucket":                      {UptimeBucket{}, client.UptimeBucket{}},
+	"UptimeResponse":                    {UptimeResponse{}, client.UptimeResponse{}},
+	"StatsResponse":                     {StatsResponse{}, client.StatsResponse{}},
+	"LeaderboardEntry":                  {LeaderboardEntry{}, client.LeaderboardEntry{}},
+	"LeaderboardResponse":               {LeaderboardResponse{}, client.LeaderboardResponse{}},
+	"IncidentStreamEvent":               {IncidentStreamEvent{}, client.IncidentStreamEvent{}},
+	"StatusStreamEvent":                 {StatusStreamEvent{}, client.StatusStreamEvent{}},
+	"GraphqlResponse":                   {GraphqlResponse{}, client.GraphqlResponse{}},
+	"GraphqlError":                      {GraphqlError{}, client.GraphqlError{}},
+	"GraphqlErrorLocation":              {GraphqlErrorLocation{}, client.GraphqlErrorLocation{}},
+	"AdminStatusPagesResponse":          {AdminStatusPagesResponse{}, client.AdminStatusPagesResponse{}},
+	"TestScrapeResponse":                {TestScrapeResponse{}, client.TestScrapeResponse{}},
+	"AdminApiKeyResponse":               {AdminApiKeyResponse{}, client.AdminApiKeyResponse{}},
+	"AdminApiKeysResponse":              {AdminApiKeysResponse{}, client.AdminApiKeysResponse{}},
+	"ServiceGroupsResponse":             {ServiceGroupsResponse{}, client.ServiceGroupsResponse{}},
+	"ServiceGroupResponse":              {ServiceGroupResponse{}, client.ServiceGroupResponse{}},
+	"ServiceGroupMemberStatus":          {ServiceGroupMemberStatus{}, client.ServiceGroupMemberStatus{}},
+	"ServiceGroupStatusResponse":        {ServiceGroupStatusResponse{}, client.ServiceGroupStatusResponse{}},
+	"ServiceGroupIncidentsResponse":     {ServiceGroupIncidentsResponse{}, client.ServiceGroupIncidentsResponse{}},
+	"AdminWorkspaceResponse":            {AdminWorkspaceResponse{}, client.AdminWorkspaceResponse{}},
+	"AdminWorkspacesResponse":           {AdminWorkspacesResponse{}, client.AdminWorkspacesResponse{}},
+	"AdminSubscriptionsResponse":        {AdminSubscriptionsResponse{}, client.AdminSubscriptionsResponse{}},
+	"AdminNotificationChannelResponse":  {AdminNotificationChannelResponse{}, client.AdminNotificationChannelResponse{}},
+	"AdminNotificationChannelsResponse": {AdminNotificationChannelsResponse{}, client.AdminNotificationChannelsResponse{}},
+	"Error":                             {client.ErrorResponse{}},
+}
+
+// requestSchemaTypes are the types of the JSON request bodies, by the name of their schema
+var requestSchemaTypes = map[string][]interface{}{
+	"CurrentStatusBatchRequest":       {CurrentStatusBatchRequest{}, client.CurrentStatusBatchRequest{}},
+	"GraphqlRequest":                  {GraphqlRequest{}, client.GraphqlRequest{}},
+	"AdminStatusPageRequest":          {AdminStatusPageRequest{}, client.AdminStatusPageRequest{}},
+	"AdminApiKeyRequest":              {AdminApiKeyRequest{}, client.AdminApiKeyRequest{}},
+	"AdminServiceGroupRequest":        {AdminServiceGroupRequest{}, client.AdminServiceGroupRequest{}},
+	"AdminWorkspaceRequest":           {AdminWorkspaceRequest{}, client.AdminWorkspaceRequest{}},
+	"AdminSubscriptionsRequest":       {AdminSubscriptionsRequest{}, client.AdminSubscriptionsRequest{}},
+	"AdminNotificationChannelRequest": {AdminNotificationChannelRequest{}, client.AdminNotificationChannelRequest{}},
+}
+
+// jsonFields returns the JSON names of the fields of a struct, and whether each is always present
+func jsonFields(structType reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			// The fields of an embedded struct are promoted, unless the struct has a field of the same name
+			for name, alwaysPresent := range jsonFields(field.Type) {
+				if _, found := fields[name]; !found {
+					fields[name] = alwaysPresent
+				}
+			}
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = !strings.Contains(options, "omitempty")
+	}
+	return fields
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	schemas := document.object("components", "schemas")
+
+	check := func(name string, value interface{}, checkRequired bool) {
+		schema := document.object("components", "schemas", name)
+		if schema == nil {
+			t.Errorf("openapi.json has no %s schema", name)
+			return
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		for _, property := range schema["required"].([]interface{}) {
+			required[property.(string)] = true
+		}
+
+		goType := reflect.TypeOf(value)
+		fields := jsonFields(goType)
+		for field, alwaysPresent := range fields {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("%s has the field %s which is missing from the %s schema", goType, field, name)
+				continue
+			}
+			if checkRequired && alwaysPresent != required[field] {
+				t.Errorf("%s field %s is always present: %t, but required in the %s schema: %t", goType, field, alwaysPresent, name, required[field])
+			}
+		}
+		for property := range properties {
+			if _, ok := fields[property]; !ok {
+				t.Errorf("the %s schema has the property %s which is missing from %s", name, property, goType)
+			}
+		}
+	}
+
+	for name, values := range responseSchemaTypes {
+		for _, value := range values {
+			check(name, value, true)
+		}
+	}
+	for name, values := range requestSchemaTypes {
+		for _, value := range values {
+			check(name, value, false)
+		}
+	}
+	for name, schema := range schemas {
+		_, isResponse := responseSchemaTypes[name]
+		_, isRequest := requestSchemaTypes[name]
+		if schema.(map[string]interface{})["type"] == "object" && !isResponse && !isRequest {
+			t.Errorf("the %s schema is not checked against a type", name)
+		}
+	}
+}
+
+// validateSchema returns the differences between a decoded JSON value and a schema
+func (d openAPIDocument) validateSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	schema = d.resolve(schema)
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || len(schema) == 0 || (len(schema) == 1 && schema["description"] != nil) {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s is null but not nullable", path)}
+	}
+	var problems []string
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, subSchema := range allOf {
+			problems = append(problems, d.validateSchema(subSchema.(map[string]interface{}), value, path)...)
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s is %v which is not one of %v", path, value, enum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s is not an object", path))
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, property := range required {
+				if _, ok := object[property.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s is missing the required property %s", path, property))
+				}
+			}
+		}
+		for key, propertyValue := range object {
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, d.validateSchema(propertySchema, propertyValue, path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				problems = append(problems, d.validateSchema(additional, propertyValue, path+"."+key)...)
+			} else if properties != nil && schema["additionalProperties"] == nil {
+				problems = append(problems, fmt.Sprintf("%s has the property %s which is not in the schema", path, key))
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s is not an array", path))
+		}
+		for i, item := range array {
+			problems = append(problems, d.validateSchema(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s is not a string", path))
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s is not a date-time: %v", path, err))
+			}
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			problems = append(problems, fmt.Sprintf("%s is not an integer", path))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a number", path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a boolean", path))
+		}
+	}
+	return problems
+}
+
+// validateResponse returns the differences between a response and the specification of its operation
+func (d openAPIDocument) validateResponse(path string, method string, statusCode int, contentType string, body []byte) []string {
+	operation := d.object("paths", path, strings.ToLower(method))
+	if operation == nil {
+		return []string{fmt.Sprintf("openapi.json has no %s %s", method, path)}
+	}
+	response := d.object("paths", path, strings.ToLower(method), "responses", fmt.Sprint(statusCode))
+	if response == nil {
+		return []string{fmt.Sprintf("openapi.json has no %d response for %s %s", statusCode, method, path)}
+	}
+	response = d.resolve(response)
+	content, _ := response["content"].(map[string]interface{})
+	if content == nil {
+		if len(body) > 0 {
+			return []string{fmt.Sprintf("%s %s %d has a body but none is specified", method, path, statusCode)}
+		}
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	media, ok := content[mediaType].(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%s %s %d has the content type %s which is not specified", method, path, statusCode, contentType)}
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{fmt.Sprintf("%s %s %d is not valid JSON: %v", method, path, statusCode, err)}
+	}
+	return d.validateSchema(media["schema"].(map[string]interface{}), value, "body")
+}
+
+func newContractTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}, AnonymousRead: true, AdminToken: "admin-token"}, nil)
+
+	now := time.Now().UTC()
+	slaTarget := 99.9
+	description := "Elevated error rates"
+	s.statusPageCache.Set("https://status.example.com", api.StatusPage{
+		Name:                    "Example",
+		URL:                     "https://status.example.com",
+		LastHistoricallyScraped: now,
+		LastCurrentlyScraped:    now,
+		LastSuccessfullyScraped: &now,
+		IsIndexed:               true,
+		Headers:                 api.JSONMap{"Authorization": "Bearer ${EXAMPLE_TOKEN}"},
+		Method:                  api.MethodGet,
+		SlaTarget:               &slaTarget,
+		Source:                  api.StatusPageSourceCode,
+	}, cache.NoExpiration)
+	s.statusPageCache.Set("https://status.unindexed.com", api.StatusPage{
+		Name:   "Unindexed",
+		URL:    "https://status.unindexed.com",
+		Source: api.StatusPageSourceAdmin,
+	}, cache.NoExpiration)
+
+	incidents := []api.Incident{{
+		ID:            1,
+		Title:         "Degraded API",
+		Components:    []string{"API"},
+		Events:        api.IncidentEventArray{api.NewIncidentEvent("Investigating", "We are investigating", now)},
+		StartTime:     now.Add(-time.Hour),
+		Description:   &description,
+		DeepLink:      "https://status.example.com/incidents/1",
+		ExternalID:    "1",
+		Impact:        api.ImpactMajor,
+		StatusPageUrl: "https://status.example.com",
+		Provider:      "atlassian",
+		State:         api.IncidentStateInvestigating,
+	}}
+	s.currentIncidentCache.Set("https://status.example.com", incidents, cache.NoExpiration)
+	s.incidentCache.Set(incidentCacheKey("https://status.example.com", map[string][]string{"statusPageUrl": {"https://status.example.com"}}), incidents, cache.NoExpiration)
+	return s
+}
+
+func TestHandlersMatchOpenAPISpec(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	server := httptest.NewServer(newContractTestServer(t).router())
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		// path is the path of the operation in openapi.json, target is the requested url relative to /api/v1
+		path           string
+		target         string
+		body           string
+		adminToken     bool
+		expectedStatus int
+	}{
+		{method: "GET", path: "/openapi.json", target: "/openapi.json", expectedStatus: 200},
+		{method: "GET", path: "/statusPages", target: "/statusPages", expectedStatus: 200},
+		{method: "GET", path: "/statusPages/search", target: "/statusPages/search?query=exam", expectedStatus: 200},
+		{method: "GET", path: "/statusPages/search", target: "/statusPages/search?query=nothing-matches-this", expectedStatus: 200},
+		{method: "GET", path: "/statusPages/search", target: "/statusPages/search", expectedStatus: 400},
+		{method: "GET", path: "/statusPages/count", target: "/statusPages/count", expectedStatus: 200},
+		{method: "GET", path: "/statusPage", target: "/statusPage?statusPageUrl=https://status.example.com", expectedStatus: 200},
+		{method: "GET", path: "/statusPage", target: "/statusPage?statusPageName=unindexed", expectedStatus: 200},
+		{method: "GET", path: "/statusPage", target: "/statusPage?statusPageName=unknown", expectedStatus: 404},
+		{method: "GET", path: "/currentStatus", target: "/currentStatus?statusPageUrl=https://status.example.com", expectedStatus: 200},
+		{method: "GET", path: "/currentStatus", target: "/currentStatus?statusPageUrl=https://status.unindexed.com", expectedStatus: 200},
+		{method: "GET", path: "/currentStatus", target: "/currentStatus?statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/currentStatus/batch", target: "/currentStatus/batch?statusPageUrl=https://status.example.com&statusPageUrl=https://status.unknown.com", expectedStatus: 200},
+		{method: "POST", path: "/currentStatus/batch", target: "/currentStatus/batch", body: `{"statusPageUrls": ["https://status.unindexed.com"]}`, expectedStatus: 200},
+		{method: "GET", path: "/currentStatus/batch", target: "/currentStatus/batch", expectedStatus: 400},
+		{method: "GET", path: "/incidents", target: "/incidents?statusPageUrl=https://status.example.com", expectedStatus: 200},
+		{method: "GET", path: "/incidents", target: "/incidents?statusPageUrl=https://status.unindexed.com", expectedStatus: 200},
+		{method: "GET", path: "/incidents", target: "/incidents?statusPageUrl=https://status.example.com&impact=unknown", expectedStatus: 400},
+		{method: "GET", path: "/incidents/search", target: "/incidents/search", expectedStatus: 400},
+		{method: "GET", path: "/incidents/export", target: "/incidents/export?statusPageUrl=https://status.example.com", expectedStatus: 400},
+		{method: "GET", path: "/incidents/export", target: "/incidents/export?format=jsonl&table=events&statusPageUrl=https://status.example.com", expectedStatus: 400},
+		{method: "GET", path: "/incidents/export", target: "/incidents/export?format=csv&statusPageUrl=https://status.example.com&group=checkout", expectedStatus: 400},
+		{method: "GET", path: "/incidents/export", target: "/incidents/export?format=csv&statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/incidents/{id}/history", target: "/incidents/abc/history", expectedStatus: 400},
+		{method: "GET", path: "/uptime", target: "/uptime", expectedStatus: 400},
+		{method: "GET", path: "/stats", target: "/stats", expectedStatus: 400},
+		{method: "GET", path: "/stats", target: "/stats?statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/stats", target: "/stats?statusPageUrl=https://status.example.com&from=2024-02-01&to=2024-01-01", expectedStatus: 400},
+		{method: "GET", path: "/stats/leaderboard", target: "/stats/leaderboard?sort=uptime", expectedStatus: 400},
+		{method: "GET", path: "/stats/leaderboard", target: "/stats/leaderboard?from=2020-01-01&to=2024-01-01", expectedStatus: 400},
+		{method: "GET", path: "/badge.svg", target: "/badge.svg?statusPageUrl=https://status.example.com&component=API", expectedStatus: 200},
+		{method: "GET", path: "/badge.svg", target: "/badge.svg?statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/badge/uptime.svg", target: "/badge/uptime.svg?statusPageUrl=https://status.example.com&days=0", expectedStatus: 400},
+		{method: "GET", path: "/feed.atom", target: "/feed.atom", expectedStatus: 400},
+		{method: "GET", path: "/feed.atom", target: "/feed.atom?statusPageUrl=https://status.example.com&statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/maintenance.ics", target: "/maintenance.ics?statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/metrics", target: "/metrics?statusPageUrl=https://status.unknown.com", expectedStatus: 404},
+		{method: "GET", path: "/groups/{name}", target: "/groups/Not%20A%20Name", expectedStatus: 404},
+		{method: "GET", path: "/groups/{name}/status", target: "/groups/checkout/status?aggregation=average", expectedStatus: 400},
+		{method: "GET", path: "/groups/{name}/incidents", target: "/groups/checkout/incidents?limit=0", expectedStatus: 400},
+		{method: "GET", path: "/stream", target: "/stream?impact=unknown", expectedStatus: 400},
+		{method: "POST", path: "/graphql", target: "/graphql", body: `{"query": "{ statusPages(first: 1) { totalCount nodes { url name level } pageInfo { hasNextPage endCursor } } }"}`, expectedStatus: 200},
+		{method: "POST", path: "/graphql", target: "/graphql", body: `{"query": "{ statusPages { nodes { notAField } } }"}`, expectedStatus: 400},
+		{method: "GET", path: "/graphql", target: "/graphql?query=%7B%20statusPage(url%3A%20%22https%3A%2F%2Fstatus.unknown.com%22)%20%7B%20name%20%7D%20%7D", expectedStatus: 200},
+		{method: "GET", path: "/graphql", target: "/graphql", expectedStatus: 400},
+		{method: "GET", path: "/admin/statusPages", target: "/admin/statusPages", expectedStatus: 403},
+		{method: "POST", path: "/admin/statusPages", target: "/admin/statusPages", body: `{"url": "not a url"}`, adminToken: true, expectedStatus: 400},
+		{method: "DELETE", path: "/admin/statusPages", target: "/admin/statusPages", adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/apiKeys", target: "/admin/apiKeys", body: `{"name": ""}`, adminToken: true, expectedStatus: 400},
+		{method: "DELETE", path: "/admin/apiKeys/{id}", target: "/admin/apiKeys/abc", adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/groups", target: "/admin/groups", body: `{"name": "checkout", "members": [{"statusPageUrl": "https://status.unknown.com"}]}`, adminToken: true, expectedStatus: 400},
+		{method: "PUT", path: "/admin/groups/{name}", target: "/admin/groups/checkout", body: `{"name": "payments", "members": [{"statusPageUrl": "https://status.example.com"}]}`, adminToken: true, expectedStatus: 400},
+		{method: "GET", path: "/admin/subscriptions", target: "/admin/subscriptions", adminToken: true, expectedStatus: 200},
+		{method: "POST", path: "/admin/subscriptions", target: "/admin/subscriptions", body: `{"statusPageUrls": ["https://status.example.com"]}`, adminToken: true, expectedStatus: 400},
+		{method: "DELETE", path: "/admin/subscriptions", target: "/admin/subscriptions?statusPageUrl=https://status.example.com", adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "email", "webhookUrl": "https://hooks.example.com"}`, adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "twitter", "slackBotToken": "xoxb-1", "slackChannel": "C123"}`, adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "slack", "slackBotToken": "xoxp-1", "slackChannel": "C123"}`, adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "slack", "webhookUrl": "https://hooks.example.com", "events": ["created", "escalated"]}`, adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "slack", "webhookUrl": "http://hooks.example.com"}`, adminToken: true, expectedStatus: 400},
+		{method: "POST", path: "/admin/notificationChannels", target: "/admin/notificationChannels", body: `{"name": "ops", "type": "slack", "webhookUrl": "https://169.254.169.254/latest/meta-data"}`, adminToken: true, expectedStatus: 400},
+		{method: "DELETE", path: "/admin/notificationChannels/{id}", target: "/admin/notificationChannels/abc", adminToken: true, expectedStatus: 400},
+		{method: "GET", path: "/admin/workspaces", target: "/admin/workspaces", expectedStatus: 403},
+		{method: "POST", path: "/admin/workspaces", target: "/admin/workspaces", body: `{"name": "Not A Name"}`, adminToken: true, expectedStatus: 400},
+		{method: "DELETE", path: "/admin/workspaces/{name}", target: "/admin/workspaces/default", adminToken: true, expectedStatus: 400},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.target, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			req, err := http.NewRequest(test.method, server.URL+apiV1Prefix+test.target, body)
+			if err != nil {
+				t.Fatalf("failed to create the request: %v", err)
+			}
+			if test.adminToken {
+				req.Header.Set("Authorization", "Bearer admin-token")
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			responseBody, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read the response: %v", err)
+			}
+			if resp.StatusCode != test.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.expectedStatus, resp.StatusCode, responseBody)
+			}
+			problems := document.validateResponse(test.path, test.method, resp.StatusCode, resp.Header.Get("Content-Type"), responseBody)
+			sort.Strings(problems)
+			for _, problem := range problems {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+func TestClientDecodesHandlerResponses(t *testing.T) {
+	server := httptest.NewServer(newContractTestServer(t).router())
+	defer server.Close()
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	statusPages, err := c.ListStatusPages(ctx)
+	if err != nil || len(statusPages.StatusPages) != 2 || statusPages.StatusPages[0].Name != "Example" {
+		t.Errorf("unexpected status pages %+v: %v", statusPages, err)
+	}
+	currentStatus, err := c.GetCurrentStatus(ctx, "https://status.example.com")
+	if err != nil || currentStatus.Status != client.StatusDegraded || currentStatus.Level != client.StatusLevelMajorOutage {
+		t.Errorf("unexpected current status %+v: %v", currentStatus, err)
+	}
+	batch, err := c.GetCurrentStatusBatch(ctx, []string{"https://status.example.com", "https://status.unknown.com"})
+	if err != nil || len(batch.Statuses) != 2 || batch.Statuses[0].OngoingIncidentCount != 1 || batch.Statuses[1].Known {
+		t.Errorf("unexpected batch %+v: %v", batch, err)
+	}
+	incidents, err := c.ListIncidents(ctx, client.IncidentsParams{StatusPageUrl: "https://status.example.com"})
+	if err != nil || len(incidents.Incidents) != 1 || incidents.Incidents[0].Impact != api.ImpactMajor {
+		t.Errorf("unexpected incidents %+v: %v", incidents, err)
+	}
+
+	_, err = c.GetStatusPage(ctx, "https://status.unknown.com")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "status page not known to statusphere" {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestPublicStatusPagesHideScrapeDefinition(t *testing.T) {
+	s := newContractTestServer(t)
+	for _, target := range []string{"/statusPage?statusPageUrl=https://status.example.com", "/statusPages", "/statusPages/search?query=example"} {
+		recorder := httptest.NewRecorder()
+		s.router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiV1Prefix+target, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d: %s", target, recorder.Code, recorder.Body)
+		}
+		for _, field := range []string{`"headers"`, `"payload"`, `"rules"`, "EXAMPLE_TOKEN"} {
+			if strings.Contains(recorder.Body.String(), field) {
+				t.Errorf("expected %s to hide %s, got %s", target, field, recorder.Body)
+			}
+		}
+	}
+
+	adminStatusPage, err := json.Marshal(api.NewAdminStatusPage(api.StatusPage{Headers: api.JSONMap{"Authorization": "Bearer ${EXAMPLE_TOKEN}"}}))
+	if err != nil || !strings.Contains(string(adminStatusPage), `"headers":{"Authorization":"Bearer ${EXAMPLE_TOKEN}"}`) {
+		t.Errorf("expected the admin status page to have the headers, got %s: %v", adminStatusPage, err)
+	}
+}
+
+func TestOpenAPISpecIsServed(t *testing.T) {
+	s := NewServer(zap.NewNop(), config.Config{CorsAllowedOrigins: []string{"http://localhost:3000"}}, nil)
+	recorder := httptest.NewRecorder()
+	s.router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if recorder.Code != http.StatusOK || !bytes.Equal(recorder.Body.Bytes(), openAPISpecJSON) {
+		t.Errorf("expected the specification to be served without an api key, got %d", recorder.Code)
+	}
+}
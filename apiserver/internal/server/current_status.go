@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/metoro-io/statusphere/common/api"
@@ -22,6 +23,10 @@ const (
 type CurrentStatusResponse struct {
 	Status    Status `json:"status"`
 	IsIndexed bool   `json:"isIndexed"`
+	// Level is the status taking the impact of the ongoing incidents and the freshness of the scrapes into account
+	Level StatusLevel `json:"level"`
+	// Reason explains the level in a human readable way
+	Reason string `json:"reason"`
 }
 
 // currentStatus is a handler for the /current-status endpoint.
@@ -30,6 +35,7 @@ type CurrentStatusResponse struct {
 // If the status page is not known to statusphere, it returns a 404.
 // If the status page is known to statusphere and it is indexed, it returns UP or DEGRADED depending on the current incidents.
 // If the status page is known to statusphere and it is not indexed, it returns UNKNOWN.
+// The level is one of OPERATIONAL, MAINTENANCE, MINOR_OUTAGE, MAJOR_OUTAGE, CRITICAL_OUTAGE or STALE, see computeStatusLevel
 func (s *Server) currentStatus(context *gin.Context) {
 	ctx := context.Request.Context()
 	statusPageUrl := context.Query("statusPageUrl")
@@ -38,58 +44,79 @@ func (s *Server) currentStatus(context *gin.Context) {
 		return
 	}
 
-	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
+	statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	if !found {
 		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
 		return
 	}
 
-	statusPageInterfaceCasted, ok := statusPageInterface.(api.StatusPage)
-	if !ok {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cast status page to api.StatusPage"})
+	if !statusPage.IsIndexed {
+		level, reason := computeStatusLevel(statusPage, nil, time.Now())
+		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUnknown, IsIndexed: false, Level: level, Reason: reason})
 		return
 	}
 
-	if !statusPageInterfaceCasted.IsIndexed {
-		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUnknown, IsIndexed: false})
+	incidents, found, err := s.getCurrentIncidents(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get current incidents", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get current incidents"})
 		return
 	}
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+
+	status := StatusUp
+	if len(incidents) > 0 {
+		status = StatusDegraded
+	}
+	level, reason := computeStatusLevel(statusPage, incidents, time.Now())
+	context.JSON(http.StatusOK, CurrentStatusResponse{Status: status, IsIndexed: true, Level: level, Reason: reason})
+}
+
+// getStatusPageFromCache returns the status page with the given url from the status page cache.
+// If the status page is not known to statusphere, it returns false for the second return value.
+func (s *Server) getStatusPageFromCache(statusPageUrl string) (api.StatusPage, bool, error) {
+	statusPageInterface, found := s.statusPageCache.Get(statusPageUrl)
+	if !found {
+		return api.StatusPage{}, false, nil
+	}
+
+	statusPage, ok := statusPageInterface.(api.StatusPage)
+	if !ok {
+		return api.StatusPage{}, false, errors.New("failed to cast status page to api.StatusPage")
+	}
+	return statusPage, true, nil
+}
 
+// getCurrentIncidents returns the current incidents of the status page, from the cache if possible.
+// If the status page is not known to statusphere, it returns false for the second return value.
+func (s *Server) getCurrentIncidents(ctx context.Context, statusPageUrl string) ([]api.Incident, bool, error) {
 	// Attempt to get the incidents from the cache
 	incidents, found, err := s.getCurrentIncidentsFromCache(ctx, statusPageUrl)
 	if err != nil {
-		s.logger.Error("failed to get incidents from cache", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from cache"})
-		return
+		return nil, false, errors.Wrap(err, "failed to get incidents from cache")
 	}
 	if found {
-		if len(incidents) > 0 {
-			context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusDegraded, IsIndexed: true})
-			return
-		}
-		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUp, IsIndexed: true})
-		return
+		return incidents, true, nil
 	}
 
 	// Attempt to get the incidents from the database
 	incidents, found, err = s.getCurrentIncidentsFromDatabase(ctx, statusPageUrl)
 	if err != nil {
-		s.logger.Error("failed to get incidents from database", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
-		return
+		return nil, false, errors.Wrap(err, "failed to get incidents from database")
 	}
 	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
-		return
+		return nil, false, nil
 	}
 
 	s.currentIncidentCache.Set(statusPageUrl, incidents, cache.DefaultExpiration)
-	if len(incidents) > 0 {
-		context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusDegraded, IsIndexed: true})
-		return
-	}
-
-	context.JSON(http.StatusOK, CurrentStatusResponse{Status: StatusUp, IsIndexed: true})
+	return incidents, true, nil
 }
 
 // getCurrentIncidentsFromCache attempts to get the current incidents from the cache.
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+const maxBatchStatusPages = 200
+
+type CurrentStatusBatchRequest struct {
+	StatusPageUrls []string `json:"statusPageUrls"`
+}
+
+type StatusPageCurrentStatus struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	// Known is false if the status page is not known to statusphere, no other field is set then
+	Known                bool        `json:"known"`
+	Status               Status      `json:"status,omitempty"`
+	Level                StatusLevel `json:"level,omitempty"`
+	Reason               string      `json:"reason,omitempty"`
+	IsIndexed            bool        `json:"isIndexed"`
+	OngoingIncidentCount int         `json:"ongoingIncidentCount"`
+	HighestOngoingImpact *api.Impact `json:"highestOngoingImpact"`
+	// LastSuccessfullyScraped is nil if the status page has never been scraped successfully
+	LastSuccessfullyScraped *time.Time `json:"lastSuccessfullyScraped"`
+}
+
+type CurrentStatusBatchResponse struct {
+	Statuses []StatusPageCurrentStatus `json:"statuses"`
+}
+
+// currentStatusBatch is a handler for the /currentStatus/batch endpoint.
+// The status pages are given by the repeated statusPageUrl query parameter, or for POST requests,
+// by the statusPageUrls field of the JSON body. At most 200 status pages can be requested at once.
+// It returns the current status and level of each status page in the order they were requested, along with the number of ongoing incidents,
+// the highest impact of the ongoing incidents and the last time the status page was scraped successfully.
+// Status pages not known to statusphere, or not seen by the workspace, are returned with known set to false rather than failing the whole request.
+func (s *Server) currentStatusBatch(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrls := context.QueryArray("statusPageUrl")
+	if context.Request.Method == http.MethodPost {
+		var request CurrentStatusBatchRequest
+		if err := context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		statusPageUrls = append(statusPageUrls, request.StatusPageUrls...)
+	}
+	if len(statusPageUrls) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+	if len(statusPageUrls) > maxBatchStatusPages {
+		context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d status pages can be requested at once", maxBatchStatusPages)})
+		return
+	}
+
+	now := time.Now()
+	scope := getWorkspaceScope(context)
+	statuses := make([]StatusPageCurrentStatus, 0, len(statusPageUrls))
+	for _, statusPageUrl := range statusPageUrls {
+		status := StatusPageCurrentStatus{StatusPageUrl: statusPageUrl}
+		statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !found || !scope.canSee(statusPageUrl) {
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Known = true
+		status.IsIndexed = statusPage.IsIndexed
+		status.LastSuccessfullyScraped = statusPage.LastSuccessfullyScraped
+		if !statusPage.IsIndexed {
+			status.Status = StatusUnknown
+			status.Level, status.Reason = computeStatusLevel(statusPage, nil, now)
+			statuses = append(statuses, status)
+			continue
+		}
+
+		incidents, found, err := s.getCurrentIncidents(ctx, statusPageUrl)
+		if err != nil {
+			s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("url", statusPageUrl))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get current incidents"})
+			return
+		}
+		if !found {
+			// The status page was deleted since the status page cache was refreshed
+			statuses = append(statuses, StatusPageCurrentStatus{StatusPageUrl: statusPageUrl})
+			continue
+		}
+
+		status.Status = StatusUp
+		if len(incidents) > 0 {
+			status.Status = StatusDegraded
+		}
+		status.OngoingIncidentCount = len(incidents)
+		status.HighestOngoingImpact = highestImpact(incidents)
+		status.Level, status.Reason = computeStatusLevel(statusPage, incidents, now)
+		statuses = append(statuses, status)
+	}
+
+	context.JSON(http.StatusOK, CurrentStatusBatchResponse{Statuses: statuses})
+}
+
+// highestImpact returns the most severe impact of the incidents, or nil if there are none
+func highestImpact(incidents []api.Incident) *api.Impact {
+	var highest *api.Impact
+	for i := range incidents {
+		if highest == nil || api.ImpactSeverity(incidents[i].Impact) > api.ImpactSeverity(*highest) {
+			impact := incidents[i].Impact
+			highest = &impact
+		}
+	}
+	return highest
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+const defaultFeedLimit = 50
+const maxFeedLimit = 200
+
+// feedMaxAge is how long feed readers and calendars can cache the feeds
+const feedMaxAge = 5 * time.Minute
+
+// statusPageFrontendUrl is the page of a status page on the statusphere frontend
+const statusPageFrontendUrl = "https://metoro.io/statusphere/status/"
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomPerson  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+// feed is a handler for the /feed.atom endpoint.
+// It has a required query parameter of statusPageUrl, which can be repeated to get a feed of several status pages
+// It has the impact, from, to, ongoing, component and query parameters of the /incidents endpoint
+// It has an optional query parameter of limit (default is 50, at most 200)
+// It returns an Atom feed with an entry per incident, newest first, whose content has every update of the incident
+func (s *Server) feed(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPages, ok := s.getRequestedStatusPages(context)
+	if !ok {
+		return
+	}
+
+	filter, err := parseIncidentFilter(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := defaultFeedLimit
+	if limitStr := context.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxFeedLimit {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be an integer between 1 and %d", maxFeedLimit)})
+			return
+		}
+	}
+	for _, statusPage := range statusPages {
+		filter.StatusPageUrls = append(filter.StatusPageUrls, statusPage.URL)
+	}
+	filter.Limit = limit
+
+	incidents, err := s.dbClient.ListIncidents(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to get incidents from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+		return
+	}
+
+	feed := buildAtomFeed(statusPages, incidents, s.requestUrl(context), time.Now())
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		s.logger.Error("failed to render feed", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render feed"})
+		return
+	}
+	setCacheControl(context, feedMaxAge)
+	context.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// getRequestedStatusPages returns the status pages of the repeated statusPageUrl query parameter
+// It responds with a 400 or a 404 and returns false if they are missing, too many or not known to statusphere
+func (s *Server) getRequestedStatusPages(context *gin.Context) ([]api.StatusPage, bool) {
+	statusPageUrls := context.QueryArray("statusPageUrl")
+	if len(statusPageUrls) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return nil, false
+	}
+	if len(statusPageUrls) > maxBatchStatusPages {
+		context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d status pages can be requested at once", maxBatchStatusPages)})
+		return nil, false
+	}
+
+	var statusPages []api.StatusPage
+	seen := make(map[string]bool)
+	for _, statusPageUrl := range statusPageUrls {
+		if seen[statusPageUrl] {
+			continue
+		}
+		seen[statusPageUrl] = true
+		statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		if !found {
+			context.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("status page %s not known to statusphere", statusPageUrl)})
+			return nil, false
+		}
+		statusPages = append(statusPages, statusPage)
+	}
+	return statusPages, true
+}
+
+// requestUrl returns the absolute url of the request, taking the proxy in front of the apiserver into account
+func (s *Server) requestUrl(context *gin.Context) string {
+	scheme := "http"
+	if context.Request.TLS != nil {
+		scheme = "https"
+	}
+	// Only the trusted proxies say which scheme the client used, anyone else could make the links of a cached feed point elsewhere
+	if forwardedProto := context.GetHeader("X-Forwarded-Proto"); forwardedProto != "" && s.isTrustedProxy(context.RemoteIP()) {
+		scheme = forwardedProto
+	}
+	return scheme + "://" + context.Request.Host + context.Request.URL.RequestURI()
+}
+
+// isTrustedProxy returns whether the address is one of the trusted proxies of the config
+func (s *Server) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.config.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(ip) {
+			return true
+		}
+		if proxyIp := net.ParseIP(proxy); proxyIp != nil && proxyIp.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildAtomFeed(statusPages []api.StatusPage, incidents []api.Incident, selfUrl string, now time.Time) atomFeed {
+	names := make(map[string]string)
+	var sortedNames []string
+	for _, statusPage := range statusPages {
+		names[statusPage.URL] = statusPage.Name
+		sortedNames = append(sortedNames, statusPage.Name)
+	}
+	sort.Strings(sortedNames)
+
+	feed := atomFeed{
+		ID:     selfUrl,
+		Author: atomPerson{Name: "Statusphere"},
+		Links:  []atomLink{{Rel: "self", Type: "application/atom+xml", Href: selfUrl}},
+	}
+	if len(statusPages) == 1 {
+		feed.Title = statusPages[0].Name + " incidents"
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Type: "text/html", Href: statusPageFrontendUrl + url.QueryEscape(statusPages[0].Name)})
+	} else {
+		feed.Title = fmt.Sprintf("Incidents of %d status pages", len(statusPages))
+		feed.Subtitle = strings.Join(sortedNames, ", ")
+	}
+
+	updated := time.Time{}
+	for _, incident := range incidents {
+		entry := buildAtomEntry(incident, names[incident.StatusPageUrl], len(statusPages) > 1)
+		if incidentUpdated := incidentLastUpdated(incident); incidentUpdated.After(updated) {
+			updated = incidentUpdated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if updated.IsZero() {
+		updated = now
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed
+}
+
+// incidentLastUpdated is the last time something happened to the incident
+func incidentLastUpdated(incident api.Incident) time.Time {
+	updated := incident.StartTime
+	if incident.EndTime != nil && incident.EndTime.After(updated) {
+		updated = *incident.EndTime
+	}
+	for _, event := range incident.Events {
+		if event.Time.After(updated) {
+			updated = event.Time
+		}
+	}
+	return updated
+}
+
+func buildAtomEntry(incident api.Incident, statusPageName string, prefixWithName bool) atomEntry {
+	title := incident.Title
+	if prefixWithName {
+		title = fmt.Sprintf("[%s] %s", statusPageName, incident.Title)
+	}
+	entry := atomEntry{
+		ID:         fmt.Sprintf("urn:statusphere:incident:%d", incident.ID),
+		Title:      title,
+		Published:  incident.StartTime.UTC().Format(time.RFC3339),
+		Updated:    incidentLastUpdated(incident).UTC().Format(time.RFC3339),
+		Author:     atomPerson{Name: statusPageName},
+		Categories: []atomCategory{{Term: string(incident.Impact)}},
+		Content:    atomText{Type: "html", Body: incidentHtml(incident)},
+	}
+	if incident.State != "" {
+		entry.Categories = append(entry.Categories, atomCategory{Term: string(incident.State)})
+	}
+	link := incident.DeepLink
+	if link == "" {
+		link = statusPageFrontendUrl + url.QueryEscape(statusPageName)
+	}
+	entry.Links = []atomLink{{Rel: "alternate", Type: "text/html", Href: link}}
+	return entry
+}
+
+// incidentHtml describes the incident and its updates, newest first
+func incidentHtml(incident api.Incident) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p><strong>Impact:</strong> %s", html.EscapeString(string(incident.Impact)))
+	if len(incident.Components) > 0 {
+		fmt.Fprintf(&b, "<br/><strong>Components:</strong> %s", html.EscapeString(strings.Join(incident.Components, ", ")))
+	}
+	fmt.Fprintf(&b, "<br/><strong>Started:</strong> %s", incident.StartTime.UTC().Format(time.RFC1123))
+	if incident.EndTime != nil {
+		fmt.Fprintf(&b, "<br/><strong>Ended:</strong> %s", incident.EndTime.UTC().Format(time.RFC1123))
+	}
+	b.WriteString("</p>")
+	if incident.Description != nil && *incident.Description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(*incident.Description))
+	}
+
+	events := make([]api.IncidentEvent, len(incident.Events))
+	copy(events, incident.Events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	for _, event := range events {
+		fmt.Fprintf(&b, "<p><strong>%s</strong> - %s<br/>%s</p>",
+			html.EscapeString(event.Title), event.Time.UTC().Format(time.RFC1123), html.EscapeString(event.Description))
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+func TestBuildAtomFeed(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	end := now.Add(-time.Hour)
+	statusPages := []api.StatusPage{{Name: "GitHub", URL: "https://www.githubstatus.com"}, {Name: "Atlassian", URL: "https://status.atlassian.com"}}
+	incidents := []api.Incident{{
+		ID:            7,
+		Title:         "Actions <delayed>",
+		StartTime:     now.Add(-3 * time.Hour),
+		EndTime:       &end,
+		Events:        api.IncidentEventArray{api.NewIncidentEvent("Investigating", "Looking", now.Add(-3*time.Hour)), api.NewIncidentEvent("Resolved", "Fixed", now.Add(-2*time.Hour))},
+		Impact:        api.ImpactMajor,
+		StatusPageUrl: "https://www.githubstatus.com",
+		State:         api.IncidentStateResolved,
+	}}
+
+	feed := buildAtomFeed(statusPages, incidents, "https://statusphere.metoro.io/api/v1/feed.atom", now)
+	body, err := xml.Marshal(feed)
+	if err != nil {
+		t.Fatalf("failed to marshal the feed: %v", err)
+	}
+	var parsed atomFeed
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("failed to parse the feed: %v", err)
+	}
+	if parsed.Title != "Incidents of 2 status pages" || parsed.Subtitle != "Atlassian, GitHub" || parsed.Updated != end.Format(time.RFC3339) {
+		t.Errorf("unexpected feed %+v", parsed)
+	}
+	if len(parsed.Entries) != 1 {
+		t.Fatalf("expected an entry, got %d", len(parsed.Entries))
+	}
+	entry := parsed.Entries[0]
+	if entry.ID != "urn:statusphere:incident:7" || entry.Title != "[GitHub] Actions <delayed>" || entry.Author.Name != "GitHub" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if strings.Index(entry.Content.Body, "Resolved") > strings.Index(entry.Content.Body, "Investigating") {
+		t.Errorf("expected the updates newest first, got %s", entry.Content.Body)
+	}
+	if entry.Links[0].Href != statusPageFrontendUrl+"GitHub" {
+		t.Errorf("expected the entry to link to the frontend without a deep link, got %s", entry.Links[0].Href)
+	}
+}
+
+func TestRenderMaintenanceCalendar(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	description := "Planned outage; systems, databases\nand " + strings.Repeat("network ", 20)
+	statusPages := []api.StatusPage{{Name: "CKP", URL: "https://www.ckp.cz"}}
+	incidents := []api.Incident{{
+		ID:            3,
+		Title:         "Planned outage",
+		StartTime:     now.Add(48 * time.Hour),
+		Description:   &description,
+		Impact:        api.ImpactMaintenance,
+		StatusPageUrl: "https://www.ckp.cz",
+	}, {
+		// Never announced to end, it is over an hour after its start
+		ID:            4,
+		Title:         "Forgotten maintenance",
+		StartTime:     now.AddDate(0, 0, -maintenanceCalendarPastDays).Add(-2 * time.Hour),
+		Impact:        api.ImpactMaintenance,
+		StatusPageUrl: "https://www.ckp.cz",
+	}}
+
+	calendar := renderMaintenanceCalendar(statusPages, incidents, now)
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:CKP maintenance\r\n",
+		"UID:incident-3@statusphere.metoro.io\r\n",
+		"DTSTART:20240315T120000Z\r\n",
+		"DTEND:20240315T130000Z\r\n",
+		"SUMMARY:[CKP] Planned outage\r\n",
+		`DESCRIPTION:Planned outage\; systems\, databases\nand network`,
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, expected) {
+			t.Errorf("expected the calendar to contain %q, got %s", expected, calendar)
+		}
+	}
+	if strings.Contains(calendar, "incident-4@") {
+		t.Errorf("expected the maintenance without an end to leave the calendar, got %s", calendar)
+	}
+	for _, line := range strings.Split(calendar, "\r\n") {
+		if len(line) > icsMaxLineOctets {
+			t.Errorf("expected the lines to be folded, got %q", line)
+		}
+	}
+}
+
+func TestRequestUrlTrustsForwardedProtoOnlyFromTrustedProxies(t *testing.T) {
+	requestUrl := func(s *Server) string {
+		context, _ := gin.CreateTestContext(httptest.NewRecorder())
+		context.Request = httptest.NewRequest("GET", "http://statusphere.example/feed.atom?statusPageUrl=x", nil)
+		context.Request.RemoteAddr = "192.0.2.1:1234"
+		context.Request.Header.Set("X-Forwarded-Proto", "https")
+		return s.requestUrl(context)
+	}
+
+	s := NewServer(zap.NewNop(), config.Config{}, nil)
+	if url := requestUrl(s); url != "http://statusphere.example/feed.atom?statusPageUrl=x" {
+		t.Errorf("expected the X-Forwarded-Proto of an untrusted client to be ignored, got %s", url)
+	}
+	s = NewServer(zap.NewNop(), config.Config{TrustedProxies: []string{"192.0.2.0/24"}}, nil)
+	if url := requestUrl(s); url != "https://statusphere.example/feed.atom?statusPageUrl=x" {
+		t.Errorf("expected the X-Forwarded-Proto of a trusted proxy to be used, got %s", url)
+	}
+}
+
+func TestWriteIcsLineDoesNotSplitCharacters(t *testing.T) {
+	var b strings.Builder
+	writeIcsLine(&b, "SUMMARY", strings.Repeat("ž", 60))
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > icsMaxLineOctets || !strings.HasSuffix(line, "ž") {
+			t.Errorf("unexpected folded line %q", line)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+type GraphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphqlErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphqlError struct {
+	Message   string                 `json:"message"`
+	Locations []GraphqlErrorLocation `json:"locations,omitempty"`
+	// Path is the path of the field that failed, made of field names and list indexes
+	Path []interface{} `json:"path,omitempty"`
+}
+
+type GraphqlResponse struct {
+	// Data is null when the query could not be executed
+	Data   interface{}    `json:"data"`
+	Errors []GraphqlError `json:"errors,omitempty"`
+}
+
+type graphqlContextKey struct{}
+
+// graphqlContext is the state of a GraphQL request shared by its resolvers
+type graphqlContext struct {
+	server  *Server
+	scope   workspaceScope
+	now     time.Time
+	loaders *graphqlLoaders
+}
+
+func getGraphqlContext(ctx context.Context) *graphqlContext {
+	return ctx.Value(graphqlContextKey{}).(*graphqlContext)
+}
+
+// graphqlQuery is a handler for the /graphql endpoint.
+// It executes a GraphQL query over the status pages, their components, incidents and uptime, see graphql_schema.go
+// The query is the body of a POST request or the query, operationName and variables (JSON encoded) query parameters of a GET request
+// It responds with a 400 and null data if the query cannot be executed, the errors of single fields are returned along with the data
+// The errors are GraphQL errors rather than the error of the other endpoints, so that GraphQL clients can read them
+// The fields resolved for every item of a list are loaded in batches, a query costs one database query per level of the query
+// Queries nested too deeply or that can return too many fields are rejected before they run, see checkGraphqlLimits
+func (s *Server) graphqlQuery(context *gin.Context) {
+	var request GraphqlRequest
+	if context.Request.Method == http.MethodPost {
+		if err := context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, newGraphqlErrorResponse("invalid request body"))
+			return
+		}
+	} else {
+		request.Query = context.Query("query")
+		request.OperationName = context.Query("operationName")
+		if variables := context.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				context.JSON(http.StatusBadRequest, newGraphqlErrorResponse("variables must be a JSON object"))
+				return
+			}
+		}
+	}
+	if request.Query == "" {
+		context.JSON(http.StatusBadRequest, newGraphqlErrorResponse("query is required"))
+		return
+	}
+	if err := checkGraphqlLimits(request); err != nil {
+		context.JSON(http.StatusBadRequest, newGraphqlErrorResponse(err.Error()))
+		return
+	}
+
+	ctx := context.Request.Context()
+	ctx = contextWithGraphql(ctx, &graphqlContext{
+		server:  s,
+		scope:   getWorkspaceScope(context),
+		now:     time.Now(),
+		loaders: s.newGraphqlLoaders(ctx),
+	})
+	result := graphql.Do(graphql.Params{
+		Schema:         graphqlSchema,
+		RequestString:  request.Query,
+		OperationName:  request.OperationName,
+		VariableValues: request.Variables,
+		Context:        ctx,
+	})
+
+	response := GraphqlResponse{Data: result.Data}
+	for _, formattedError := range result.Errors {
+		graphqlError := GraphqlError{Message: formattedError.Message, Path: formattedError.Path}
+		for _, location := range formattedError.Locations {
+			graphqlError.Locations = append(graphqlError.Locations, GraphqlErrorLocation{Line: location.Line, Column: location.Column})
+		}
+		response.Errors = append(response.Errors, graphqlError)
+	}
+	// Queries that fail to parse or validate have no data at all
+	if result.Data == nil && len(response.Errors) > 0 {
+		context.JSON(http.StatusBadRequest, response)
+		return
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+func contextWithGraphql(ctx context.Context, graphqlCtx *graphqlContext) context.Context {
+	return context.WithValue(ctx, graphqlContextKey{}, graphqlCtx)
+}
+
+func newGraphqlErrorResponse(message string) GraphqlResponse {
+	return GraphqlResponse{Errors: []GraphqlError{{Message: message}}}
+}
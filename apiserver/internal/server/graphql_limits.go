@@ -0,0 +1,173 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// maxGraphqlDepth bounds how deeply the fields of a query are nested
+// The status pages and the incidents refer to each other, a query could otherwise nest them without end
+// It leaves room for the introspection query of GraphQL clients, which is 13 levels deep
+const maxGraphqlDepth = 15
+
+// maxGraphqlCost bounds the number of fields a query can return, the fields of a list count once per item it can have
+// Lists with a first argument have that many items, the other lists of an item are counted as one
+const maxGraphqlCost = 50000
+
+// checkGraphqlLimits returns an error if an operation of the query is nested too deeply or can return too many fields
+// Queries that do not parse are left to graphql.Do, which reports the error
+func checkGraphqlLimits(request GraphqlRequest) error {
+	document, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return nil
+	}
+	limits := graphqlLimits{fragments: make(map[string]*ast.FragmentDefinition), variables: request.Variables}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			limits.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		limits.variableDefaults = make(map[string]ast.Value)
+		limits.selections = 0
+		for _, variable := range operation.VariableDefinitions {
+			if variable.Variable != nil && variable.Variable.Name != nil && variable.DefaultValue != nil {
+				limits.variableDefaults[variable.Variable.Name.Value] = variable.DefaultValue
+			}
+		}
+		depth, cost := limits.measure(operation.SelectionSet, graphqlSchema.QueryType(), 1, map[string]bool{})
+		if depth > maxGraphqlDepth {
+			return fmt.Errorf("the query is nested %d levels deep, at most %d are allowed", depth, maxGraphqlDepth)
+		}
+		if cost > maxGraphqlCost {
+			return fmt.Errorf("the query can return up to %d fields, at most %d are allowed, request fewer items with first", cost, maxGraphqlCost)
+		}
+	}
+	return nil
+}
+
+type graphqlLimits struct {
+	fragments        map[string]*ast.FragmentDefinition
+	variables        map[string]interface{}
+	variableDefaults map[string]ast.Value
+	// selections is the number of selections measured, fragments spread several times are measured each time
+	selections int
+}
+
+// measure returns the depth of the selection set and the number of fields it can return when its parent is returned
+// multiplier times. parent is nil for the introspection types, whose lists are counted as one item
+// The fragments being measured are skipped, a fragment cycle is reported by the validation of graphql.Do
+func (l *graphqlLimits) measure(selectionSet *ast.SelectionSet, parent *graphql.Object, multiplier int, measuring map[string]bool) (int, int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+	depth, cost := 0, 0
+	for _, selection := range selectionSet.Selections {
+		// Fragments spreading other fragments several times would take exponential time to measure
+		l.selections++
+		if l.selections > maxGraphqlCost {
+			return depth, maxGraphqlCost + 1
+		}
+		var selectionDepth, selectionCost int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			var definition *graphql.FieldDefinition
+			if parent != nil && selection.Name != nil {
+				definition = parent.Fields()[selection.Name.Value]
+			}
+			childMultiplier := multiplier * l.first(selection, definition)
+			if childMultiplier > maxGraphqlCost {
+				childMultiplier = maxGraphqlCost + 1
+			}
+			var child *graphql.Object
+			if definition != nil {
+				child, _ = graphql.GetNamed(definition.Type).(*graphql.Object)
+			}
+			selectionDepth, selectionCost = l.measure(selection.SelectionSet, child, childMultiplier, measuring)
+			selectionDepth++
+			selectionCost += multiplier
+		case *ast.InlineFragment:
+			selectionDepth, selectionCost = l.measure(selection.SelectionSet, parent, multiplier, measuring)
+		case *ast.FragmentSpread:
+			if selection.Name == nil {
+				continue
+			}
+			name := selection.Name.Value
+			fragment, found := l.fragments[name]
+			if !found || measuring[name] {
+				continue
+			}
+			measuring[name] = true
+			selectionDepth, selectionCost = l.measure(fragment.SelectionSet, parent, multiplier, measuring)
+			delete(measuring, name)
+		}
+		if selectionDepth > depth {
+			depth = selectionDepth
+		}
+		cost += selectionCost
+		// The costs are only compared to the limit, they are capped so that they cannot overflow
+		if cost > maxGraphqlCost {
+			cost = maxGraphqlCost + 1
+		}
+	}
+	return depth, cost
+}
+
+// first returns the number of items the field can return, the value of its first argument, or 1 if it has none
+func (l *graphqlLimits) first(field *ast.Field, definition *graphql.FieldDefinition) int {
+	if definition == nil {
+		return 1
+	}
+	first := 0
+	for _, argument := range definition.Args {
+		if argument.Name() == "first" {
+			first, _ = argument.DefaultValue.(int)
+		}
+	}
+	if first == 0 {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name == nil || argument.Name.Value != "first" {
+			continue
+		}
+		if value, ok := l.intValue(argument.Value); ok {
+			first = value
+		}
+	}
+	if first < 1 || first > maxGraphqlFirst {
+		// first is rejected by the resolver, the field returns nothing
+		return 1
+	}
+	return first
+}
+
+// intValue returns the value of an int literal or variable, false if it is neither
+func (l *graphqlLimits) intValue(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		parsed, err := strconv.Atoi(value.Value)
+		return parsed, err == nil
+	case *ast.Variable:
+		if value.Name == nil {
+			return 0, false
+		}
+		// Variables are decoded from JSON, their numbers are floats
+		if variable, found := l.variables[value.Name.Value]; found {
+			number, ok := variable.(float64)
+			return int(number), ok
+		}
+		if defaultValue, found := l.variableDefaults[value.Name.Value]; found {
+			return l.intValue(defaultValue)
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/uptime"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type batchResult[V any] struct {
+	value V
+	err   error
+}
+
+// batchLoader loads the values of keys in batches for a single GraphQL request, like a DataLoader
+// Resolvers return the thunk of load: the executor resolves a whole level of the query, queuing the keys of every item of
+// the lists, before it calls the thunks, so the first thunk fetches the keys of the level in a single batch
+type batchLoader[K comparable, V any] struct {
+	mu sync.Mutex
+	// fetch returns the values of the keys, the keys missing from the map have the zero value
+	fetch     func(keys []K) (map[K]V, error)
+	queued    []K
+	queuedSet map[K]bool
+	results   map[K]batchResult[V]
+}
+
+func newBatchLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{fetch: fetch, queuedSet: make(map[K]bool), results: make(map[K]batchResult[V])}
+}
+
+// load queues the key and returns a thunk of its value, the value of a key is only fetched once per request
+func (l *batchLoader[K, V]) load(key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok && !l.queuedSet[key] {
+		l.queued = append(l.queued, key)
+		l.queuedSet[key] = true
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.results[key]; !ok {
+			l.fetchQueued()
+		}
+		result := l.results[key]
+		return result.value, result.err
+	}
+}
+
+func (l *batchLoader[K, V]) fetchQueued() {
+	keys := l.queued
+	l.queued = nil
+	l.queuedSet = make(map[K]bool)
+
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		l.results[key] = batchResult[V]{value: values[key], err: err}
+	}
+}
+
+// statusPageIncidentsKey selects a page of the incidents of a status page, the incidents of the keys that share the
+// arguments of the incidents field are fetched in a single query
+type statusPageIncidentsKey struct {
+	statusPageUrl string
+	args          incidentsArgs
+}
+
+// statusPageComponentsKey selects the components of a status page that had an incident in the last days
+type statusPageComponentsKey struct {
+	statusPageUrl string
+	days          int
+}
+
+// uptimeKey selects the rolled up days of a status page, or of one of its components, between from and to
+type uptimeKey struct {
+	statusPageUrl string
+	component     string
+	from          time.Time
+	to            time.Time
+}
+
+// graphqlComponent is a component of a status page, the components are known from the incidents affecting them
+type graphqlComponent struct {
+	Name                  string    `json:"name"`
+	StatusPageUrl         string    `json:"statusPageUrl"`
+	LastIncidentStartTime time.Time `json:"lastIncidentStartTime"`
+}
+
+// graphqlLoaders batch the database queries of the fields of a GraphQL request that are resolved for every item of a list
+type graphqlLoaders struct {
+	currentIncidents *batchLoader[string, []api.Incident]
+	incidents        *batchLoader[statusPageIncidentsKey, []api.Incident]
+	components       *batchLoader[statusPageComponentsKey, []graphqlComponent]
+	uptimeDays       *batchLoader[uptimeKey, []api.UptimeDay]
+}
+
+func (s *Server) newGraphqlLoaders(ctx context.Context) *graphqlLoaders {
+	return &graphqlLoaders{
+		currentIncidents: newBatchLoader(func(statusPageUrls []string) (map[string][]api.Incident, error) {
+			return s.loadCurrentIncidents(ctx, statusPageUrls)
+		}),
+		incidents: newBatchLoader(func(keys []statusPageIncidentsKey) (map[statusPageIncidentsKey][]api.Incident, error) {
+			return s.loadStatusPageIncidents(ctx, keys)
+		}),
+		components: newBatchLoader(func(keys []statusPageComponentsKey) (map[statusPageComponentsKey][]graphqlComponent, error) {
+			return s.loadComponents(ctx, keys)
+		}),
+		uptimeDays: newBatchLoader(func(keys []uptimeKey) (map[uptimeKey][]api.UptimeDay, error) {
+			return s.loadUptimeDays(ctx, keys)
+		}),
+	}
+}
+
+// loadCurrentIncidents returns the current incidents of the status pages, from the cache if possible
+// The status pages missing from the cache are fetched in a single query and cached
+func (s *Server) loadCurrentIncidents(ctx context.Context, statusPageUrls []string) (map[string][]api.Incident, error) {
+	currentIncidents := make(map[string][]api.Incident, len(statusPageUrls))
+	var missing []string
+	for _, statusPageUrl := range statusPageUrls {
+		incidents, found, err := s.getCurrentIncidentsFromCache(ctx, statusPageUrl)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			missing = append(missing, statusPageUrl)
+			continue
+		}
+		currentIncidents[statusPageUrl] = incidents
+	}
+	if len(missing) == 0 {
+		return currentIncidents, nil
+	}
+
+	fetched, err := s.dbClient.ListCurrentIncidents(ctx, missing)
+	if err != nil {
+		s.logger.Error("failed to get current incidents from database", zap.Error(err))
+		return nil, errors.New("failed to get current incidents")
+	}
+	for _, statusPageUrl := range missing {
+		incidents := fetched[statusPageUrl]
+		if incidents == nil {
+			incidents = []api.Incident{}
+		}
+		s.currentIncidentCache.Set(statusPageUrl, incidents, cache.DefaultExpiration)
+		currentIncidents[statusPageUrl] = incidents
+	}
+	return currentIncidents, nil
+}
+
+// loadStatusPageIncidents returns a page of incidents of each status page, the keys with the same arguments share a query
+// A page has one more incident than requested when there is a next page
+func (s *Server) loadStatusPageIncidents(ctx context.Context, keys []statusPageIncidentsKey) (map[statusPageIncidentsKey][]api.Incident, error) {
+	statusPageUrlsByArgs := make(map[incidentsArgs][]string)
+	for _, key := range keys {
+		statusPageUrlsByArgs[key.args] = append(statusPageUrlsByArgs[key.args], key.statusPageUrl)
+	}
+
+	incidents := make(map[statusPageIncidentsKey][]api.Incident, len(keys))
+	for args, statusPageUrls := range statusPageUrlsByArgs {
+		filter, err := args.filter()
+		if err != nil {
+			return nil, err
+		}
+		filter.StatusPageUrls = statusPageUrls
+		byStatusPage, err := s.dbClient.ListIncidentsPerStatusPage(ctx, filter, args.first+1)
+		if err != nil {
+			s.logger.Error("failed to get incidents from database", zap.Error(err))
+			return nil, errors.New("failed to get incidents")
+		}
+		for statusPageUrl, statusPageIncidents := range byStatusPage {
+			incidents[statusPageIncidentsKey{statusPageUrl: statusPageUrl, args: args}] = statusPageIncidents
+		}
+	}
+	return incidents, nil
+}
+
+// loadComponents returns the components of each status page that had an incident in the last days of its key, by name
+func (s *Server) loadComponents(ctx context.Context, keys []statusPageComponentsKey) (map[statusPageComponentsKey][]graphqlComponent, error) {
+	statusPageUrlsByDays := make(map[int][]string)
+	for _, key := range keys {
+		statusPageUrlsByDays[key.days] = append(statusPageUrlsByDays[key.days], key.statusPageUrl)
+	}
+
+	components := make(map[statusPageComponentsKey][]graphqlComponent, len(keys))
+	for days, statusPageUrls := range statusPageUrlsByDays {
+		lastStartTimes, err := s.dbClient.GetLastComponentIncidentStartTimes(ctx, statusPageUrls, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			s.logger.Error("failed to get components from database", zap.Error(err))
+			return nil, errors.New("failed to get components")
+		}
+		for statusPageUrl, componentStartTimes := range lastStartTimes {
+			key := statusPageComponentsKey{statusPageUrl: statusPageUrl, days: days}
+			for name, lastStartTime := range componentStartTimes {
+				components[key] = append(components[key], graphqlComponent{Name: name, StatusPageUrl: statusPageUrl, LastIncidentStartTime: lastStartTime})
+			}
+			sort.Slice(components[key], func(i, j int) bool {
+				return components[key][i].Name < components[key][j].Name
+			})
+		}
+	}
+	return components, nil
+}
+
+// loadUptimeDays returns the rolled up days of each key, see getUptimeDays, the keys with the same period share a query
+func (s *Server) loadUptimeDays(ctx context.Context, keys []uptimeKey) (map[uptimeKey][]api.UptimeDay, error) {
+	type period struct {
+		from time.Time
+		to   time.Time
+	}
+	keysByPeriod := make(map[period][]uptimeKey)
+	for _, key := range keys {
+		keysByPeriod[period{from: key.from, to: key.to}] = append(keysByPeriod[period{from: key.from, to: key.to}], key)
+	}
+
+	uptimeDays := make(map[uptimeKey][]api.UptimeDay, len(keys))
+	for period, periodKeys := range keysByPeriod {
+		statusPageUrls := make(map[string]bool)
+		// The days of the whole status pages are always needed, a component only has a rollup on the days it had an incident
+		components := map[string]bool{uptime.PageComponent: true}
+		for _, key := range periodKeys {
+			statusPageUrls[key.statusPageUrl] = true
+			components[key.component] = true
+		}
+		days, err := s.dbClient.ListUptimeDays(ctx, mapKeys(statusPageUrls), mapKeys(components), period.from, period.to)
+		if err != nil {
+			s.logger.Error("failed to get uptime from database", zap.Error(err))
+			return nil, errors.New("failed to get uptime")
+		}
+
+		type source struct {
+			statusPageUrl string
+			component     string
+		}
+		daysBySource := make(map[source][]api.UptimeDay)
+		for _, day := range days {
+			daysBySource[source{statusPageUrl: day.StatusPageUrl, component: day.Component}] = append(daysBySource[source{statusPageUrl: day.StatusPageUrl, component: day.Component}], day)
+		}
+		for _, key := range periodKeys {
+			pageDays := daysBySource[source{statusPageUrl: key.statusPageUrl, component: uptime.PageComponent}]
+			if key.component == uptime.PageComponent {
+				uptimeDays[key] = pageDays
+				continue
+			}
+			uptimeDays[key] = overlayComponentDays(pageDays, daysBySource[source{statusPageUrl: key.statusPageUrl, component: key.component}])
+		}
+	}
+	return uptimeDays, nil
+}
+
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
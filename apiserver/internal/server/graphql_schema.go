@@ -0,0 +1,684 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/uptime"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultGraphqlStatusPagesFirst = 50
+const defaultGraphqlIncidentsFirst = 20
+
+// maxGraphqlFirst bounds the pages of every list, a nested list is loaded for every item of its parent
+const maxGraphqlFirst = 100
+
+const defaultGraphqlComponentsDays = 90
+const maxGraphqlComponentsDays = 366
+
+// graphqlSchema is the schema of the /graphql endpoint, the resolvers get the request from the context, see graphqlContext
+var graphqlSchema = mustNewGraphqlSchema()
+
+type graphqlPageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+	// EndCursor is nil when the page is empty
+	EndCursor *string `json:"endCursor"`
+}
+
+type graphqlIncidentConnection struct {
+	Nodes    []api.Incident  `json:"nodes"`
+	PageInfo graphqlPageInfo `json:"pageInfo"`
+}
+
+type graphqlStatusPageConnection struct {
+	Nodes      []api.StatusPage `json:"nodes"`
+	PageInfo   graphqlPageInfo  `json:"pageInfo"`
+	TotalCount int              `json:"totalCount"`
+}
+
+// incidentsArgs are the arguments of the fields that list incidents, they are kept as given so that they can key the batches
+type incidentsArgs struct {
+	impacts   string
+	from      string
+	to        string
+	ongoing   bool
+	component string
+	query     string
+	first     int
+	after     string
+}
+
+func mustNewGraphqlSchema() graphql.Schema {
+	impactEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Impact",
+		Values: graphql.EnumValueConfigMap{
+			"CRITICAL":    {Value: api.ImpactCritical},
+			"MAJOR":       {Value: api.ImpactMajor},
+			"MINOR":       {Value: api.ImpactMinor},
+			"NONE":        {Value: api.ImpactNone},
+			"MAINTENANCE": {Value: api.ImpactMaintenance},
+		},
+	})
+	incidentStateEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "IncidentState",
+		Values: graphql.EnumValueConfigMap{
+			"INVESTIGATING": {Value: api.IncidentStateInvestigating},
+			"IDENTIFIED":    {Value: api.IncidentStateIdentified},
+			"MONITORING":    {Value: api.IncidentStateMonitoring},
+			"RESOLVED":      {Value: api.IncidentStateResolved},
+		},
+	})
+	statusLevelEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "StatusLevel",
+		Values: graphql.EnumValueConfigMap{
+			string(StatusLevelOperational):    {Value: StatusLevelOperational},
+			string(StatusLevelMaintenance):    {Value: StatusLevelMaintenance},
+			string(StatusLevelMinorOutage):    {Value: StatusLevelMinorOutage},
+			string(StatusLevelMajorOutage):    {Value: StatusLevelMajorOutage},
+			string(StatusLevelCriticalOutage): {Value: StatusLevelCriticalOutage},
+			string(StatusLevelStale):          {Value: StatusLevelStale},
+		},
+	})
+	granularityEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "UptimeGranularity",
+		Values: graphql.EnumValueConfigMap{
+			"DAY":   {Value: GranularityDay},
+			"MONTH": {Value: GranularityMonth},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": {Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   {Type: graphql.String, Description: "Pass it as the after argument to get the next page"},
+		},
+	})
+	incidentEventType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IncidentEvent",
+		Fields: graphql.Fields{
+			"title":       {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.NewNonNull(graphql.String)},
+			"time":        {Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+	uptimeBucketType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UptimeBucket",
+		Fields: graphql.Fields{
+			"start":                {Type: graphql.NewNonNull(graphql.DateTime)},
+			"periodSeconds":        {Type: graphql.NewNonNull(graphql.Float)},
+			"fullOutageSeconds":    {Type: graphql.NewNonNull(graphql.Float)},
+			"partialOutageSeconds": {Type: graphql.NewNonNull(graphql.Float)},
+			"uptimePercentage":     {Type: graphql.NewNonNull(graphql.Float)},
+			"slaBreached":          {Type: graphql.Boolean, Description: "Only set when the status page has an sla target"},
+		},
+	})
+	uptimeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Uptime",
+		Description: "Uptime from the daily rollups of the jobrunner, days that have not been rolled up yet are missing",
+		Fields: graphql.Fields{
+			"statusPageUrl":    {Type: graphql.NewNonNull(graphql.String)},
+			"component":        {Type: graphql.NewNonNull(graphql.String), Description: "Empty for the whole status page"},
+			"granularity":      {Type: graphql.NewNonNull(granularityEnum)},
+			"from":             {Type: graphql.NewNonNull(graphql.DateTime)},
+			"to":               {Type: graphql.NewNonNull(graphql.DateTime)},
+			"slaTarget":        {Type: graphql.Float},
+			"uptimePercentage": {Type: graphql.NewNonNull(graphql.Float)},
+			"slaBreached":      {Type: graphql.Boolean},
+			"buckets":          {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(uptimeBucketType)))},
+		},
+	})
+
+	var statusPageType, incidentType, componentType *graphql.Object
+	incidentConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IncidentConnection",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"nodes":    {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(incidentType)))},
+				"pageInfo": {Type: graphql.NewNonNull(pageInfoType)},
+			}
+		}),
+	})
+	statusPageConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StatusPageConnection",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"nodes":      {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(statusPageType)))},
+				"pageInfo":   {Type: graphql.NewNonNull(pageInfoType)},
+				"totalCount": {Type: graphql.NewNonNull(graphql.Int)},
+			}
+		}),
+	})
+
+	incidentsArguments := func(first int) graphql.FieldConfigArgument {
+		return graphql.FieldConfigArgument{
+			"impacts":   {Type: graphql.NewList(graphql.NewNonNull(impactEnum)), Description: "Default is every impact"},
+			"from":      {Type: graphql.String, Description: "Only incidents overlapping the window, a date or an RFC3339 timestamp"},
+			"to":        {Type: graphql.String, Description: "Only incidents overlapping the window, a date or an RFC3339 timestamp"},
+			"ongoing":   {Type: graphql.Boolean, DefaultValue: false, Description: "Only incidents that have not ended"},
+			"component": {Type: graphql.String, Description: "Only incidents affecting the component"},
+			"query":     {Type: graphql.String, Description: "Only incidents containing the text"},
+			"first":     {Type: graphql.Int, DefaultValue: first, Description: fmt.Sprintf("At most %d", maxGraphqlFirst)},
+			"after":     {Type: graphql.String, Description: "endCursor of the previous page"},
+		}
+	}
+	uptimeArguments := graphql.FieldConfigArgument{
+		"from":        {Type: graphql.String, Description: "A date or an RFC3339 timestamp, default is 30 days before to"},
+		"to":          {Type: graphql.String, Description: "A date or an RFC3339 timestamp, default is today"},
+		"granularity": {Type: granularityEnum, DefaultValue: GranularityDay},
+	}
+
+	componentType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Component",
+		Description: "A component of a status page, the components are known from the incidents affecting them",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name":                  {Type: graphql.NewNonNull(graphql.String)},
+				"lastIncidentStartTime": {Type: graphql.NewNonNull(graphql.DateTime), Description: "Start of the latest incident affecting the component, maintenance excluded"},
+				"statusPage": {
+					Type:    graphql.NewNonNull(statusPageType),
+					Resolve: resolveComponentStatusPage,
+				},
+				"uptime": {
+					Type:    graphql.NewNonNull(uptimeType),
+					Args:    uptimeArguments,
+					Resolve: resolveComponentUptime,
+				},
+			}
+		}),
+	})
+
+	incidentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Incident",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          {Type: graphql.NewNonNull(graphql.ID), Resolve: resolveIncidentID},
+				"title":       {Type: graphql.NewNonNull(graphql.String)},
+				"description": {Type: graphql.String},
+				"impact":      {Type: graphql.NewNonNull(impactEnum)},
+				"state":       {Type: incidentStateEnum, Description: "Null when the state could not be derived"},
+				"startTime":   {Type: graphql.NewNonNull(graphql.DateTime)},
+				"endTime":     {Type: graphql.DateTime},
+				"deepLink":    {Type: graphql.NewNonNull(graphql.String)},
+				"externalId":  {Type: graphql.NewNonNull(graphql.String)},
+				"provider":    {Type: graphql.NewNonNull(graphql.String)},
+				"components": {
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Resolve: resolveIncidentComponents,
+				},
+				"events": {
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(incidentEventType))),
+					Resolve: resolveIncidentEvents,
+				},
+				"statusPage": {
+					Type:    statusPageType,
+					Resolve: resolveIncidentStatusPage,
+				},
+			}
+		}),
+	})
+
+	statusPageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "StatusPage",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"url":                     {Type: graphql.NewNonNull(graphql.String)},
+				"name":                    {Type: graphql.NewNonNull(graphql.String)},
+				"isIndexed":               {Type: graphql.NewNonNull(graphql.Boolean)},
+				"slaTarget":               {Type: graphql.Float},
+				"lastSuccessfullyScraped": {Type: graphql.DateTime, Description: "Null if the status page has never been scraped successfully"},
+				"level": {
+					Type:    graphql.NewNonNull(statusLevelEnum),
+					Resolve: resolveStatusPageLevel,
+				},
+				"reason": {
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "Explains the level in a human readable way",
+					Resolve:     resolveStatusPageReason,
+				},
+				"currentIncidents": {
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(incidentType))),
+					Resolve: resolveStatusPageCurrentIncidents,
+				},
+				"incidents": {
+					Type:        graphql.NewNonNull(incidentConnectionType),
+					Description: "Incidents of the status page, newest first",
+					Args:        incidentsArguments(defaultGraphqlIncidentsFirst),
+					Resolve:     resolveStatusPageIncidents,
+				},
+				"components": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(componentType))),
+					Description: "Components that had an incident in the last days, by name",
+					Args: graphql.FieldConfigArgument{
+						"days": {Type: graphql.Int, DefaultValue: defaultGraphqlComponentsDays, Description: fmt.Sprintf("At most %d", maxGraphqlComponentsDays)},
+					},
+					Resolve: resolveStatusPageComponents,
+				},
+				"uptime": {
+					Type:    graphql.NewNonNull(uptimeType),
+					Args:    uptimeArguments,
+					Resolve: resolveStatusPageUptime,
+				},
+			}
+		}),
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"statusPage": {
+				Type:        statusPageType,
+				Description: "Null if the status page is not known to statusphere",
+				Args: graphql.FieldConfigArgument{
+					"url": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveStatusPage,
+			},
+			"statusPages": {
+				Type:        graphql.NewNonNull(statusPageConnectionType),
+				Description: "Status pages by name",
+				Args: graphql.FieldConfigArgument{
+					"query":     {Type: graphql.String, Description: "Only status pages whose name or url contains the text, case insensitive"},
+					"isIndexed": {Type: graphql.Boolean, Description: "Only status pages that are indexed, or not"},
+					"first":     {Type: graphql.Int, DefaultValue: defaultGraphqlStatusPagesFirst, Description: fmt.Sprintf("At most %d", maxGraphqlFirst)},
+					"after":     {Type: graphql.String, Description: "endCursor of the previous page"},
+				},
+				Resolve: resolveStatusPages,
+			},
+			"incident": {
+				Type:        incidentType,
+				Description: "Null if the incident does not exist",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolveIncident,
+			},
+			"incidents": {
+				Type:        graphql.NewNonNull(incidentConnectionType),
+				Description: "Incidents of several status pages merged, newest first",
+				Args: func() graphql.FieldConfigArgument {
+					args := incidentsArguments(defaultGraphqlIncidentsFirst)
+					args["statusPageUrls"] = &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Default is every status page"}
+					return args
+				}(),
+				Resolve: resolveIncidents,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(errors.Wrap(err, "invalid graphql schema"))
+	}
+	return schema
+}
+
+func resolveStatusPage(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	url, _ := p.Args["url"].(string)
+	statusPage, found, err := request.server.getStatusPageFromCache(url)
+	if err != nil {
+		return nil, err
+	}
+	if !found || !request.scope.canSee(url) {
+		return nil, nil
+	}
+	return statusPage, nil
+}
+
+func resolveStatusPages(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	first, err := graphqlFirstArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	query, _ := p.Args["query"].(string)
+	isIndexed, filterIndexed := p.Args["isIndexed"].(bool)
+
+	var statusPages []api.StatusPage
+	for _, statusPage := range request.server.getVisibleStatusPages(request.scope) {
+		if filterIndexed && statusPage.IsIndexed != isIndexed {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(statusPage.Name), strings.ToLower(query)) && !strings.Contains(strings.ToLower(statusPage.URL), strings.ToLower(query)) {
+			continue
+		}
+		statusPages = append(statusPages, statusPage)
+	}
+	sort.Slice(statusPages, func(i, j int) bool {
+		if !strings.EqualFold(statusPages[i].Name, statusPages[j].Name) {
+			return strings.ToLower(statusPages[i].Name) < strings.ToLower(statusPages[j].Name)
+		}
+		return statusPages[i].URL < statusPages[j].URL
+	})
+
+	connection := graphqlStatusPageConnection{Nodes: []api.StatusPage{}, TotalCount: len(statusPages)}
+	start := 0
+	if after, _ := p.Args["after"].(string); after != "" {
+		url, err := base64.RawURLEncoding.DecodeString(after)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		start = -1
+		for i, statusPage := range statusPages {
+			if statusPage.URL == string(url) {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, errors.New("invalid cursor, the status page is gone")
+		}
+	}
+	end := min(start+first, len(statusPages))
+	connection.Nodes = append(connection.Nodes, statusPages[start:end]...)
+	connection.PageInfo.HasNextPage = end < len(statusPages)
+	if len(connection.Nodes) > 0 {
+		endCursor := base64.RawURLEncoding.EncodeToString([]byte(connection.Nodes[len(connection.Nodes)-1].URL))
+		connection.PageInfo.EndCursor = &endCursor
+	}
+	return connection, nil
+}
+
+func resolveIncident(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	idStr, _ := p.Args["id"].(string)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, errors.New("id must be an integer")
+	}
+	incident, err := request.server.dbClient.GetIncident(p.Context, id)
+	if err != nil {
+		request.server.logger.Error("failed to get incident from database", zap.Error(err))
+		return nil, errors.New("failed to get incident")
+	}
+	if incident == nil || !request.scope.canSee(incident.StatusPageUrl) {
+		return nil, nil
+	}
+	return *incident, nil
+}
+
+func resolveIncidents(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	args, err := parseGraphqlIncidentsArgs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := args.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	var statusPageUrls []string
+	if urls, ok := p.Args["statusPageUrls"].([]interface{}); ok {
+		for _, url := range urls {
+			statusPageUrls = append(statusPageUrls, url.(string))
+		}
+	}
+	statusPageUrls, ok := request.scope.restrict(statusPageUrls)
+	if !ok {
+		return newGraphqlIncidentConnection(nil, args.first), nil
+	}
+	filter.StatusPageUrls = statusPageUrls
+	// Fetch one more incident than requested to know if there is a next page
+	filter.Limit = args.first + 1
+
+	incidents, err := request.server.dbClient.ListIncidents(p.Context, filter)
+	if err != nil {
+		request.server.logger.Error("failed to get incidents from database", zap.Error(err))
+		return nil, errors.New("failed to get incidents")
+	}
+	return newGraphqlIncidentConnection(incidents, args.first), nil
+}
+
+func resolveStatusPageLevel(p graphql.ResolveParams) (interface{}, error) {
+	return resolveStatusPageStatus(p, func(level StatusLevel, reason string) interface{} { return level })
+}
+
+func resolveStatusPageReason(p graphql.ResolveParams) (interface{}, error) {
+	return resolveStatusPageStatus(p, func(level StatusLevel, reason string) interface{} { return reason })
+}
+
+// resolveStatusPageStatus resolves a field of the status level of the status page from its current incidents, see computeStatusLevel
+func resolveStatusPageStatus(p graphql.ResolveParams, field func(level StatusLevel, reason string) interface{}) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	statusPage := p.Source.(api.StatusPage)
+	if !statusPage.IsIndexed {
+		return field(computeStatusLevel(statusPage, nil, request.now)), nil
+	}
+	load := request.loaders.currentIncidents.load(statusPage.URL)
+	return func() (interface{}, error) {
+		incidents, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return field(computeStatusLevel(statusPage, incidents, request.now)), nil
+	}, nil
+}
+
+func resolveStatusPageCurrentIncidents(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	statusPage := p.Source.(api.StatusPage)
+	if !statusPage.IsIndexed {
+		return []api.Incident{}, nil
+	}
+	load := request.loaders.currentIncidents.load(statusPage.URL)
+	return func() (interface{}, error) {
+		incidents, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if incidents == nil {
+			return []api.Incident{}, nil
+		}
+		return incidents, nil
+	}, nil
+}
+
+func resolveStatusPageIncidents(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	statusPage := p.Source.(api.StatusPage)
+	args, err := parseGraphqlIncidentsArgs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	// The arguments are checked before they are batched, so that a bad argument fails its own field only
+	if _, err := args.filter(); err != nil {
+		return nil, err
+	}
+	load := request.loaders.incidents.load(statusPageIncidentsKey{statusPageUrl: statusPage.URL, args: args})
+	return func() (interface{}, error) {
+		incidents, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return newGraphqlIncidentConnection(incidents, args.first), nil
+	}, nil
+}
+
+func resolveStatusPageComponents(p graphql.ResolveParams) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	statusPage := p.Source.(api.StatusPage)
+	days, _ := p.Args["days"].(int)
+	if days <= 0 || days > maxGraphqlComponentsDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", maxGraphqlComponentsDays)
+	}
+	load := request.loaders.components.load(statusPageComponentsKey{statusPageUrl: statusPage.URL, days: days})
+	return func() (interface{}, error) {
+		components, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if components == nil {
+			return []graphqlComponent{}, nil
+		}
+		return components, nil
+	}, nil
+}
+
+func resolveStatusPageUptime(p graphql.ResolveParams) (interface{}, error) {
+	return resolveUptime(p, p.Source.(api.StatusPage).URL, uptime.PageComponent)
+}
+
+func resolveComponentUptime(p graphql.ResolveParams) (interface{}, error) {
+	component := p.Source.(graphqlComponent)
+	return resolveUptime(p, component.StatusPageUrl, component.Name)
+}
+
+// resolveUptime resolves the uptime of a status page, or of one of its components, like the /uptime endpoint
+func resolveUptime(p graphql.ResolveParams, statusPageUrl string, component string) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	fromStr, _ := p.Args["from"].(string)
+	toStr, _ := p.Args["to"].(string)
+	from, to, err := parseUptimeRange(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+	granularity, _ := p.Args["granularity"].(Granularity)
+	statusPage, found, err := request.server.getStatusPageFromCache(statusPageUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("status page not known to statusphere")
+	}
+
+	load := request.loaders.uptimeDays.load(uptimeKey{statusPageUrl: statusPageUrl, component: component, from: from, to: to})
+	return func() (interface{}, error) {
+		days, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return newUptimeResponse(statusPage, component, granularity, from, to, days), nil
+	}, nil
+}
+
+func resolveComponentStatusPage(p graphql.ResolveParams) (interface{}, error) {
+	return resolveStatusPageOf(p, p.Source.(graphqlComponent).StatusPageUrl)
+}
+
+func resolveIncidentStatusPage(p graphql.ResolveParams) (interface{}, error) {
+	return resolveStatusPageOf(p, p.Source.(api.Incident).StatusPageUrl)
+}
+
+// resolveStatusPageOf resolves the status page of an item from the status page cache, it is null if the status page is gone
+func resolveStatusPageOf(p graphql.ResolveParams, statusPageUrl string) (interface{}, error) {
+	request := getGraphqlContext(p.Context)
+	statusPage, found, err := request.server.getStatusPageFromCache(statusPageUrl)
+	if err != nil || !found {
+		return nil, err
+	}
+	return statusPage, nil
+}
+
+func resolveIncidentID(p graphql.ResolveParams) (interface{}, error) {
+	return strconv.FormatInt(p.Source.(api.Incident).ID, 10), nil
+}
+
+func resolveIncidentComponents(p graphql.ResolveParams) (interface{}, error) {
+	components := p.Source.(api.Incident).Components
+	if components == nil {
+		return []string{}, nil
+	}
+	return components, nil
+}
+
+func resolveIncidentEvents(p graphql.ResolveParams) (interface{}, error) {
+	events := p.Source.(api.Incident).Events
+	if events == nil {
+		return []api.IncidentEvent{}, nil
+	}
+	return []api.IncidentEvent(events), nil
+}
+
+// graphqlFirstArg returns the first argument of a paginated field
+func graphqlFirstArg(args map[string]interface{}) (int, error) {
+	first, _ := args["first"].(int)
+	if first <= 0 || first > maxGraphqlFirst {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxGraphqlFirst)
+	}
+	return first, nil
+}
+
+func parseGraphqlIncidentsArgs(args map[string]interface{}) (incidentsArgs, error) {
+	var parsed incidentsArgs
+	first, err := graphqlFirstArg(args)
+	if err != nil {
+		return parsed, err
+	}
+	parsed.first = first
+
+	if impacts, ok := args["impacts"].([]interface{}); ok {
+		var names []string
+		for _, impact := range impacts {
+			names = append(names, string(impact.(api.Impact)))
+		}
+		parsed.impacts = strings.Join(names, ",")
+	}
+	parsed.from, _ = args["from"].(string)
+	parsed.to, _ = args["to"].(string)
+	parsed.ongoing, _ = args["ongoing"].(bool)
+	parsed.component, _ = args["component"].(string)
+	parsed.query, _ = args["query"].(string)
+	parsed.after, _ = args["after"].(string)
+	return parsed, nil
+}
+
+// filter returns the incident filter of the arguments, without the status pages and the limit
+func (a incidentsArgs) filter() (db.IncidentFilter, error) {
+	filter := db.IncidentFilter{Ongoing: a.ongoing, Component: a.component, Query: a.query}
+	if a.impacts != "" {
+		for _, impact := range strings.Split(a.impacts, ",") {
+			filter.Impacts = append(filter.Impacts, api.Impact(impact))
+		}
+	}
+	if a.from != "" {
+		from, err := parseTimeQuery(a.from)
+		if err != nil {
+			return filter, errors.New("from must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		filter.From = &from
+	}
+	if a.to != "" {
+		to, err := parseTimeQuery(a.to)
+		if err != nil {
+			return filter, errors.New("to must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+	if a.after != "" {
+		cursor, err := decodeIncidentCursor(a.after)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Cursor = &cursor
+	}
+	return filter, nil
+}
+
+// newGraphqlIncidentConnection returns the page of the incidents, which have one more incident than the page when there is a next page
+func newGraphqlIncidentConnection(incidents []api.Incident, first int) graphqlIncidentConnection {
+	connection := graphqlIncidentConnection{Nodes: []api.Incident{}}
+	if len(incidents) > first {
+		incidents = incidents[:first]
+		connection.PageInfo.HasNextPage = true
+	}
+	connection.Nodes = append(connection.Nodes, incidents...)
+	if len(incidents) > 0 {
+		last := incidents[len(incidents)-1]
+		endCursor := encodeIncidentCursor(db.IncidentCursor{StartTime: last.StartTime, ID: last.ID})
+		connection.PageInfo.EndCursor = &endCursor
+	}
+	return connection
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/testutil"
+	"github.com/metoro-io/statusphere/apiserver/client"
+)
+
+func TestBatchLoaderFetchesQueuedKeysOnce(t *testing.T) {
+	var fetches [][]string
+	loader := newBatchLoader(func(keys []string) (map[string]int, error) {
+		fetches = append(fetches, keys)
+		values := make(map[string]int)
+		for _, key := range keys {
+			values[key] = len(key)
+		}
+		return values, nil
+	})
+
+	a := loader.load("a")
+	bb := loader.load("bb")
+	again := loader.load("a")
+	if value, err := bb(); value != 2 || err != nil {
+		t.Errorf("expected 2, got %d: %v", value, err)
+	}
+	if value, err := a(); value != 1 || err != nil {
+		t.Errorf("expected 1, got %d: %v", value, err)
+	}
+	if value, _ := again(); value != 1 {
+		t.Errorf("expected 1, got %d", value)
+	}
+
+	// Keys queued after a fetch are fetched in the next batch, known keys are not fetched again
+	ccc := loader.load("ccc")
+	loader.load("a")
+	if value, _ := ccc(); value != 3 {
+		t.Errorf("expected 3, got %d", value)
+	}
+	if expected := [][]string{{"a", "bb"}, {"ccc"}}; !reflect.DeepEqual(fetches, expected) {
+		t.Errorf("expected the fetches %v, got %v", expected, fetches)
+	}
+}
+
+func TestBatchLoaderReturnsFetchError(t *testing.T) {
+	loader := newBatchLoader(func(keys []string) (map[string]int, error) {
+		return nil, errors.New("database is down")
+	})
+	first, second := loader.load("a"), loader.load("b")
+	if _, err := first(); err == nil {
+		t.Error("expected the fetch error")
+	}
+	if _, err := second(); err == nil {
+		t.Error("expected the fetch error for every key of the batch")
+	}
+}
+
+func TestGraphqlQuery(t *testing.T) {
+	server := httptest.NewServer(newContractTestServer(t).router())
+	defer server.Close()
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	response, err := c.GraphQL(ctx, client.GraphqlRequest{
+		Query: `query Pages($first: Int) {
+			statusPages(first: $first) {
+				totalCount
+				nodes { name level isIndexed currentIncidents { id impact state components statusPage { name } } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}`,
+		Variables: map[string]interface{}{"first": 1},
+	})
+	if err != nil || len(response.Errors) != 0 {
+		t.Fatalf("unexpected errors %+v: %v", response, err)
+	}
+	type statusPagesData struct {
+		StatusPages struct {
+			TotalCount int `json:"totalCount"`
+			Nodes      []struct {
+				Name             string `json:"name"`
+				Level            string `json:"level"`
+				IsIndexed        bool   `json:"isIndexed"`
+				CurrentIncidents []struct {
+					ID         string   `json:"id"`
+					Impact     string   `json:"impact"`
+					State      string   `json:"state"`
+					Components []string `json:"components"`
+					StatusPage struct {
+						Name string `json:"name"`
+					} `json:"statusPage"`
+				} `json:"currentIncidents"`
+			} `json:"nodes"`
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+		} `json:"statusPages"`
+	}
+	var data statusPagesData
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page := data.StatusPages
+	if page.TotalCount != 2 || len(page.Nodes) != 1 || !page.PageInfo.HasNextPage || page.PageInfo.EndCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	example := page.Nodes[0]
+	if example.Name != "Example" || example.Level != "MAJOR_OUTAGE" || len(example.CurrentIncidents) != 1 {
+		t.Fatalf("unexpected status page %+v", example)
+	}
+	incident := example.CurrentIncidents[0]
+	if incident.ID != "1" || incident.Impact != "MAJOR" || incident.State != "INVESTIGATING" || incident.StatusPage.Name != "Example" || !reflect.DeepEqual(incident.Components, []string{"API"}) {
+		t.Errorf("unexpected incident %+v", incident)
+	}
+
+	// The unindexed status page is on the next page, its status does not need its incidents and it has never been scraped
+	response, err = c.GraphQL(ctx, client.GraphqlRequest{
+		Query:     `query Next($after: String) { statusPages(after: $after) { nodes { name level currentIncidents { id } } pageInfo { hasNextPage } } }`,
+		Variables: map[string]interface{}{"after": page.PageInfo.EndCursor},
+	})
+	if err != nil || len(response.Errors) != 0 {
+		t.Fatalf("unexpected errors %+v: %v", response, err)
+	}
+	var next statusPagesData
+	if err := json.Unmarshal(response.Data, &next); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page = next.StatusPages
+	if len(page.Nodes) != 1 || page.Nodes[0].Name != "Unindexed" || page.Nodes[0].Level != "STALE" || len(page.Nodes[0].CurrentIncidents) != 0 || page.PageInfo.HasNextPage {
+		t.Errorf("unexpected second page %+v", page)
+	}
+}
+
+func TestGraphqlQueryErrors(t *testing.T) {
+	server := httptest.NewServer(newContractTestServer(t).router())
+	defer server.Close()
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	// A field with a bad argument fails on its own, the other fields are resolved
+	response, err := c.GraphQL(ctx, client.GraphqlRequest{
+		Query: `{ statusPage(url: "https://status.example.com") { name incidents(first: 0) { nodes { id } } } }`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Message != "first must be between 1 and 100" {
+		t.Errorf("expected the error of the incidents field, got %+v", response.Errors)
+	}
+	if expected := `{"statusPage":null}`; string(response.Data) != expected {
+		t.Errorf("expected the error to null the status page as incidents is not nullable, got %s", response.Data)
+	}
+
+	response, err = c.GraphQL(ctx, client.GraphqlRequest{Query: `{ statusPage(url: "https://status.unknown.com") { name } }`})
+	if err != nil || string(response.Data) != `{"statusPage":null}` {
+		t.Errorf("expected an unknown status page to be null, got %+v: %v", response, err)
+	}
+
+	_, err = c.GraphQL(ctx, client.GraphqlRequest{Query: `{ statusPages { nodes { notAField } } }`})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message == "" {
+		t.Errorf("expected an invalid query to fail, got %v", err)
+	}
+}
+
+func TestGraphqlQueryLimits(t *testing.T) {
+	server := httptest.NewServer(newContractTestServer(t).router())
+	defer server.Close()
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		request client.GraphqlRequest
+		// rejected is a part of the error of a rejected query, empty if the query runs
+		rejected string
+	}{
+		{
+			name:    "introspection",
+			request: client.GraphqlRequest{Query: testutil.IntrospectionQuery},
+		},
+		{
+			name:     "status pages and incidents nested in each other",
+			request:  client.GraphqlRequest{Query: `{ incidents { nodes { statusPage { incidents { nodes { statusPage { incidents { nodes { statusPage { incidents { nodes { statusPage { incidents { nodes { statusPage { name } } } } } } } } } } } } } } } }`},
+			rejected: "nested 16 levels deep",
+		},
+		{
+			name: "nested in each other through fragments",
+			request: client.GraphqlRequest{Query: `
+				{ statusPages(first: 100) { nodes { ...Incidents } } }
+				fragment Incidents on StatusPage { incidents(first: 100) { nodes { statusPage { incidents(first: 100) { nodes { id } } } } } }`,
+			},
+			rejected: "fields",
+		},
+		{
+			name:     "too many items",
+			request:  client.GraphqlRequest{Query: `query Pages($first: Int) { statusPages(first: 100) { nodes { incidents(first: $first) { nodes { id title startTime endTime events { title time } } } } } }`, Variables: map[string]interface{}{"first": 100}},
+			rejected: "fields",
+		},
+		{
+			name:    "a page of every status page",
+			request: client.GraphqlRequest{Query: `query Pages($first: Int) { statusPages(first: $first) { nodes { name level currentIncidents { id title startTime } } } }`, Variables: map[string]interface{}{"first": 100}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := c.GraphQL(ctx, test.request)
+			var apiErr *client.Error
+			if test.rejected == "" {
+				if err != nil {
+					t.Errorf("expected the query to run, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || !strings.Contains(apiErr.Message, test.rejected) {
+				t.Errorf("expected the query to be rejected with %q, got %v", test.rejected, err)
+			}
+		})
+	}
+}
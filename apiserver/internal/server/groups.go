@@ -0,0 +1,368 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const maxServiceGroupMembers = 50
+
+// nameRegex matches the names of service groups and workspaces, which are used in urls
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// weightedStatusSeverities are the severities averaged by the weighted aggregation, maintenance is planned so it is not an outage
+var weightedStatusSeverities = map[StatusLevel]float64{
+	StatusLevelOperational:    0,
+	StatusLevelMaintenance:    0,
+	StatusLevelMinorOutage:    1,
+	StatusLevelMajorOutage:    2,
+	StatusLevelCriticalOutage: 3,
+}
+
+type ServiceGroupsResponse struct {
+	ServiceGroups []api.ServiceGroup `json:"serviceGroups"`
+}
+
+type ServiceGroupResponse struct {
+	ServiceGroup api.ServiceGroup `json:"serviceGroup"`
+}
+
+type ServiceGroupMemberStatus struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	// StatusPageName is empty if the status page is not known to statusphere anymore
+	StatusPageName string      `json:"statusPageName"`
+	Component      string      `json:"component,omitempty"`
+	Weight         float64     `json:"weight"`
+	Level          StatusLevel `json:"level"`
+	Reason         string      `json:"reason"`
+}
+
+type ServiceGroupStatusResponse struct {
+	Name        string                      `json:"name"`
+	Aggregation api.ServiceGroupAggregation `json:"aggregation"`
+	// Level is the composite status of the members, STALE members are left out unless every member is STALE
+	Level  StatusLevel `json:"level"`
+	Reason string      `json:"reason"`
+	// Score is the weighted average severity of the members, from 0 when none has an outage to 3 when all have a critical outage
+	// It is only set for the weighted aggregation
+	Score   *float64                   `json:"score,omitempty"`
+	Members []ServiceGroupMemberStatus `json:"members"`
+}
+
+type ServiceGroupIncidentsResponse struct {
+	Incidents []api.Incident `json:"incidents"`
+	// NextCursor is set when there are more incidents, pass it as the cursor query parameter to get them
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// serviceGroups is a handler for the /groups endpoint.
+// It returns the service groups of the workspace ordered by name
+func (s *Server) serviceGroups(context *gin.Context) {
+	groups, err := s.dbClient.ListServiceGroups(context.Request.Context(), getWorkspaceScope(context).workspaceID)
+	if err != nil {
+		s.logger.Error("failed to list service groups", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list service groups"})
+		return
+	}
+	if groups == nil {
+		groups = []api.ServiceGroup{}
+	}
+	context.JSON(http.StatusOK, ServiceGroupsResponse{ServiceGroups: groups})
+}
+
+// serviceGroup is a handler for the /groups/:name endpoint.
+// It returns the definition of the service group
+func (s *Server) serviceGroup(context *gin.Context) {
+	group, ok := s.getServiceGroup(context, context.Param("name"))
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, ServiceGroupResponse{ServiceGroup: *group})
+}
+
+// serviceGroupStatus is a handler for the /groups/:name/status endpoint.
+// It has an optional query parameter of aggregation, worst or weighted (default is the aggregation of the group)
+// It returns the composite status of the group along with the status of every member, see aggregateServiceGroupStatus
+func (s *Server) serviceGroupStatus(context *gin.Context) {
+	var aggregation api.ServiceGroupAggregation
+	if aggregationStr := context.Query("aggregation"); aggregationStr != "" {
+		var err error
+		aggregation, err = api.ParseServiceGroupAggregation(aggregationStr)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	group, ok := s.getServiceGroup(context, context.Param("name"))
+	if !ok {
+		return
+	}
+	if aggregation == "" {
+		aggregation = group.Aggregation
+	}
+
+	now := time.Now()
+	scope := getWorkspaceScope(context)
+	members := make([]ServiceGroupMemberStatus, 0, len(group.Members))
+	for _, member := range group.Members {
+		memberStatus, err := s.getServiceGroupMemberStatus(context.Request.Context(), scope, member, now)
+		if err != nil {
+			s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("url", member.StatusPageUrl))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get current incidents"})
+			return
+		}
+		members = append(members, memberStatus)
+	}
+
+	level, reason, score := aggregateServiceGroupStatus(aggregation, members)
+	context.JSON(http.StatusOK, ServiceGroupStatusResponse{
+		Name:        group.Name,
+		Aggregation: aggregation,
+		Level:       level,
+		Reason:      reason,
+		Score:       score,
+		Members:     members,
+	})
+}
+
+// serviceGroupIncidents is a handler for the /groups/:name/incidents endpoint.
+// It has the impact, from, to, ongoing, component, query, limit and cursor parameters of the /incidents endpoint
+// It returns the incidents of every member merged, newest first, a member with a component only contributes the incidents affecting it
+// The members whose status page the workspace unsubscribed from are left out
+func (s *Server) serviceGroupIncidents(context *gin.Context) {
+	filter, err := parseIncidentFilter(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := parseIncidentsLimit(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	group, ok := s.getServiceGroup(context, context.Param("name"))
+	if !ok {
+		return
+	}
+
+	filter.Sources = serviceGroupIncidentSources(getWorkspaceScope(context), group)
+	if len(filter.Sources) == 0 {
+		context.JSON(http.StatusOK, ServiceGroupIncidentsResponse{Incidents: []api.Incident{}})
+		return
+	}
+	// Fetch one more incident than requested to know if there is a next page
+	filter.Limit = limit + 1
+	incidents, err := s.dbClient.ListIncidents(context.Request.Context(), filter)
+	if err != nil {
+		s.logger.Error("failed to get incidents from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+		return
+	}
+
+	response := ServiceGroupIncidentsResponse{Incidents: incidents}
+	if len(incidents) > limit {
+		response.Incidents = incidents[:limit]
+		last := response.Incidents[limit-1]
+		response.NextCursor = encodeIncidentCursor(db.IncidentCursor{StartTime: last.StartTime, ID: last.ID})
+	}
+	if response.Incidents == nil {
+		response.Incidents = []api.Incident{}
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// getServiceGroup returns the service group of the workspace with the name
+// It responds with a 404 or a 500 and returns false if the group cannot be returned
+func (s *Server) getServiceGroup(context *gin.Context, name string) (*api.ServiceGroup, bool) {
+	// Names that could not have been created do not need a lookup
+	if !nameRegex.MatchString(name) {
+		context.JSON(http.StatusNotFound, gin.H{"error": "service group not found"})
+		return nil, false
+	}
+	group, err := s.dbClient.GetServiceGroup(context.Request.Context(), getWorkspaceScope(context).workspaceID, name)
+	if err != nil {
+		s.logger.Error("failed to get service group", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service group"})
+		return nil, false
+	}
+	if group == nil {
+		context.JSON(http.StatusNotFound, gin.H{"error": "service group not found"})
+		return nil, false
+	}
+	return group, true
+}
+
+// serviceGroupIncidentSources returns the sources of the incidents of the group, leaving out the members the workspace does not see
+func serviceGroupIncidentSources(scope workspaceScope, group *api.ServiceGroup) []db.IncidentSource {
+	var sources []db.IncidentSource
+	for _, member := range group.Members {
+		if scope.canSee(member.StatusPageUrl) {
+			sources = append(sources, db.IncidentSource{StatusPageUrl: member.StatusPageUrl, Component: member.Component})
+		}
+	}
+	return sources
+}
+
+// getServiceGroupMemberStatus returns the status of the member from its current incidents
+// A member whose status page was deleted, or that the workspace unsubscribed from, since it was added to the group is STALE
+func (s *Server) getServiceGroupMemberStatus(ctx context.Context, scope workspaceScope, member api.ServiceGroupMember, now time.Time) (ServiceGroupMemberStatus, error) {
+	memberStatus := ServiceGroupMemberStatus{
+		StatusPageUrl: member.StatusPageUrl,
+		Component:     member.Component,
+		Weight:        member.Weight,
+		Level:         StatusLevelStale,
+		Reason:        "the status page is not known to statusphere anymore",
+	}
+	if !scope.canSee(member.StatusPageUrl) {
+		memberStatus.Reason = "the workspace is not subscribed to the status page anymore"
+		return memberStatus, nil
+	}
+	statusPage, found, err := s.getStatusPageFromCache(member.StatusPageUrl)
+	if err != nil || !found {
+		return memberStatus, err
+	}
+
+	var incidents []api.Incident
+	if statusPage.IsIndexed {
+		incidents, found, err = s.getCurrentIncidents(ctx, statusPage.URL)
+		if err != nil || !found {
+			return memberStatus, err
+		}
+	}
+	memberStatus.StatusPageName = statusPage.Name
+	memberStatus.Level, memberStatus.Reason = computeStatusLevel(statusPage, componentIncidents(incidents, member.Component), now)
+	return memberStatus, nil
+}
+
+// aggregateServiceGroupStatus returns the composite level of the members, the reason for it and, for the weighted aggregation, the score
+// The worst aggregation takes the level of the most affected member.
+// The weighted aggregation averages the severities of the members by their weights, with 0 for no outage and 1, 2 and 3
+// for a minor, major and critical outage, and rounds the average to the nearest level.
+// For example a critical outage of a member with a weight of 1 in a group with a total weight of 5 is a minor outage of the group.
+// STALE members say nothing about the incidents so they are left out, the group is STALE if every member is
+func aggregateServiceGroupStatus(aggregation api.ServiceGroupAggregation, members []ServiceGroupMemberStatus) (StatusLevel, string, *float64) {
+	var known []ServiceGroupMemberStatus
+	for _, member := range members {
+		if member.Level != StatusLevelStale {
+			known = append(known, member)
+		}
+	}
+	if len(known) == 0 {
+		return StatusLevelStale, "no member of the group has a known status", nil
+	}
+	staleSuffix := ""
+	if stale := len(members) - len(known); stale > 0 {
+		staleSuffix = fmt.Sprintf(", %d of %d members are stale and left out", stale, len(members))
+	}
+
+	if aggregation != api.ServiceGroupAggregationWeighted {
+		worst := known[0]
+		for _, member := range known[1:] {
+			if statusLevelMetricValues[member.Level] > statusLevelMetricValues[worst.Level] {
+				worst = member
+			}
+		}
+		if worst.Level == StatusLevelOperational {
+			return StatusLevelOperational, "every member is operational" + staleSuffix, nil
+		}
+		return worst.Level, fmt.Sprintf("%s: %s%s", serviceGroupMemberName(worst), worst.Reason, staleSuffix), nil
+	}
+
+	var totalWeight, weightedSeverity float64
+	affected := 0
+	inMaintenance := false
+	for _, member := range known {
+		totalWeight += member.Weight
+		weightedSeverity += member.Weight * weightedStatusSeverities[member.Level]
+		if member.Level != StatusLevelOperational {
+			affected++
+		}
+		if member.Level == StatusLevelMaintenance {
+			inMaintenance = true
+		}
+	}
+	score := 0.0
+	if totalWeight > 0 {
+		// Rounded so the level does not depend on floating point noise
+		score = math.Round(weightedSeverity/totalWeight*1000) / 1000
+	}
+
+	var level StatusLevel
+	switch math.Round(score) {
+	case 0:
+		level = StatusLevelOperational
+		if inMaintenance {
+			level = StatusLevelMaintenance
+		}
+	case 1:
+		level = StatusLevelMinorOutage
+	case 2:
+		level = StatusLevelMajorOutage
+	default:
+		level = StatusLevelCriticalOutage
+	}
+	return level, fmt.Sprintf("weighted severity of %g out of 3, %d of %d members are affected%s", score, affected, len(known), staleSuffix), &score
+}
+
+func serviceGroupMemberName(member ServiceGroupMemberStatus) string {
+	name := member.StatusPageName
+	if name == "" {
+		name = member.StatusPageUrl
+	}
+	if member.Component != "" {
+		name += " " + member.Component
+	}
+	return name
+}
+
+// validateServiceGroupMembers checks the members of a service group and defaults their weights to 1
+// Every status page must be known to statusphere and seen by the workspace, and a status page or component can only be a member once
+func (s *Server) validateServiceGroupMembers(scope workspaceScope, members []api.ServiceGroupMember) (api.ServiceGroupMembers, error) {
+	if len(members) == 0 {
+		return nil, errors.New("at least one member is required")
+	}
+	if len(members) > maxServiceGroupMembers {
+		return nil, fmt.Errorf("a service group has at most %d members", maxServiceGroupMembers)
+	}
+
+	validated := make(api.ServiceGroupMembers, 0, len(members))
+	seen := make(map[api.ServiceGroupMember]bool)
+	for _, member := range members {
+		member.StatusPageUrl = strings.TrimSpace(member.StatusPageUrl)
+		member.Component = strings.TrimSpace(member.Component)
+		if member.StatusPageUrl == "" {
+			return nil, errors.New("statusPageUrl is required for every member")
+		}
+		_, found, err := s.getStatusPageFromCache(member.StatusPageUrl)
+		if err != nil {
+			return nil, err
+		}
+		if !found || !scope.canSee(member.StatusPageUrl) {
+			return nil, fmt.Errorf("status page %s not known to statusphere", member.StatusPageUrl)
+		}
+		if member.Weight < 0 || math.IsNaN(member.Weight) || math.IsInf(member.Weight, 0) {
+			return nil, errors.New("the weight of a member must be positive, or 0 for the default of 1")
+		}
+		if member.Weight == 0 {
+			member.Weight = 1
+		}
+		key := api.ServiceGroupMember{StatusPageUrl: member.StatusPageUrl, Component: member.Component}
+		if seen[key] {
+			return nil, fmt.Errorf("status page %s with component %q is a member more than once", member.StatusPageUrl, member.Component)
+		}
+		seen[key] = true
+		validated = append(validated, member)
+	}
+	return validated, nil
+}
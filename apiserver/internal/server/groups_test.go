@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestAggregateServiceGroupStatus(t *testing.T) {
+	member := func(level StatusLevel, weight float64) ServiceGroupMemberStatus {
+		return ServiceGroupMemberStatus{StatusPageUrl: "https://status.example.com", StatusPageName: "Example", Weight: weight, Level: level, Reason: "reason"}
+	}
+
+	tests := []struct {
+		name          string
+		aggregation   api.ServiceGroupAggregation
+		members       []ServiceGroupMemberStatus
+		expected      StatusLevel
+		expectedScore *float64
+	}{
+		{
+			name:        "worst of operational members",
+			aggregation: api.ServiceGroupAggregationWorst,
+			members:     []ServiceGroupMemberStatus{member(StatusLevelOperational, 1), member(StatusLevelOperational, 1)},
+			expected:    StatusLevelOperational,
+		},
+		{
+			name:        "worst wins regardless of weights",
+			aggregation: api.ServiceGroupAggregationWorst,
+			members:     []ServiceGroupMemberStatus{member(StatusLevelMaintenance, 10), member(StatusLevelCriticalOutage, 0.1), member(StatusLevelMinorOutage, 1)},
+			expected:    StatusLevelCriticalOutage,
+		},
+		{
+			name:        "stale members are left out",
+			aggregation: api.ServiceGroupAggregationWorst,
+			members:     []ServiceGroupMemberStatus{member(StatusLevelStale, 1), member(StatusLevelMajorOutage, 1)},
+			expected:    StatusLevelMajorOutage,
+		},
+		{
+			name:        "every member stale",
+			aggregation: api.ServiceGroupAggregationWeighted,
+			members:     []ServiceGroupMemberStatus{member(StatusLevelStale, 1), member(StatusLevelStale, 1)},
+			expected:    StatusLevelStale,
+		},
+		{
+			name:          "light dependency down",
+			aggregation:   api.ServiceGroupAggregationWeighted,
+			members:       []ServiceGroupMemberStatus{member(StatusLevelOperational, 3), member(StatusLevelOperational, 1), member(StatusLevelCriticalOutage, 1)},
+			expected:      StatusLevelMinorOutage,
+			expectedScore: floatPointer(0.6),
+		},
+		{
+			name:          "heavy dependency down",
+			aggregation:   api.ServiceGroupAggregationWeighted,
+			members:       []ServiceGroupMemberStatus{member(StatusLevelCriticalOutage, 3), member(StatusLevelOperational, 1), member(StatusLevelOperational, 1)},
+			expected:      StatusLevelMajorOutage,
+			expectedScore: floatPointer(1.8),
+		},
+		{
+			name:          "small outage rounds down",
+			aggregation:   api.ServiceGroupAggregationWeighted,
+			members:       []ServiceGroupMemberStatus{member(StatusLevelMinorOutage, 1), member(StatusLevelOperational, 2)},
+			expected:      StatusLevelOperational,
+			expectedScore: floatPointer(0.333),
+		},
+		{
+			name:          "maintenance is not an outage",
+			aggregation:   api.ServiceGroupAggregationWeighted,
+			members:       []ServiceGroupMemberStatus{member(StatusLevelMaintenance, 1), member(StatusLevelOperational, 1)},
+			expected:      StatusLevelMaintenance,
+			expectedScore: floatPointer(0),
+		},
+		{
+			name:          "every member critical",
+			aggregation:   api.ServiceGroupAggregationWeighted,
+			members:       []ServiceGroupMemberStatus{member(StatusLevelCriticalOutage, 2), member(StatusLevelCriticalOutage, 0.5), member(StatusLevelStale, 1)},
+			expected:      StatusLevelCriticalOutage,
+			expectedScore: floatPointer(3),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, reason, score := aggregateServiceGroupStatus(test.aggregation, test.members)
+			if level != test.expected {
+				t.Errorf("expected level %s, got %s (%s)", test.expected, level, reason)
+			}
+			if reason == "" {
+				t.Error("expected a reason")
+			}
+			if (score == nil) != (test.expectedScore == nil) || (score != nil && *score != *test.expectedScore) {
+				t.Errorf("expected score %v, got %v", test.expectedScore, score)
+			}
+		})
+	}
+}
+
+func TestValidateServiceGroupMembers(t *testing.T) {
+	s := newContractTestServer(t)
+
+	defaultScope := workspaceScope{workspaceID: api.DefaultWorkspaceID}
+	members, err := s.validateServiceGroupMembers(defaultScope, []api.ServiceGroupMember{
+		{StatusPageUrl: " https://status.example.com "},
+		{StatusPageUrl: "https://status.example.com", Component: "API", Weight: 2.5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if members[0].StatusPageUrl != "https://status.example.com" || members[0].Weight != 1 || members[1].Weight != 2.5 {
+		t.Errorf("unexpected members %+v", members)
+	}
+
+	tests := []struct {
+		name          string
+		scope         workspaceScope
+		members       []api.ServiceGroupMember
+		expectedError string
+	}{
+		{name: "no members", expectedError: "at least one member"},
+		{name: "unknown status page", members: []api.ServiceGroupMember{{StatusPageUrl: "https://status.unknown.com"}}, expectedError: "not known"},
+		{
+			name:          "unsubscribed status page",
+			scope:         workspaceScope{workspaceID: 2, statusPageUrls: map[string]bool{"https://status.other.com": true}},
+			members:       []api.ServiceGroupMember{{StatusPageUrl: "https://status.example.com"}},
+			expectedError: "not known",
+		},
+		{name: "negative weight", members: []api.ServiceGroupMember{{StatusPageUrl: "https://status.example.com", Weight: -1}}, expectedError: "weight"},
+		{
+			name: "duplicate member",
+			members: []api.ServiceGroupMember{
+				{StatusPageUrl: "https://status.example.com", Component: "API"},
+				{StatusPageUrl: "https://status.example.com", Component: "API", Weight: 2},
+			},
+			expectedError: "more than once",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope := test.scope
+			if scope.workspaceID == 0 {
+				scope = defaultScope
+			}
+			_, err := s.validateServiceGroupMembers(scope, test.members)
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("expected an error containing %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestServiceGroupMemberStatus(t *testing.T) {
+	s := newContractTestServer(t)
+	now := time.Now()
+
+	unsubscribed := workspaceScope{workspaceID: 2, statusPageUrls: map[string]bool{}}
+	tests := []struct {
+		scope    workspaceScope
+		member   api.ServiceGroupMember
+		expected StatusLevel
+	}{
+		{member: api.ServiceGroupMember{StatusPageUrl: "https://status.example.com", Weight: 1}, expected: StatusLevelMajorOutage},
+		{member: api.ServiceGroupMember{StatusPageUrl: "https://status.example.com", Component: "API", Weight: 1}, expected: StatusLevelMajorOutage},
+		{member: api.ServiceGroupMember{StatusPageUrl: "https://status.example.com", Component: "Dashboard", Weight: 1}, expected: StatusLevelOperational},
+		{member: api.ServiceGroupMember{StatusPageUrl: "https://status.unindexed.com", Weight: 1}, expected: StatusLevelStale},
+		{member: api.ServiceGroupMember{StatusPageUrl: "https://status.deleted.com", Weight: 1}, expected: StatusLevelStale},
+		{scope: unsubscribed, member: api.ServiceGroupMember{StatusPageUrl: "https://status.example.com", Weight: 1}, expected: StatusLevelStale},
+	}
+	for _, test := range tests {
+		memberStatus, err := s.getServiceGroupMemberStatus(context.Background(), test.scope, test.member, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if memberStatus.Level != test.expected {
+			t.Errorf("expected %s for %+v, got %s (%s)", test.expected, test.member, memberStatus.Level, memberStatus.Reason)
+		}
+	}
+}
+
+func floatPointer(f float64) *float64 {
+	return &f
+}
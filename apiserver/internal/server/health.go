@@ -0,0 +1,24 @@
+package server
+
+import (
+	"context"
+
+	"github.com/metoro-io/statusphere/common/health"
+	"github.com/pkg/errors"
+)
+
+// readinessChecks are the checks of the /readyz endpoint
+// The apiserver is ready once it can reach the database and StartCaches has loaded the status pages, which every endpoint looks up
+func (s *Server) readinessChecks() health.Checks {
+	return health.Checks{
+		"database": func(ctx context.Context) error {
+			return s.dbClient.Ping(ctx)
+		},
+		"caches": func(ctx context.Context) error {
+			if !s.statusPageCacheLoaded.Load() {
+				return errors.New("the status pages have not been loaded yet")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// exportFlushInterval is how many incidents are written to the response between flushes
+const exportFlushInterval = 100
+
+// incidentExporter writes incidents to the body of an export in a format
+type incidentExporter interface {
+	// writeHeader is called once before the first incident, even if there is none
+	writeHeader() error
+	write(incident api.Incident) error
+	// flush writes the buffered incidents to the underlying writer
+	flush() error
+}
+
+// incidentExport is a handler for the /incidents/export endpoint.
+// It has a required query parameter of format, csv or jsonl
+// It has a required query parameter of either statusPageUrl, which can be repeated, or group to export the incidents of a service group
+// It has the impact, from, to, ongoing, component and query parameters of the /incidents endpoint, every matching incident is exported
+// The csv format has a row per incident and an optional query parameter of table=events to export a row per event of the incidents instead
+// The jsonl format has a line per incident with its events nested
+// Incidents are exported newest first and streamed from the database as they are read
+func (s *Server) incidentExport(context *gin.Context) {
+	format := context.Query("format")
+	table := context.DefaultQuery("table", "incidents")
+	exporter, filename, contentType, err := newIncidentExporter(format, table, context.Writer)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseIncidentFilter(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groupName := context.Query("group")
+	if groupName != "" && len(context.QueryArray("statusPageUrl")) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl and group cannot be combined"})
+		return
+	}
+	if groupName != "" {
+		group, ok := s.getServiceGroup(context, groupName)
+		if !ok {
+			return
+		}
+		filter.Sources = serviceGroupIncidentSources(getWorkspaceScope(context), group)
+	} else {
+		statusPages, ok := s.getRequestedStatusPages(context)
+		if !ok {
+			return
+		}
+		for _, statusPage := range statusPages {
+			filter.StatusPageUrls = append(filter.StatusPageUrls, statusPage.URL)
+		}
+	}
+
+	// The headers are only written with the first incident so that a failing query can still respond with an error
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		context.Header("Content-Type", contentType)
+		context.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		context.Status(http.StatusOK)
+		return exporter.writeHeader()
+	}
+
+	// A group whose members are all out of sight has nothing to export, an empty filter would export everything
+	if groupName == "" || len(filter.Sources) > 0 {
+		exported := 0
+		err = s.dbClient.StreamIncidents(context.Request.Context(), filter, func(incident api.Incident) error {
+			if err := start(); err != nil {
+				return err
+			}
+			if err := exporter.write(incident); err != nil {
+				return err
+			}
+			exported++
+			if exported%exportFlushInterval != 0 {
+				return nil
+			}
+			if err := exporter.flush(); err != nil {
+				return err
+			}
+			context.Writer.Flush()
+			return nil
+		})
+	}
+	if err == nil {
+		err = start()
+	}
+	if err == nil {
+		err = exporter.flush()
+	}
+	if err != nil {
+		if !started {
+			s.logger.Error("failed to get incidents from database", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+			return
+		}
+		// The response has started, it can only be cut short
+		s.logger.Error("failed to export incidents", zap.Error(err))
+	}
+}
+
+// newIncidentExporter returns the exporter of the format and table writing to w, along with the filename and content type of the export
+func newIncidentExporter(format string, table string, w io.Writer) (incidentExporter, string, string, error) {
+	switch format {
+	case "csv":
+		switch table {
+		case "incidents":
+			return &incidentsCSVExporter{writer: csv.NewWriter(w)}, "incidents.csv", "text/csv; charset=utf-8", nil
+		case "events":
+			return &incidentEventsCSVExporter{writer: csv.NewWriter(w)}, "incident-events.csv", "text/csv; charset=utf-8", nil
+		default:
+			return nil, "", "", errors.New("table must be incidents or events")
+		}
+	case "jsonl":
+		if table != "incidents" {
+			return nil, "", "", errors.New("table can only be set with the csv format, the jsonl format nests the events in the incidents")
+		}
+		buffered := bufio.NewWriter(w)
+		return &incidentsJSONLExporter{buffered: buffered, encoder: json.NewEncoder(buffered)}, "incidents.jsonl", "application/x-ndjson", nil
+	case "":
+		return nil, "", "", errors.New("format is required")
+	default:
+		return nil, "", "", errors.New("format must be csv or jsonl")
+	}
+}
+
+// incidentsCSVExporter writes a row per incident, the components of an incident are separated by semicolons
+type incidentsCSVExporter struct {
+	writer *csv.Writer
+}
+
+func (e *incidentsCSVExporter) writeHeader() error {
+	return e.writer.Write([]string{"id", "statusPageUrl", "title", "impact", "state", "startTime", "endTime", "components", "description", "deepLink", "externalId", "provider", "eventCount"})
+}
+
+func (e *incidentsCSVExporter) write(incident api.Incident) error {
+	endTime := ""
+	if incident.EndTime != nil {
+		endTime = formatExportTime(*incident.EndTime)
+	}
+	description := ""
+	if incident.Description != nil {
+		description = *incident.Description
+	}
+	return e.writer.Write([]string{
+		strconv.FormatInt(incident.ID, 10),
+		incident.StatusPageUrl,
+		incident.Title,
+		string(incident.Impact),
+		string(incident.State),
+		formatExportTime(incident.StartTime),
+		endTime,
+		strings.Join(incident.Components, "; "),
+		description,
+		incident.DeepLink,
+		incident.ExternalID,
+		incident.Provider,
+		strconv.Itoa(len(incident.Events)),
+	})
+}
+
+func (e *incidentsCSVExporter) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// incidentEventsCSVExporter writes a row per event of the incidents, which join the rows of the incidents export on incidentId
+type incidentEventsCSVExporter struct {
+	writer *csv.Writer
+}
+
+func (e *incidentEventsCSVExporter) writeHeader() error {
+	return e.writer.Write([]string{"incidentId", "statusPageUrl", "time", "title", "description"})
+}
+
+func (e *incidentEventsCSVExporter) write(incident api.Incident) error {
+	for _, event := range incident.Events {
+		err := e.writer.Write([]string{
+			strconv.FormatInt(incident.ID, 10),
+			incident.StatusPageUrl,
+			formatExportTime(event.Time),
+			event.Title,
+			event.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *incidentEventsCSVExporter) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// incidentsJSONLExporter writes a line per incident, encoded like the incidents of the /incidents endpoint
+type incidentsJSONLExporter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (e *incidentsJSONLExporter) writeHeader() error {
+	return nil
+}
+
+func (e *incidentsJSONLExporter) write(incident api.Incident) error {
+	// Encode terminates every incident with a newline
+	return e.encoder.Encode(incident)
+}
+
+func (e *incidentsJSONLExporter) flush() error {
+	return e.buffered.Flush()
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func exportTestIncident() api.Incident {
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	description := "Requests are failing, \"again\""
+	return api.Incident{
+		ID:            42,
+		Title:         "Elevated errors",
+		Components:    []string{"API", "Dashboard"},
+		StartTime:     start,
+		EndTime:       &end,
+		Description:   &description,
+		DeepLink:      "https://status.example.com/incidents/1",
+		ExternalID:    "abc123",
+		Impact:        api.ImpactMajor,
+		StatusPageUrl: "https://status.example.com",
+		Provider:      "atlassian",
+		State:         api.IncidentStateResolved,
+		Events: api.IncidentEventArray{
+			api.NewIncidentEvent("Investigating", "We are looking into it", start),
+			api.NewIncidentEvent("Resolved", "Line one\nline two", end),
+		},
+	}
+}
+
+func export(t *testing.T, format string, table string, incidents ...api.Incident) string {
+	var buffer bytes.Buffer
+	exporter, _, _, err := newIncidentExporter(format, table, &buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exporter.writeHeader(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, incident := range incidents {
+		if err := exporter.write(incident); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := exporter.flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buffer.String()
+}
+
+func TestExportIncidentsCSV(t *testing.T) {
+	incident := exportTestIncident()
+	ongoing := api.Incident{ID: 43, Title: "Slow", StartTime: incident.StartTime, Impact: api.ImpactMinor, StatusPageUrl: "https://status.example.com"}
+
+	expected := "id,statusPageUrl,title,impact,state,startTime,endTime,components,description,deepLink,externalId,provider,eventCount\n" +
+		"42,https://status.example.com,Elevated errors,major,resolved,2024-03-13T06:55:00Z,2024-03-13T07:55:00Z,API; Dashboard,\"Requests are failing, \"\"again\"\"\",https://status.example.com/incidents/1,abc123,atlassian,2\n" +
+		"43,https://status.example.com,Slow,minor,,2024-03-13T06:55:00Z,,,,,,,0\n"
+	if got := export(t, "csv", "incidents", incident, ongoing); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestExportIncidentEventsCSV(t *testing.T) {
+	expected := "incidentId,statusPageUrl,time,title,description\n" +
+		"42,https://status.example.com,2024-03-13T06:55:00Z,Investigating,We are looking into it\n" +
+		"42,https://status.example.com,2024-03-13T07:55:00Z,Resolved,\"Line one\nline two\"\n"
+	if got := export(t, "csv", "events", exportTestIncident()); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestExportIncidentsJSONL(t *testing.T) {
+	incident := exportTestIncident()
+	lines := strings.Split(strings.TrimSuffix(export(t, "jsonl", "incidents", incident, incident), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per incident, got %d", len(lines))
+	}
+	var decoded api.Incident
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.ID != incident.ID || len(decoded.Events) != 2 || decoded.Events[1].Description != "Line one\nline two" {
+		t.Errorf("expected the incident with its events nested, got %+v", decoded)
+	}
+}
+
+func TestNewIncidentExporterErrors(t *testing.T) {
+	for _, test := range []struct{ format, table string }{{"", "incidents"}, {"xml", "incidents"}, {"csv", "updates"}, {"jsonl", "events"}} {
+		if _, _, _, err := newIncidentExporter(test.format, test.table, &bytes.Buffer{}); err == nil {
+			t.Errorf("expected an error for format %q and table %q", test.format, test.table)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+)
+
+type IncidentHistoryResponse struct {
+	Incident  api.Incident           `json:"incident"`
+	Revisions []api.IncidentRevision `json:"revisions"`
+}
+
+// incidentHistory is a handler for the /incidents/:id/history endpoint.
+// It returns the incident along with every observed change of it, oldest first.
+// If the incident is not known to statusphere, it returns a 404.
+func (s *Server) incidentHistory(context *gin.Context) {
+	ctx := context.Request.Context()
+	id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+		return
+	}
+
+	incident, err := s.dbClient.GetIncident(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get incident from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incident from database"})
+		return
+	}
+	if incident == nil || !getWorkspaceScope(context).canSee(incident.StatusPageUrl) {
+		context.JSON(http.StatusNotFound, gin.H{"error": "incident not known to statusphere"})
+		return
+	}
+
+	revisions, err := s.dbClient.GetIncidentRevisions(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get incident revisions from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incident revisions from database"})
+		return
+	}
+	if revisions == nil {
+		revisions = []api.IncidentRevision{}
+	}
+
+	context.JSON(http.StatusOK, IncidentHistoryResponse{Incident: *incident, Revisions: revisions})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+)
+
+const defaultIncidentSearchLimit = 25
+const maxIncidentSearchLimit = 100
+
+type IncidentSearchResponse struct {
+	Results []db.IncidentSearchResult `json:"results"`
+}
+
+// incidentSearch is a handler for the /incidents/search endpoint.
+// It has a required query parameter of query, which supports the web search syntax e.g. "us-east-1" or kafka -maintenance
+// It searches the title, description and updates of the incidents of every status page the workspace sees
+// It has an optional query parameter of statusPageUrl, which can be repeated, to only search some status pages
+// It has the impact, from and to query parameters of the /incidents endpoint
+// It has optional query parameters of limit (default is 25, at most 100) and offset
+// Entries returned first are the ones with the best match, each has a snippet with the matches wrapped in <mark> tags
+func (s *Server) incidentSearch(context *gin.Context) {
+	ctx := context.Request.Context()
+	query := context.Query("query")
+	if query == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+
+	filter, err := parseIncidentFilter(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var ok bool
+	filter.StatusPageUrls, ok = getWorkspaceScope(context).restrict(context.QueryArray("statusPageUrl"))
+	if !ok {
+		context.JSON(http.StatusOK, IncidentSearchResponse{Results: []db.IncidentSearchResult{}})
+		return
+	}
+
+	limit := defaultIncidentSearchLimit
+	if limitStr := context.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxIncidentSearchLimit {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be an integer between 1 and %d", maxIncidentSearchLimit)})
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := context.Query("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			context.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a positive integer"})
+			return
+		}
+	}
+
+	results, err := s.dbClient.SearchIncidents(ctx, query, filter, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to search incidents", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search incidents"})
+		return
+	}
+	if results == nil {
+		results = []db.IncidentSearchResult{}
+	}
+
+	context.JSON(http.StatusOK, IncidentSearchResponse{Results: results})
+}
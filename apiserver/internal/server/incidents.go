@@ -2,25 +2,40 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"net/http"
-	"sort"
-	"strconv"
-	"strings"
 )
 
+const defaultIncidentsLimit = 100
+const maxIncidentsLimit = 1000
+
 type IncidentsResponse struct {
 	Incidents []api.Incident `json:"incidents"`
 	IsIndexed bool           `json:"isIndexed"`
+	// NextCursor is set when there are more incidents, pass it as the cursor query parameter to get them
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // incidents is a handler for the /incidents endpoint.
 // It has a required query parameter of statusPageUrl
 // It has an optional query parameter of impact (default is all), which is an array of impacts e.g. impact=critical,major,minor,none to exclude maintenance
+// It has optional query parameters of from and to (dates or RFC3339 timestamps) to only return incidents overlapping that window
+// It has an optional query parameter of ongoing=true to only return incidents that have not ended
+// It has optional query parameters of component and query to only return incidents affecting a component or containing a text
+// It has optional query parameters of limit (default is 100, at most 1000) and cursor (the nextCursor of the previous response)
+// Incidents are returned newest first
 func (s *Server) incidents(context *gin.Context) {
 	ctx := context.Request.Context()
 	statusPageUrl := context.Query("statusPageUrl")
@@ -29,29 +44,20 @@ func (s *Server) incidents(context *gin.Context) {
 		return
 	}
 
-	impactQuery := context.Query("impact")
-	var impacts []api.Impact
-	if impactQuery != "" {
-		impactsStr := strings.Split(impactQuery, ",")
-		for _, impactStr := range impactsStr {
-			impact, err := api.ParseImpact(impactStr)
-			if err != nil {
-				context.JSON(http.StatusBadRequest, gin.H{"error": "invalid impact"})
-				return
-			}
-			impacts = append(impacts, impact)
-		}
+	filter, err := parseIncidentFilter(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	filter.StatusPageUrls = []string{statusPageUrl}
 
-	var limit *int = nil
-	if limitStr := context.Query("limit"); limitStr != "" {
-		limitInt, err := strconv.Atoi(limitStr)
-		if err != nil {
-			context.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
-			return
-		}
-		limit = &limitInt
+	limit, err := parseIncidentsLimit(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	// Fetch one more incident than requested to know if there is a next page
+	filter.Limit = limit + 1
 
 	// Check to see that the status page is known to statusphere and is indexed
 	statusPage, found := s.statusPageCache.Get(statusPageUrl)
@@ -72,96 +78,162 @@ func (s *Server) incidents(context *gin.Context) {
 	}
 
 	// Attempt to get the incidents from the cache
-	incidents, found, err := s.getIncidentsFromCache(ctx, statusPageUrl, impacts)
+	cacheKey := incidentCacheKey(statusPageUrl, context.Request.URL.Query())
+	incidents, found, err := s.getIncidentsFromCache(ctx, cacheKey)
 	if err != nil {
 		s.logger.Error("failed to get incidents from cache", zap.Error(err))
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from cache"})
 		return
 	}
-	if found {
-		sortIncidentsDescending(incidents)
-		if limit != nil && len(incidents) > *limit {
-			incidents = incidents[:*limit]
+	if !found {
+		// Attempt to get the incidents from the database
+		incidents, err = s.dbClient.ListIncidents(ctx, filter)
+		if err != nil {
+			s.logger.Error("failed to get incidents from database", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+			return
 		}
-		context.JSON(http.StatusOK, IncidentsResponse{Incidents: incidents, IsIndexed: true})
-		return
+		s.incidentCache.Set(cacheKey, incidents, cache.DefaultExpiration)
 	}
 
-	// Attempt to get the incidents from the database
-	incidents, found, err = s.getIncidentsFromDatabase(ctx, statusPageUrl)
-	if err != nil {
-		s.logger.Error("failed to get incidents from database", zap.Error(err))
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
-		return
+	response := IncidentsResponse{Incidents: incidents, IsIndexed: true}
+	if len(incidents) > limit {
+		response.Incidents = incidents[:limit]
+		last := response.Incidents[limit-1]
+		response.NextCursor = encodeIncidentCursor(db.IncidentCursor{StartTime: last.StartTime, ID: last.ID})
 	}
-	if !found {
-		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
-		return
+	if response.Incidents == nil {
+		response.Incidents = []api.Incident{}
 	}
-
-	sortIncidentsDescending(incidents)
-	s.incidentCache.Set(statusPageUrl, incidents, cache.DefaultExpiration)
-	if limit != nil && len(incidents) > *limit {
-		incidents = incidents[:*limit]
-	}
-	context.JSON(http.StatusOK, IncidentsResponse{Incidents: incidents, IsIndexed: true})
+	context.JSON(http.StatusOK, response)
 }
 
-func sortIncidentsDescending(incidents []api.Incident) {
-	sort.Slice(incidents, func(i, j int) bool {
-		return incidents[i].StartTime.After(incidents[j].StartTime)
-	})
-}
+// parseIncidentFilter parses the query parameters shared by the endpoints that list incidents
+// It does not set the status pages or the limit
+func parseIncidentFilter(context *gin.Context) (db.IncidentFilter, error) {
+	var filter db.IncidentFilter
 
-// getIncidentsFromCache attempts to get the incidents from the cache.
-// If the incidents are found in the cache, it returns them.
-// If the incidents are not found in the cache, it returns false for the second return value.
-func (s *Server) getIncidentsFromCache(ctx context.Context, statusPageUrl string, impacts []api.Impact) ([]api.Incident, bool, error) {
-	incidents, found := s.incidentCache.Get(statusPageUrl)
-	if !found {
-		return nil, false, nil
+	impacts, err := parseImpactQuery(context)
+	if err != nil {
+		return filter, err
 	}
+	filter.Impacts = impacts
 
-	incidentsCasted, ok := incidents.([]api.Incident)
-	if !ok {
-		return nil, false, errors.New("failed to cast incidents to []api.Incident")
+	if fromStr := context.Query("from"); fromStr != "" {
+		from, err := parseTimeQuery(fromStr)
+		if err != nil {
+			return filter, errors.New("from must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		filter.From = &from
+	}
+	if toStr := context.Query("to"); toStr != "" {
+		to, err := parseTimeQuery(toStr)
+		if err != nil {
+			return filter, errors.New("to must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
 	}
 
-	if len(impacts) > 0 {
-		var filteredIncidents []api.Incident
-		for _, incident := range incidentsCasted {
-			for _, impact := range impacts {
-				if incident.Impact == impact {
-					filteredIncidents = append(filteredIncidents, incident)
-				}
-			}
+	if ongoingStr := context.Query("ongoing"); ongoingStr != "" {
+		ongoing, err := strconv.ParseBool(ongoingStr)
+		if err != nil {
+			return filter, errors.New("ongoing must be a boolean")
 		}
-		incidentsCasted = filteredIncidents
+		filter.Ongoing = ongoing
 	}
 
-	return incidentsCasted, true, nil
+	filter.Component = context.Query("component")
+	filter.Query = context.Query("query")
+
+	if cursorStr := context.Query("cursor"); cursorStr != "" {
+		cursor, err := decodeIncidentCursor(cursorStr)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Cursor = &cursor
+	}
+	return filter, nil
 }
 
-// getIncidentsFromDatabase attempts to get the incidents from the database.
-// If the incidents are found in the database, it returns them.
-// If the incidents are not found in the database, it returns false for the second return value.
-func (s *Server) getIncidentsFromDatabase(ctx context.Context, statusPageUrl string) ([]api.Incident, bool, error) {
-	incidents, err := s.dbClient.GetIncidents(ctx, statusPageUrl)
+// parseIncidentsLimit parses the limit query parameter of the endpoints that page through incidents
+func parseIncidentsLimit(context *gin.Context) (int, error) {
+	limitStr := context.Query("limit")
+	if limitStr == "" {
+		return defaultIncidentsLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		return nil, false, err
+		return 0, errors.New("limit must be an integer")
 	}
+	if limit <= 0 || limit > maxIncidentsLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxIncidentsLimit)
+	}
+	return limit, nil
+}
 
-	if len(incidents) == 0 {
-		// See if the status page exists
-		statusPage, err := s.dbClient.GetStatusPage(ctx, statusPageUrl)
+// parseImpactQuery parses the comma separated impact query parameter, it returns nil if it is not set
+func parseImpactQuery(context *gin.Context) ([]api.Impact, error) {
+	impactQuery := context.Query("impact")
+	if impactQuery == "" {
+		return nil, nil
+	}
+	var impacts []api.Impact
+	for _, impactStr := range strings.Split(impactQuery, ",") {
+		impact, err := api.ParseImpact(impactStr)
 		if err != nil {
-			return nil, false, err
-		}
-		if statusPage == nil {
-			// The status page does not exist
-			return nil, false, nil
+			return nil, errors.New("invalid impact")
 		}
+		impacts = append(impacts, impact)
+	}
+	return impacts, nil
+}
+
+// incidentCacheKey returns the incident cache key of a request, the keys of a status page all start with its url
+func incidentCacheKey(statusPageUrl string, query url.Values) string {
+	// Encode sorts the parameters by key so equivalent requests share a key
+	return statusPageUrl + "|" + query.Encode()
+}
+
+func encodeIncidentCursor(cursor db.IncidentCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", cursor.StartTime.UnixNano(), cursor.ID)))
+}
+
+func decodeIncidentCursor(encoded string) (db.IncidentCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return db.IncidentCursor{}, err
+	}
+	startTimeStr, idStr, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return db.IncidentCursor{}, errors.New("invalid cursor")
+	}
+	startTime, err := strconv.ParseInt(startTimeStr, 10, 64)
+	if err != nil {
+		return db.IncidentCursor{}, err
 	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return db.IncidentCursor{}, err
+	}
+	return db.IncidentCursor{StartTime: time.Unix(0, startTime), ID: id}, nil
+}
 
-	return incidents, true, nil
+// getIncidentsFromCache attempts to get the incidents of a request from the cache.
+// If the incidents are found in the cache, it returns them.
+// If the incidents are not found in the cache, it returns false for the second return value.
+func (s *Server) getIncidentsFromCache(ctx context.Context, cacheKey string) ([]api.Incident, bool, error) {
+	incidents, found := s.incidentCache.Get(cacheKey)
+	if !found {
+		return nil, false, nil
+	}
+
+	incidentsCasted, ok := incidents.([]api.Incident)
+	if !ok {
+		return nil, false, errors.New("failed to cast incidents to []api.Incident")
+	}
+
+	return incidentsCasted, true, nil
 }
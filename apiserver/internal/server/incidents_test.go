@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+)
+
+func TestIncidentCursorRoundTrip(t *testing.T) {
+	cursor := db.IncidentCursor{StartTime: time.Date(2024, 3, 13, 6, 55, 1, 123456000, time.UTC), ID: 42}
+	decoded, err := decodeIncidentCursor(encodeIncidentCursor(cursor))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.StartTime.Equal(cursor.StartTime) || decoded.ID != cursor.ID {
+		t.Errorf("expected %+v, got %+v", cursor, decoded)
+	}
+
+	if _, err := decodeIncidentCursor("not a cursor"); err == nil {
+		t.Error("expected an error for an invalid cursor")
+	}
+}
+
+func newTestContext(target string) *gin.Context {
+	context, _ := gin.CreateTestContext(httptest.NewRecorder())
+	context.Request = httptest.NewRequest("GET", target, nil)
+	return context
+}
+
+func TestParseIncidentFilter(t *testing.T) {
+	filter, err := parseIncidentFilter(newTestContext("/api/v1/incidents?impact=critical,major&from=2024-03-01&to=2024-04-01T00:00:00Z&ongoing=true&component=API&query=us-east-1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filter.Impacts) != 2 || filter.Impacts[0] != api.ImpactCritical || filter.Impacts[1] != api.ImpactMajor {
+		t.Errorf("unexpected impacts: %v", filter.Impacts)
+	}
+	if filter.From == nil || !filter.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected from: %v", filter.From)
+	}
+	if filter.To == nil || !filter.To.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected to: %v", filter.To)
+	}
+	if !filter.Ongoing || filter.Component != "API" || filter.Query != "us-east-1" {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+
+	for _, target := range []string{
+		"/api/v1/incidents?impact=unknown",
+		"/api/v1/incidents?from=yesterday",
+		"/api/v1/incidents?from=2024-04-01&to=2024-03-01",
+		"/api/v1/incidents?ongoing=maybe",
+		"/api/v1/incidents?cursor=invalid",
+	} {
+		if _, err := parseIncidentFilter(newTestContext(target)); err == nil {
+			t.Errorf("expected an error for %s", target)
+		}
+	}
+}
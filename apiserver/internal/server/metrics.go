@@ -0,0 +1,266 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxMetricsComponentsPerPage bounds the number of components exported per status page, the most recently affected are kept
+const maxMetricsComponentsPerPage = 25
+
+// metricsComponentWindow is how long a component keeps being exported after its last incident
+const metricsComponentWindow = 30 * 24 * time.Hour
+
+// maxMetricLabelLength bounds the length of the sanitized names used as label values
+const maxMetricLabelLength = 64
+
+// metricImpacts are the impacts the ongoing incidents are counted by, each is always exported so alerts can compare them to 0
+var metricImpacts = []api.Impact{api.ImpactCritical, api.ImpactMajor, api.ImpactMinor, api.ImpactMaintenance, api.ImpactNone}
+
+// statusLevelMetricValues are the values of the status level gauges, STALE is below OPERATIONAL as it says nothing about the incidents
+var statusLevelMetricValues = map[StatusLevel]float64{
+	StatusLevelStale:          -1,
+	StatusLevelOperational:    0,
+	StatusLevelMaintenance:    1,
+	StatusLevelMinorOutage:    2,
+	StatusLevelMajorOutage:    3,
+	StatusLevelCriticalOutage: 4,
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	// labels are pairs of names and values, in the order they are written
+	labels []string
+	value  float64
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// metrics is a handler for the /metrics endpoint.
+// It exports the status of the status pages as Prometheus gauges in the text exposition format
+// It has an optional query parameter of statusPageUrl, which can be repeated, to only export some status pages (default is all)
+// It has an optional query parameter of components=true to also export the gauges of the components that had an incident in the last 30 days,
+// at most 25 per status page
+// The status pages are labelled with their url and their name sanitized to lower snake case
+func (s *Server) metrics(context *gin.Context) {
+	ctx := context.Request.Context()
+	var statusPages []api.StatusPage
+	// The database queries are limited to the requested status pages, if any
+	var statusPageUrls []string
+	if len(context.QueryArray("statusPageUrl")) > 0 {
+		var ok bool
+		statusPages, ok = s.getRequestedStatusPages(context)
+		if !ok {
+			return
+		}
+		for _, statusPage := range statusPages {
+			statusPageUrls = append(statusPageUrls, statusPage.URL)
+		}
+	} else {
+		scope := getWorkspaceScope(context)
+		statusPages = s.getVisibleStatusPages(scope)
+		statusPageUrls, _ = scope.restrict(nil)
+	}
+	sort.Slice(statusPages, func(i, j int) bool {
+		return statusPages[i].URL < statusPages[j].URL
+	})
+	withComponents := context.Query("components") == "true"
+
+	lastIncidents, err := s.dbClient.GetLastIncidentStartTimes(ctx, statusPageUrls)
+	if err != nil {
+		s.logger.Error("failed to get last incidents from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get last incidents from database"})
+		return
+	}
+	now := time.Now()
+	var lastComponentIncidents map[string]map[string]time.Time
+	if withComponents {
+		lastComponentIncidents, err = s.dbClient.GetLastComponentIncidentStartTimes(ctx, statusPageUrls, now.Add(-metricsComponentWindow))
+		if err != nil {
+			s.logger.Error("failed to get last component incidents from database", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get last component incidents from database"})
+			return
+		}
+	}
+
+	statusLevel := &metricFamily{name: "statusphere_status_level", help: "Status level of the status page: -1 stale, 0 operational, 1 maintenance, 2 minor outage, 3 major outage, 4 critical outage."}
+	ongoingIncidents := &metricFamily{name: "statusphere_ongoing_incidents", help: "Number of ongoing incidents of the status page by impact."}
+	lastScrapeAge := &metricFamily{name: "statusphere_last_successful_scrape_age_seconds", help: "Seconds since the status page was last scraped successfully, missing if it never was."}
+	lastIncidentAge := &metricFamily{name: "statusphere_last_incident_age_seconds", help: "Seconds since the last incident of the status page started, maintenance excluded, missing if it never had one."}
+	componentStatusLevel := &metricFamily{name: "statusphere_component_status_level", help: "Status level of the component, with the values of statusphere_status_level."}
+	componentOngoingIncidents := &metricFamily{name: "statusphere_component_ongoing_incidents", help: "Number of ongoing incidents affecting the component by impact."}
+	componentLastIncidentAge := &metricFamily{name: "statusphere_component_last_incident_age_seconds", help: "Seconds since the last incident affecting the component started, maintenance excluded."}
+
+	for _, statusPage := range statusPages {
+		var incidents []api.Incident
+		if statusPage.IsIndexed {
+			var found bool
+			incidents, found, err = s.getCurrentIncidents(ctx, statusPage.URL)
+			if err != nil {
+				s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("url", statusPage.URL))
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get current incidents"})
+				return
+			}
+			if !found {
+				// The status page was deleted since the status page cache was refreshed
+				continue
+			}
+		}
+
+		pageLabels := []string{"status_page", sanitizeMetricLabel(statusPage.Name), "url", statusPage.URL}
+		level, _ := computeStatusLevel(statusPage, incidents, now)
+		statusLevel.add(statusLevelMetricValues[level], pageLabels...)
+		addOngoingIncidents(ongoingIncidents, incidents, pageLabels)
+		if statusPage.LastSuccessfullyScraped != nil {
+			lastScrapeAge.add(now.Sub(*statusPage.LastSuccessfullyScraped).Seconds(), pageLabels...)
+		}
+		if lastIncident, ok := lastIncidents[statusPage.URL]; ok {
+			lastIncidentAge.add(now.Sub(lastIncident).Seconds(), pageLabels...)
+		}
+
+		if !withComponents {
+			continue
+		}
+		lastComponentIncident := lastComponentIncidents[statusPage.URL]
+		// Components whose names only differ by case or punctuation would be the same series, the first one is kept
+		exportedComponents := make(map[string]bool)
+		for _, component := range metricsComponents(incidents, lastComponentIncident, now) {
+			componentLabel := sanitizeMetricLabel(component)
+			if exportedComponents[componentLabel] {
+				continue
+			}
+			exportedComponents[componentLabel] = true
+			componentLabels := append(append([]string{}, pageLabels...), "component", componentLabel)
+			affecting := componentIncidents(incidents, component)
+			level, _ := computeStatusLevel(statusPage, affecting, now)
+			componentStatusLevel.add(statusLevelMetricValues[level], componentLabels...)
+			addOngoingIncidents(componentOngoingIncidents, affecting, componentLabels)
+			if lastIncident, ok := lastComponentIncident[component]; ok {
+				componentLastIncidentAge.add(now.Sub(lastIncident).Seconds(), componentLabels...)
+			}
+		}
+	}
+
+	families := []*metricFamily{statusLevel, ongoingIncidents, lastScrapeAge, lastIncidentAge}
+	if withComponents {
+		families = append(families, componentStatusLevel, componentOngoingIncidents, componentLastIncidentAge)
+	}
+	context.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	context.Status(http.StatusOK)
+	if err := writeMetricFamilies(context.Writer, families); err != nil {
+		s.logger.Warn("failed to write metrics", zap.Error(err))
+	}
+}
+
+func addOngoingIncidents(family *metricFamily, incidents []api.Incident, labels []string) {
+	counts := make(map[api.Impact]int)
+	for _, incident := range incidents {
+		counts[incident.Impact]++
+	}
+	for _, impact := range metricImpacts {
+		family.add(float64(counts[impact]), append(append([]string{}, labels...), "impact", string(impact))...)
+	}
+}
+
+// metricsComponents returns the components to export: those of the ongoing incidents and those with a recent incident,
+// the most recently affected first, at most maxMetricsComponentsPerPage of them
+func metricsComponents(ongoing []api.Incident, lastIncidents map[string]time.Time, now time.Time) []string {
+	lastAffected := make(map[string]time.Time)
+	for component, startTime := range lastIncidents {
+		lastAffected[component] = startTime
+	}
+	for _, incident := range ongoing {
+		for _, component := range incident.Components {
+			// Ongoing incidents are the most recent, including the maintenance that is not in lastIncidents
+			lastAffected[component] = now
+		}
+	}
+
+	components := make([]string, 0, len(lastAffected))
+	for component := range lastAffected {
+		components = append(components, component)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if !lastAffected[components[i]].Equal(lastAffected[components[j]]) {
+			return lastAffected[components[i]].After(lastAffected[components[j]])
+		}
+		return components[i] < components[j]
+	})
+	if len(components) > maxMetricsComponentsPerPage {
+		components = components[:maxMetricsComponentsPerPage]
+	}
+	sort.Strings(components)
+	return components
+}
+
+// sanitizeMetricLabel turns a name into ASCII lower snake case, e.g. "Amazon Web Services (AWS)" into "amazon_web_services_aws"
+func sanitizeMetricLabel(name string) string {
+	var b strings.Builder
+	separate := false
+	// The accents are dropped rather than treated as separators, e.g. "Čeká" becomes "ceka"
+	for _, character := range norm.NFD.String(strings.ToLower(name)) {
+		if unicode.Is(unicode.Mn, character) {
+			continue
+		}
+		if (character >= 'a' && character <= 'z') || (character >= '0' && character <= '9') {
+			if separate && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			separate = false
+			b.WriteRune(character)
+			continue
+		}
+		separate = true
+	}
+	sanitized := b.String()
+	if len(sanitized) > maxMetricLabelLength {
+		sanitized = strings.TrimRight(sanitized[:maxMetricLabelLength], "_")
+	}
+	if sanitized == "" {
+		return "unnamed"
+	}
+	return sanitized
+}
+
+// writeMetricFamilies writes the families in the Prometheus text exposition format
+func writeMetricFamilies(w io.Writer, families []*metricFamily) error {
+	labelEscaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var b strings.Builder
+	for _, family := range families {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name)
+		for _, sample := range family.samples {
+			b.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i+1 < len(sample.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, `%s="%s"`, sample.labels[i], labelEscaper.Replace(sample.labels[i+1]))
+				}
+				b.WriteByte('}')
+			}
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
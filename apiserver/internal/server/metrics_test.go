@@ -0,0 +1,88 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestSanitizeMetricLabel(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Amazon Web Services (AWS)": "amazon_web_services_aws",
+		"  GitHub ":                 "github",
+		"Čeká na opravu":            "ceka_na_opravu",
+		"!!!":                       "unnamed",
+		strings.Repeat("a_", 40):    strings.TrimRight(strings.Repeat("a_", 32), "_"),
+	} {
+		if got := sanitizeMetricLabel(name); got != expected {
+			t.Errorf("expected %q to be sanitized to %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestWriteMetricFamilies(t *testing.T) {
+	family := &metricFamily{name: "statusphere_status_level", help: "Status level."}
+	family.add(3, "status_page", "github", "url", `https://example.com/"quoted"`)
+	family.add(0.5)
+
+	var b strings.Builder
+	if err := writeMetricFamilies(&b, []*metricFamily{family}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# HELP statusphere_status_level Status level.\n" +
+		"# TYPE statusphere_status_level gauge\n" +
+		`statusphere_status_level{status_page="github",url="https://example.com/\"quoted\""} 3` + "\n" +
+		"statusphere_status_level 0.5\n"
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestAddOngoingIncidentsExportsEveryImpact(t *testing.T) {
+	family := &metricFamily{}
+	addOngoingIncidents(family, []api.Incident{{Impact: api.ImpactMajor}, {Impact: api.ImpactMajor}}, []string{"url", "x"})
+	if len(family.samples) != len(metricImpacts) {
+		t.Fatalf("expected a sample per impact, got %d", len(family.samples))
+	}
+	for _, sample := range family.samples {
+		expected := 0.0
+		if sample.labels[3] == string(api.ImpactMajor) {
+			expected = 2
+		}
+		if sample.value != expected {
+			t.Errorf("unexpected sample %+v", sample)
+		}
+	}
+}
+
+func TestMetricsComponentsAreBounded(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	lastIncidents := make(map[string]time.Time)
+	for i := 0; i < maxMetricsComponentsPerPage+10; i++ {
+		lastIncidents[string(rune('A'+i))] = now.Add(-time.Duration(i) * time.Hour)
+	}
+	ongoing := []api.Incident{{Components: []string{"Ongoing"}}}
+
+	components := metricsComponents(ongoing, lastIncidents, now)
+	if len(components) != maxMetricsComponentsPerPage {
+		t.Fatalf("expected %d components, got %d", maxMetricsComponentsPerPage, len(components))
+	}
+	found := false
+	for _, component := range components {
+		if component == "Ongoing" {
+			found = true
+		}
+		if component != "Ongoing" && lastIncidents[component].Before(now.Add(-time.Duration(maxMetricsComponentsPerPage-1)*time.Hour)) {
+			t.Errorf("expected only the most recently affected components, got %s", component)
+		}
+	}
+	if !found {
+		t.Error("expected the components of the ongoing incidents to be exported")
+	}
+	if !reflect.DeepEqual(metricsComponents(nil, nil, now), []string{}) {
+		t.Error("expected no components without incidents")
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpecJSON is the OpenAPI 3 specification of the /api/v1 routes, contract_test.go checks it against the handlers
+//
+//go:embed openapi.json
+var openAPISpecJSON []byte
+
+// openAPISpec is a handler for the /openapi.json endpoint.
+func (s *Server) openAPISpec(context *gin.Context) {
+	context.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpecJSON)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiterIdleTimeout is how long a bucket is kept after its last request, an idle bucket is full again by then
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+// rateLimiter is a token bucket per client, each bucket holds up to a minute of requests and refills continuously
+// The buckets live in the memory of each apiserver, so every replica allows the full rate
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow takes a token from the bucket of the client if there is one
+// It returns the tokens left, and if the request is not allowed, how long until the next token
+func (l *rateLimiter) allow(client string, perMinute int, now time.Time) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, wait := l.refill(client, perMinute, now)
+	if wait > 0 {
+		return false, 0, wait
+	}
+	bucket.tokens--
+	return true, int(bucket.tokens), 0
+}
+
+// wait returns how long until the bucket of the client has a token, 0 if it has one, without taking it
+func (l *rateLimiter) wait(client string, perMinute int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, wait := l.refill(client, perMinute, now)
+	return wait
+}
+
+// refill returns the bucket of the client with the tokens refilled since it was last seen, and how long until it has
+// a token
+func (l *rateLimiter) refill(client string, perMinute int, now time.Time) (*tokenBucket, time.Duration) {
+	l.prune(now)
+
+	capacity := float64(perMinute)
+	refillPerSecond := capacity / 60
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, lastSeen: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*refillPerSecond)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		return bucket, time.Duration((1 - bucket.tokens) / refillPerSecond * float64(time.Second))
+	}
+	return bucket, 0
+}
+
+// prune drops the buckets of the clients that went idle, at most once per idle timeout
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < rateLimiterIdleTimeout {
+		return
+	}
+	l.lastPrune = now
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > rateLimiterIdleTimeout {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -1,65 +1,182 @@
 package server
 
 import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
+	"github.com/metoro-io/statusphere/common/api"
 	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/health"
+	"github.com/metoro-io/statusphere/common/publicnet"
 	"github.com/metoro-io/statusphere/common/utils"
+	"github.com/metoro-io/statusphere/scraper/providerset"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
+const apiV1Prefix = "/api/v1"
+
 type Server struct {
 	logger               *zap.Logger
+	config               config.Config
 	dbClient             *db.DbClient
 	statusPageCache      *cache.Cache
 	incidentCache        *cache.Cache
 	currentIncidentCache *cache.Cache
+	streamBroker         *streamBroker
+	scraper              statusPageScraper
+	apiKeyCache          *cache.Cache
+	rateLimiter          *rateLimiter
+	// workspaceStatusPageCache has the urls of the status pages each workspace subscribed to, by workspace id
+	workspaceStatusPageCache *cache.Cache
+	// leaderboardCache has the unranked entries of the leaderboards, by workspace and period, see statsLeaderboard
+	leaderboardCache *cache.Cache
+	// statusPageCacheLoaded is set once the status page cache has been loaded from the database, the apiserver is not ready before
+	statusPageCacheLoaded atomic.Bool
+	httpServer            *http.Server
+	// shutdown is closed when the apiserver starts shutting down, to end the streams that would otherwise never finish
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
 }
 
-func NewServer(logger *zap.Logger, dbClient *db.DbClient) *Server {
-	return &Server{
-		logger:               logger,
-		dbClient:             dbClient,
-		statusPageCache:      cache.New(15*time.Minute, 15*time.Minute),
-		incidentCache:        cache.New(1*time.Minute, 1*time.Minute),
-		currentIncidentCache: cache.New(1*time.Minute, 1*time.Minute),
+func NewServer(logger *zap.Logger, config config.Config, dbClient *db.DbClient) *Server {
+	s := &Server{
+		logger:   logger,
+		config:   config,
+		dbClient: dbClient,
+		// The caches are invalidated on changes, see StartCaches, the expirations only bound how long a missed change is served
+		statusPageCache:          cache.New(cache.NoExpiration, 0),
+		incidentCache:            cache.New(10*time.Minute, 10*time.Minute),
+		currentIncidentCache:     cache.New(10*time.Minute, 10*time.Minute),
+		streamBroker:             newStreamBroker(),
+		scraper:                  providerset.NewRequestScraper(logger, publicnet.NewHttpClient(config.TestScrapeAllowPrivateTargets)),
+		apiKeyCache:              cache.New(1*time.Minute, 1*time.Minute),
+		rateLimiter:              newRateLimiter(),
+		workspaceStatusPageCache: cache.New(1*time.Minute, 1*time.Minute),
+		leaderboardCache:         cache.New(1*time.Minute, 1*time.Minute),
+		shutdown:                 make(chan struct{}),
 	}
+	// The server is created here rather than in Serve so that Shutdown can be called concurrently with it
+	s.httpServer = &http.Server{Addr: config.ListenAddress, ReadHeaderTimeout: 10 * time.Second}
+	return s
 }
 
+// Serve serves the api on the listen address, it returns http.ErrServerClosed once Shutdown is called
 func (s *Server) Serve() error {
+	s.httpServer.Handler = s.router().Handler()
+	return errors.Wrap(s.httpServer.ListenAndServe(), "Failed to start server")
+}
+
+// Shutdown stops accepting connections and waits for the requests in flight to finish until ctx is done
+// The streams are ended first, they would otherwise keep the apiserver from shutting down until the timeout
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+	return s.httpServer.Shutdown(ctx)
+}
+
+// router returns the handler of every route of the apiserver
+func (s *Server) router() *gin.Engine {
 	r := gin.New()
 	r.UseH2C = true
+	// The anonymous rate limit is per client ip, which clients could choose with X-Forwarded-For if any proxy was trusted
+	if err := r.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		panic(errors.Wrap(err, "invalid trusted proxies"))
+	}
 	r.Use(gin.Recovery())
 
-	corsHandler := handleCors()
+	// The probes are registered before the other middlewares so that the frequent requests of orchestrators are not logged
+	r.GET("/healthz", gin.WrapF(health.LivenessHandler()))
+	r.GET("/readyz", gin.WrapF(health.ReadinessHandler(s.readinessChecks())))
+
+	corsHandler := handleCors(s.config.CorsAllowedOrigins)
 	r.Use(corsHandler)
-	r.Use(gzip.Gzip(gzip.BestSpeed))
+	// Compressed server-sent events would be buffered instead of reaching the client
+	r.Use(gzip.Gzip(gzip.BestSpeed, gzip.WithExcludedPaths([]string{apiV1Prefix + "/stream"})))
 
 	r.Use(ginZap(s.logger))
 
-	apiV1 := r.Group("/api/v1")
+	// The specification is served without authentication, so clients can be generated from it
+	r.GET(apiV1Prefix+"/openapi.json", addNoIndexHeader(), s.openAPISpec)
+
+	apiV1 := r.Group(apiV1Prefix)
 	{
 		apiV1.Use(addNoIndexHeader())
-		apiV1.GET("/incidents", s.incidents)
-		apiV1.GET("/currentStatus", s.currentStatus)
-		apiV1.GET("/statusPage", s.statusPage)
-		apiV1.GET("/statusPages", s.statusPages)
-		apiV1.GET("/statusPages/search", s.statusPageSearch)
-		apiV1.GET("/statusPages/count", s.statusPageCount)
-		apiV1.GET("/sitemap.xml", s.siteMap)
+		apiV1.Use(s.authenticate())
+		apiV1.Use(s.scopeToWorkspace())
+
+		read := apiV1.Group("", requireScope(api.ApiKeyScopeRead))
+		// The batch endpoint reports the status pages the workspace does not see as unknown instead of failing
+		read.GET("/currentStatus/batch", s.currentStatusBatch)
+		read.POST("/currentStatus/batch", s.currentStatusBatch)
+
+		public := read.Group("", requireVisibleStatusPages())
+		public.GET("/incidents", s.incidents)
+		public.GET("/incidents/search", s.incidentSearch)
+		public.GET("/incidents/export", s.incidentExport)
+		public.GET("/incidents/:id/history", s.incidentHistory)
+		public.GET("/currentStatus", s.currentStatus)
+		public.GET("/statusPage", s.statusPage)
+		public.GET("/statusPages", s.statusPages)
+		public.GET("/statusPages/search", s.statusPageSearch)
+		public.GET("/statusPages/count", s.statusPageCount)
+		public.GET("/uptime", s.uptime)
+		public.GET("/stats", s.stats)
+		public.GET("/stats/leaderboard", s.statsLeaderboard)
+		public.GET("/badge.svg", s.statusBadge)
+		public.GET("/badge/uptime.svg", s.uptimeBadge)
+		public.GET("/feed.atom", s.feed)
+		public.GET("/maintenance.ics", s.maintenanceCalendar)
+		public.GET("/metrics", s.metrics)
+		public.GET("/groups", s.serviceGroups)
+		public.GET("/groups/:name", s.serviceGroup)
+		public.GET("/groups/:name/status", s.serviceGroupStatus)
+		public.GET("/groups/:name/incidents", s.serviceGroupIncidents)
+		public.GET("/sitemap.xml", s.siteMap)
+		public.GET("/stream", s.stream)
+		public.GET("/graphql", s.graphqlQuery)
+		public.POST("/graphql", s.graphqlQuery)
+
+		admin := apiV1.Group("/admin", requireScope(api.ApiKeyScopeAdmin))
+		admin.GET("/apiKeys", s.adminListApiKeys)
+		admin.POST("/apiKeys", s.adminCreateApiKey)
+		admin.DELETE("/apiKeys/:id", s.adminRevokeApiKey)
+		admin.POST("/groups", s.adminCreateServiceGroup)
+		admin.PUT("/groups/:name", s.adminUpdateServiceGroup)
+		admin.DELETE("/groups/:name", s.adminDeleteServiceGroup)
+		admin.GET("/subscriptions", s.adminListSubscriptions)
+		admin.POST("/subscriptions", s.adminAddSubscriptions)
+		admin.DELETE("/subscriptions", s.adminRemoveSubscription)
+		admin.GET("/notificationChannels", s.adminListNotificationChannels)
+		admin.POST("/notificationChannels", s.adminCreateNotificationChannel)
+		admin.DELETE("/notificationChannels/:id", s.adminDeleteNotificationChannel)
+
+		// The status pages and the workspaces are shared by every workspace, only the default workspace manages them
+		shared := admin.Group("", requireDefaultWorkspace())
+		shared.GET("/statusPages", s.adminListStatusPages)
+		shared.POST("/statusPages", s.adminCreateStatusPage)
+		shared.PUT("/statusPages", s.adminUpdateStatusPage)
+		shared.DELETE("/statusPages", s.adminDeleteStatusPage)
+		shared.POST("/statusPages/testScrape", s.adminTestScrape)
+		shared.GET("/workspaces", s.adminListWorkspaces)
+		shared.POST("/workspaces", s.adminCreateWorkspace)
+		shared.DELETE("/workspaces/:name", s.adminDeleteWorkspace)
 	}
-	return errors.Wrap(r.Run(":8888"), "Failed to start server")
+	return r
 }
 
-func handleCors() gin.HandlerFunc {
+func handleCors(allowedOrigins []string) gin.HandlerFunc {
 	corsConfig := cors.DefaultConfig()
-	// Development cors
-	corsConfig.AllowOrigins = []string{"http://localhost:3000", "https://direct.pykaso.net", "http://127.0.0.1:8888"}
+	corsConfig.AllowOrigins = allowedOrigins
+	corsConfig.AddAllowHeaders("Authorization", "X-API-Key")
+	corsConfig.AddExposeHeaders("Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining")
 	handlerFunc := cors.New(corsConfig)
 	return handlerFunc
 }
@@ -3,7 +3,6 @@ package server
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/ikeikeikeike/go-sitemap-generator/v2/stm"
-	"github.com/metoro-io/statusphere/common/api"
 	"net/http"
 	"net/url"
 )
@@ -11,19 +10,13 @@ import (
 func (s *Server) siteMap(context *gin.Context) {
 	sm := stm.NewSitemap(1)
 	sm.Create()
-	pages := s.statusPageCache.Items()
+	pages := s.getVisibleStatusPages(getWorkspaceScope(context))
 	if len(pages) == 0 {
 		s.logger.Warn("no status pages found")
 		context.JSON(http.StatusInternalServerError, "no status pages found")
 		return
 	}
-	for _, statusPage := range pages {
-		page, ok := statusPage.Object.(api.StatusPage)
-		if !ok {
-			s.logger.Error("failed to cast status page")
-			context.JSON(http.StatusInternalServerError, "failed to cast status page")
-			return
-		}
+	for _, page := range pages {
 		escapeString := url.QueryEscape(page.Name)
 		sm.Add(stm.URL{{"loc", "https://metoro.io/statusphere/status/" + escapeString}, {"changefreq", "always"}, {"mobile", true}, {"priority", 0.1}})
 	}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/stats"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultStatsDays = 90
+const maxStatsDays = 3 * 366
+
+// maxLeaderboardDays bounds the period of the leaderboard, which reads the incidents of every status page
+const maxLeaderboardDays = 366
+
+const defaultLeaderboardLimit = 25
+const maxLeaderboardLimit = 100
+
+type LeaderboardSort string
+
+const (
+	LeaderboardSortDowntime  LeaderboardSort = "downtime"
+	LeaderboardSortIncidents LeaderboardSort = "incidents"
+	LeaderboardSortMttr      LeaderboardSort = "mttr"
+)
+
+type StatsResponse struct {
+	StatusPageUrl string       `json:"statusPageUrl"`
+	From          time.Time    `json:"from"`
+	To            time.Time    `json:"to"`
+	Stats         stats.Report `json:"stats"`
+}
+
+type LeaderboardEntry struct {
+	Rank          int           `json:"rank"`
+	StatusPageUrl string        `json:"statusPageUrl"`
+	Name          string        `json:"name"`
+	Stats         stats.Summary `json:"stats"`
+}
+
+type LeaderboardResponse struct {
+	From    time.Time          `json:"from"`
+	To      time.Time          `json:"to"`
+	Sort    LeaderboardSort    `json:"sort"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// stats is a handler for the /stats endpoint.
+// It has a required query parameter of statusPageUrl
+// It has optional query parameters of from and to (dates or RFC3339 timestamps, default is the last 90 days)
+// It returns the incident counts by impact, the mean and median time to resolve, the longest outage, the downtime,
+// the most affected components and the stats of each month of the period along with their change from the previous month
+func (s *Server) stats(context *gin.Context) {
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+	from, to, err := parseStatsPeriod(context, maxStatsDays)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, found, err := s.getStatusPageFromCache(statusPageUrl); err != nil || !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+
+	incidents, err := s.dbClient.ListIncidents(context.Request.Context(), db.IncidentFilter{StatusPageUrls: []string{statusPageUrl}, From: &from, To: &to})
+	if err != nil {
+		s.logger.Error("failed to get incidents from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+		return
+	}
+
+	context.JSON(http.StatusOK, StatsResponse{
+		StatusPageUrl: statusPageUrl,
+		From:          from,
+		To:            to,
+		Stats:         stats.Compute(incidents, from, to, time.Now()),
+	})
+}
+
+// statsLeaderboard is a handler for the /stats/leaderboard endpoint.
+// It ranks the indexed status pages the workspace sees, the most reliable first or the least reliable first with order=worst
+// It has optional query parameters of from and to (dates or RFC3339 timestamps, default is the last 90 days, at most a year),
+// sort (downtime, incidents or mttr, default is downtime) and limit (default is 25, at most 100)
+// Status pages that resolved no incident have no mean time to resolve and rank as the best by mttr
+func (s *Server) statsLeaderboard(context *gin.Context) {
+	from, to, err := parseStatsPeriod(context, maxLeaderboardDays)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sortBy := LeaderboardSort(context.DefaultQuery("sort", string(LeaderboardSortDowntime)))
+	if sortBy != LeaderboardSortDowntime && sortBy != LeaderboardSortIncidents && sortBy != LeaderboardSortMttr {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "sort must be downtime, incidents or mttr"})
+		return
+	}
+	order := context.DefaultQuery("order", "best")
+	if order != "best" && order != "worst" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "order must be best or worst"})
+		return
+	}
+	limit := defaultLeaderboardLimit
+	if limitStr := context.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxLeaderboardLimit {
+			context.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be an integer between 1 and %d", maxLeaderboardLimit)})
+			return
+		}
+	}
+
+	// The entries are cached for the period as requested, so that a default period ending now is cached too
+	scope := getWorkspaceScope(context)
+	cacheKey := fmt.Sprintf("%d|%s|%s", scope.workspaceID, context.Query("from"), context.Query("to"))
+	cached, found := s.leaderboardCache.Get(cacheKey)
+	if !found {
+		computed, err := s.computeLeaderboard(context, scope, from, to)
+		if err != nil {
+			s.logger.Error("failed to get incidents from database", zap.Error(err))
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get incidents from database"})
+			return
+		}
+		s.leaderboardCache.Set(cacheKey, computed, cache.DefaultExpiration)
+		cached = computed
+	}
+	response := cached.(LeaderboardResponse)
+	response.Sort = sortBy
+	// The cached entries are shared between requests, they are ranked on a copy
+	response.Entries = append([]LeaderboardEntry{}, response.Entries...)
+	rankLeaderboard(response.Entries, sortBy, order == "worst")
+	if len(response.Entries) > limit {
+		response.Entries = response.Entries[:limit]
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// computeLeaderboard returns the unranked entries of the indexed status pages the workspace sees
+// Only the fields of the incidents the summaries need are read, the default workspace reads those of every status page
+func (s *Server) computeLeaderboard(context *gin.Context, scope workspaceScope, from time.Time, to time.Time) (LeaderboardResponse, error) {
+	response := LeaderboardResponse{From: from, To: to, Entries: []LeaderboardEntry{}}
+	statusPageUrls, ok := scope.restrict(nil)
+	if !ok {
+		return response, nil
+	}
+	incidents, err := s.dbClient.ListIncidentOutages(context.Request.Context(), db.IncidentFilter{StatusPageUrls: statusPageUrls, From: &from, To: &to})
+	if err != nil {
+		return response, err
+	}
+	incidentsByStatusPage := make(map[string][]api.Incident)
+	for _, incident := range incidents {
+		incidentsByStatusPage[incident.StatusPageUrl] = append(incidentsByStatusPage[incident.StatusPageUrl], incident)
+	}
+
+	now := time.Now()
+	for _, statusPage := range s.getVisibleStatusPages(scope) {
+		if !statusPage.IsIndexed {
+			continue
+		}
+		response.Entries = append(response.Entries, LeaderboardEntry{
+			StatusPageUrl: statusPage.URL,
+			Name:          statusPage.Name,
+			Stats:         stats.Summarize(incidentsByStatusPage[statusPage.URL], from, to, now),
+		})
+	}
+	return response, nil
+}
+
+// rankLeaderboard sorts the entries, the most reliable first unless worstFirst, and sets their rank
+// Ties are broken by the other measures, then by name
+func rankLeaderboard(entries []LeaderboardEntry, sortBy LeaderboardSort, worstFirst bool) {
+	mttr := func(entry LeaderboardEntry) float64 {
+		if entry.Stats.MeanTimeToResolveSeconds == nil {
+			return 0
+		}
+		return *entry.Stats.MeanTimeToResolveSeconds
+	}
+	measures := func(entry LeaderboardEntry) []float64 {
+		downtime, incidents := entry.Stats.DowntimeSeconds, float64(entry.Stats.IncidentCount)
+		switch sortBy {
+		case LeaderboardSortIncidents:
+			return []float64{incidents, downtime, mttr(entry)}
+		case LeaderboardSortMttr:
+			return []float64{mttr(entry), downtime, incidents}
+		default:
+			return []float64{downtime, incidents, mttr(entry)}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		left, right := measures(entries[i]), measures(entries[j])
+		for k := range left {
+			if left[k] != right[k] {
+				return (left[k] < right[k]) != worstFirst
+			}
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+}
+
+// parseStatsPeriod returns the period of the from and to query parameters, the last 90 days by default
+// The period must not be longer than maxDays
+func parseStatsPeriod(context *gin.Context, maxDays int) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if toStr := context.Query("to"); toStr != "" {
+		parsed, err := parseTimeQuery(toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -defaultStatsDays)
+	if fromStr := context.Query("from"); fromStr != "" {
+		parsed, err := parseTimeQuery(fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	if to.Sub(from) > time.Duration(maxDays)*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("the period must not be longer than %d days", maxDays)
+	}
+	return from, to, nil
+}
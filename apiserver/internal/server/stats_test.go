@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/stats"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestRankLeaderboard(t *testing.T) {
+	hour := 3600.0
+	entries := func() []LeaderboardEntry {
+		return []LeaderboardEntry{
+			{Name: "Beta", Stats: stats.Summary{IncidentCount: 1, DowntimeSeconds: hour, MeanTimeToResolveSeconds: &hour}},
+			{Name: "alpha", Stats: stats.Summary{IncidentCount: 1, DowntimeSeconds: hour, MeanTimeToResolveSeconds: &hour}},
+			{Name: "Gamma", Stats: stats.Summary{IncidentCount: 4, DowntimeSeconds: 0.5 * hour}},
+			{Name: "Delta", Stats: stats.Summary{}},
+		}
+	}
+	names := func(entries []LeaderboardEntry) []string {
+		var result []string
+		for i, entry := range entries {
+			if entry.Rank != i+1 {
+				t.Errorf("expected %s to rank %d, got %d", entry.Name, i+1, entry.Rank)
+			}
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		sortBy     LeaderboardSort
+		worstFirst bool
+		expected   []string
+	}{
+		{sortBy: LeaderboardSortDowntime, expected: []string{"Delta", "Gamma", "alpha", "Beta"}},
+		{sortBy: LeaderboardSortDowntime, worstFirst: true, expected: []string{"alpha", "Beta", "Gamma", "Delta"}},
+		{sortBy: LeaderboardSortIncidents, expected: []string{"Delta", "alpha", "Beta", "Gamma"}},
+		// Status pages that resolved no incident rank as the best by mttr
+		{sortBy: LeaderboardSortMttr, expected: []string{"Delta", "Gamma", "alpha", "Beta"}},
+	}
+	for _, test := range tests {
+		ranked := entries()
+		rankLeaderboard(ranked, test.sortBy, test.worstFirst)
+		got := names(ranked)
+		for i := range test.expected {
+			if got[i] != test.expected[i] {
+				t.Errorf("sorting by %s (worst first %t): expected %v, got %v", test.sortBy, test.worstFirst, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestStatsLeaderboardIsCached(t *testing.T) {
+	// The contract test server has no database, the leaderboard can only come from the cache
+	s := newContractTestServer(t)
+	cached := LeaderboardResponse{Entries: []LeaderboardEntry{
+		{Name: "Busy", Stats: stats.Summary{IncidentCount: 3}},
+		{Name: "Quiet", Stats: stats.Summary{IncidentCount: 1}},
+	}}
+	s.leaderboardCache.Set(fmt.Sprintf("%d||", api.DefaultWorkspaceID), cached, cache.DefaultExpiration)
+	r := s.router()
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiV1Prefix+"/stats/leaderboard?sort=incidents&limit=1", nil))
+	var response LeaderboardResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || len(response.Entries) != 1 || response.Entries[0].Name != "Quiet" || response.Sort != LeaderboardSortIncidents {
+		t.Errorf("expected the cached leaderboard ranked by incidents, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if cached.Entries[0].Name != "Busy" || cached.Entries[0].Rank != 0 {
+		t.Errorf("expected the cached entries to be left as they were, got %+v", cached.Entries)
+	}
+}
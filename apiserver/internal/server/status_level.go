@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+type StatusLevel string
+
+const (
+	StatusLevelOperational    StatusLevel = "OPERATIONAL"
+	StatusLevelMaintenance    StatusLevel = "MAINTENANCE"
+	StatusLevelMinorOutage    StatusLevel = "MINOR_OUTAGE"
+	StatusLevelMajorOutage    StatusLevel = "MAJOR_OUTAGE"
+	StatusLevelCriticalOutage StatusLevel = "CRITICAL_OUTAGE"
+	StatusLevelStale          StatusLevel = "STALE"
+)
+
+// staleAfterScrapeIntervals is how many scrape intervals can pass without a successful scrape before the status page is stale
+const staleAfterScrapeIntervals = 3
+
+// computeStatusLevel returns the level of the status page and the reason for it.
+// A status page that has not been scraped successfully for a few scrape intervals is STALE, whatever its incidents say,
+// otherwise the level comes from the most severe ongoing incident.
+// Incidents without an impact are MINOR_OUTAGE as some providers, e.g. RSS feeds, do not report the impact at all.
+func computeStatusLevel(statusPage api.StatusPage, incidents []api.Incident, now time.Time) (StatusLevel, string) {
+	if !statusPage.IsIndexed {
+		return StatusLevelStale, "the status page has not been scraped successfully yet"
+	}
+
+	lastScraped := statusPage.LastCurrentlyScraped
+	if statusPage.LastSuccessfullyScraped != nil {
+		lastScraped = *statusPage.LastSuccessfullyScraped
+	}
+	staleAfter := staleAfterScrapeIntervals * statusPage.ScrapeInterval()
+	if now.Sub(lastScraped) > staleAfter {
+		return StatusLevelStale, fmt.Sprintf("the status page was last scraped successfully at %s, it is scraped every %s",
+			lastScraped.UTC().Format(time.RFC3339), statusPage.ScrapeInterval())
+	}
+
+	highest := highestImpact(incidents)
+	if highest == nil {
+		return StatusLevelOperational, "no ongoing incidents"
+	}
+
+	var level StatusLevel
+	switch *highest {
+	case api.ImpactCritical:
+		level = StatusLevelCriticalOutage
+	case api.ImpactMajor:
+		level = StatusLevelMajorOutage
+	case api.ImpactMaintenance:
+		level = StatusLevelMaintenance
+	default:
+		level = StatusLevelMinorOutage
+	}
+
+	var title string
+	for _, incident := range incidents {
+		if incident.Impact == *highest {
+			title = incident.Title
+			break
+		}
+	}
+	if len(incidents) == 1 {
+		return level, fmt.Sprintf("1 ongoing incident with %s impact: %s", *highest, title)
+	}
+	return level, fmt.Sprintf("%d ongoing incidents, the most severe has %s impact: %s", len(incidents), *highest, title)
+}
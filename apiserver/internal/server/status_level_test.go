@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestComputeStatusLevel(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	recently := now.Add(-time.Minute)
+	statusPage := api.StatusPage{IsIndexed: true, LastCurrentlyScraped: recently, LastSuccessfullyScraped: &recently}
+
+	tests := []struct {
+		name       string
+		statusPage func(api.StatusPage) api.StatusPage
+		impacts    []api.Impact
+		expected   StatusLevel
+	}{
+		{name: "no incidents", expected: StatusLevelOperational},
+		{name: "maintenance", impacts: []api.Impact{api.ImpactMaintenance}, expected: StatusLevelMaintenance},
+		{name: "no impact", impacts: []api.Impact{api.ImpactNone}, expected: StatusLevelMinorOutage},
+		{name: "most severe wins", impacts: []api.Impact{api.ImpactMinor, api.ImpactCritical, api.ImpactMaintenance}, expected: StatusLevelCriticalOutage},
+		{name: "major", impacts: []api.Impact{api.ImpactMajor}, expected: StatusLevelMajorOutage},
+		{
+			name: "not indexed",
+			statusPage: func(p api.StatusPage) api.StatusPage {
+				p.IsIndexed = false
+				return p
+			},
+			expected: StatusLevelStale,
+		},
+		{
+			name: "failing scrapes",
+			statusPage: func(p api.StatusPage) api.StatusPage {
+				lastSuccess := now.Add(-24 * time.Hour)
+				p.LastSuccessfullyScraped = &lastSuccess
+				return p
+			},
+			impacts:  []api.Impact{api.ImpactCritical},
+			expected: StatusLevelStale,
+		},
+		{
+			name: "longer scrape interval",
+			statusPage: func(p api.StatusPage) api.StatusPage {
+				lastSuccess := now.Add(-time.Hour)
+				p.LastSuccessfullyScraped = &lastSuccess
+				p.ScrapeIntervalSeconds = 3600
+				return p
+			},
+			expected: StatusLevelOperational,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := statusPage
+			if test.statusPage != nil {
+				page = test.statusPage(page)
+			}
+			var incidents []api.Incident
+			for _, impact := range test.impacts {
+				incidents = append(incidents, api.Incident{Title: string(impact), Impact: impact})
+			}
+			level, reason := computeStatusLevel(page, incidents, now)
+			if level != test.expected {
+				t.Errorf("expected %s, got %s (%s)", test.expected, level, reason)
+			}
+			if reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
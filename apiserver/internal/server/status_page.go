@@ -38,9 +38,9 @@ func (s *Server) statusPage(context *gin.Context) {
 	}
 
 	if statusPageName != "" {
-		for _, statusPage := range s.statusPageCache.Items() {
-			if strings.ToLower(statusPage.Object.(api.StatusPage).Name) == statusPageName {
-				context.JSON(http.StatusOK, StatusPageResponse{StatusPage: statusPage.Object.(api.StatusPage)})
+		for _, statusPage := range s.getVisibleStatusPages(getWorkspaceScope(context)) {
+			if strings.ToLower(statusPage.Name) == statusPageName {
+				context.JSON(http.StatusOK, StatusPageResponse{StatusPage: statusPage})
 				return
 			}
 		}
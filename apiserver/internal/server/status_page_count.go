@@ -11,5 +11,10 @@ type StatusPageCountResponse struct {
 
 // statusPageCount is a handler for the /statusPages/count endpoint.
 func (s *Server) statusPageCount(context *gin.Context) {
-	context.JSON(http.StatusOK, StatusPageCountResponse{StatusPageCount: s.statusPageCache.ItemCount()})
+	scope := getWorkspaceScope(context)
+	if scope.isDefault() {
+		context.JSON(http.StatusOK, StatusPageCountResponse{StatusPageCount: s.statusPageCache.ItemCount()})
+		return
+	}
+	context.JSON(http.StatusOK, StatusPageCountResponse{StatusPageCount: len(s.getVisibleStatusPages(scope))})
 }
@@ -34,10 +34,10 @@ func (s *Server) statusPageSearch(context *gin.Context) {
 
 	var statusPagesRanked []statusPageRanked
 
-	for _, statusPage := range s.statusPageCache.Items() {
+	for _, statusPage := range s.getVisibleStatusPages(getWorkspaceScope(context)) {
 		score := math.MaxInt
-		nameMatch := fuzzy.RankMatch(query, strings.ToLower(statusPage.Object.(api.StatusPage).Name))
-		urlMatch := fuzzy.RankMatch(query, strings.ToLower(statusPage.Object.(api.StatusPage).URL))
+		nameMatch := fuzzy.RankMatch(query, strings.ToLower(statusPage.Name))
+		urlMatch := fuzzy.RankMatch(query, strings.ToLower(statusPage.URL))
 		if nameMatch != -1 {
 			score = nameMatch
 		}
@@ -48,7 +48,7 @@ func (s *Server) statusPageSearch(context *gin.Context) {
 		}
 
 		if score != math.MaxInt {
-			statusPagesRanked = append(statusPagesRanked, statusPageRanked{StatusPage: statusPage.Object.(api.StatusPage), Score: score})
+			statusPagesRanked = append(statusPagesRanked, statusPageRanked{StatusPage: statusPage, Score: score})
 		}
 	}
 
@@ -57,7 +57,7 @@ func (s *Server) statusPageSearch(context *gin.Context) {
 		return statusPagesRanked[i].Score < statusPagesRanked[j].Score
 	})
 
-	var statusPages []api.StatusPage
+	statusPages := []api.StatusPage{}
 	for _, statusPage := range statusPagesRanked {
 		statusPages = append(statusPages, statusPage.StatusPage)
 	}
@@ -13,10 +13,7 @@ type StatusPagesResponse struct {
 }
 
 func (s *Server) statusPages(context *gin.Context) {
-	var statusPages []api.StatusPage
-	for _, statusPage := range s.statusPageCache.Items() {
-		statusPages = append(statusPages, statusPage.Object.(api.StatusPage))
-	}
+	statusPages := s.getVisibleStatusPages(getWorkspaceScope(context))
 
 	// Sort the status pages by name alphabetically a to z
 	sort.Slice(statusPages, func(i, j int) bool {
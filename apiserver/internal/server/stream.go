@@ -0,0 +1,61 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamKeepAliveInterval is how often a comment is sent to keep idle connections open through proxies
+const streamKeepAliveInterval = 30 * time.Second
+
+// stream is a handler for the /stream endpoint, it streams server-sent events until the client disconnects.
+// It has an optional query parameter of statusPageUrl, which can be repeated, to only receive the events of some status pages
+// It has an optional query parameter of impact (default is all), which is an array of impacts e.g. impact=critical,major
+// The events are incident.created, incident.updated and incident.resolved with the incident,
+// and status.changed with the new status and level of a status page. The impact does not filter status.changed events.
+// Status changes are only noticed when an incident changes, a status page becoming stale does not send an event.
+// Clients that fall too far behind, or connected to an apiserver that shuts down, are disconnected and should reconnect, events are not replayed.
+func (s *Server) stream(context *gin.Context) {
+	impacts, err := parseImpactQuery(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscriber := newStreamSubscriber(context.QueryArray("statusPageUrl"), impacts)
+	// The subscriptions of the workspace are those when the client connected
+	subscriber.visibleStatusPageUrls = getWorkspaceScope(context).statusPageUrls
+	s.streamBroker.subscribe(subscriber)
+	defer s.streamBroker.unsubscribe(subscriber)
+
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the events
+	context.Header("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+	context.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+	context.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscriber.events:
+			if !ok {
+				return false
+			}
+			context.SSEvent(event.name, event.data)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-context.Request.Context().Done():
+			return false
+		case <-s.shutdown:
+			return false
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"go.uber.org/zap"
+)
+
+const (
+	streamEventIncidentCreated  = "incident.created"
+	streamEventIncidentUpdated  = "incident.updated"
+	streamEventIncidentResolved = "incident.resolved"
+	streamEventStatusChanged    = "status.changed"
+)
+
+// streamSubscriberBuffer is how many events a subscriber can fall behind before it is disconnected
+const streamSubscriberBuffer = 64
+
+// The incident changes received within incidentChangeBatchWindow are published together, up to incidentChangeBatchSize
+const incidentChangeBatchWindow = 200 * time.Millisecond
+const incidentChangeBatchSize = 500
+
+type streamEvent struct {
+	name          string
+	data          interface{}
+	statusPageUrl string
+	// impact is nil for events that are not about a single incident, they are not filtered by impact
+	impact *api.Impact
+}
+
+type IncidentStreamEvent struct {
+	Type     db.IncidentChangeType `json:"type"`
+	Incident api.Incident          `json:"incident"`
+}
+
+type StatusStreamEvent struct {
+	StatusPageUrl string      `json:"statusPageUrl"`
+	Status        Status      `json:"status"`
+	Level         StatusLevel `json:"level"`
+	Reason        string      `json:"reason"`
+}
+
+type streamSubscriber struct {
+	// statusPageUrls and impacts are empty to receive the events of every status page and impact
+	statusPageUrls map[string]bool
+	impacts        map[api.Impact]bool
+	// visibleStatusPageUrls are the status pages the workspace of the subscriber sees, nil for every status page
+	visibleStatusPageUrls map[string]bool
+	events                chan streamEvent
+}
+
+func newStreamSubscriber(statusPageUrls []string, impacts []api.Impact) *streamSubscriber {
+	subscriber := &streamSubscriber{
+		statusPageUrls: make(map[string]bool),
+		impacts:        make(map[api.Impact]bool),
+		events:         make(chan streamEvent, streamSubscriberBuffer),
+	}
+	for _, statusPageUrl := range statusPageUrls {
+		subscriber.statusPageUrls[statusPageUrl] = true
+	}
+	for _, impact := range impacts {
+		subscriber.impacts[impact] = true
+	}
+	return subscriber
+}
+
+func (s *streamSubscriber) matches(event streamEvent) bool {
+	if s.visibleStatusPageUrls != nil && !s.visibleStatusPageUrls[event.statusPageUrl] {
+		return false
+	}
+	if len(s.statusPageUrls) > 0 && !s.statusPageUrls[event.statusPageUrl] {
+		return false
+	}
+	if len(s.impacts) > 0 && event.impact != nil && !s.impacts[*event.impact] {
+		return false
+	}
+	return true
+}
+
+// streamBroker fans the events out to the subscribers of this apiserver
+type streamBroker struct {
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]struct{}
+	// levels is the last known level of each status page, to only publish status changes
+	levels map[string]StatusLevel
+}
+
+func newStreamBroker() *streamBroker {
+	return &streamBroker{
+		subscribers: make(map[*streamSubscriber]struct{}),
+		levels:      make(map[string]StatusLevel),
+	}
+}
+
+func (b *streamBroker) subscribe(subscriber *streamSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[subscriber] = struct{}{}
+}
+
+func (b *streamBroker) unsubscribe(subscriber *streamSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[subscriber]; ok {
+		delete(b.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// publish sends the event to every matching subscriber
+// Subscribers that do not keep up are disconnected rather than slowing down everyone else
+func (b *streamBroker) publish(event streamEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for subscriber := range b.subscribers {
+		if !subscriber.matches(event) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			delete(b.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+// updateLevel records the level of the status page and returns whether it changed
+func (b *streamBroker) updateLevel(statusPageUrl string, level StatusLevel) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous, ok := b.levels[statusPageUrl]
+	b.levels[statusPageUrl] = level
+	return !ok || previous != level
+}
+
+// batchIncidentChanges calls publish with the incident changes received on the channel within the window of the first
+// one, at most maxSize at a time, until the channel is closed
+func batchIncidentChanges(changes <-chan db.IncidentChange, window time.Duration, maxSize int, publish func([]db.IncidentChange)) {
+	for change := range changes {
+		batch := []db.IncidentChange{change}
+		timer := time.NewTimer(window)
+	collect:
+		for len(batch) < maxSize {
+			select {
+			case change, ok := <-changes:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, change)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		publish(batch)
+	}
+}
+
+// publishIncidentChanges publishes the changed incidents and, if they changed, the new statuses of their status pages
+// The incidents are read at once, a scrape that writes many incidents does not delay the subscribers with a query each
+func (s *Server) publishIncidentChanges(ctx context.Context, changes []db.IncidentChange) {
+	ids := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.IncidentID)
+	}
+	incidents, err := s.dbClient.GetIncidentsByIDs(ctx, ids)
+	if err != nil {
+		s.logger.Error("failed to get changed incidents", zap.Error(err), zap.Int("count", len(ids)))
+	}
+
+	var statusPageUrls []string
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if !seen[change.StatusPageUrl] {
+			seen[change.StatusPageUrl] = true
+			statusPageUrls = append(statusPageUrls, change.StatusPageUrl)
+		}
+		incident, ok := incidents[change.IncidentID]
+		if !ok {
+			continue
+		}
+		name := streamEventIncidentUpdated
+		switch change.Type {
+		case db.IncidentChangeCreated:
+			name = streamEventIncidentCreated
+		case db.IncidentChangeResolved:
+			name = streamEventIncidentResolved
+		}
+		s.streamBroker.publish(streamEvent{
+			name:          name,
+			data:          IncidentStreamEvent{Type: change.Type, Incident: incident},
+			statusPageUrl: incident.StatusPageUrl,
+			impact:        &incident.Impact,
+		})
+	}
+	for _, statusPageUrl := range statusPageUrls {
+		s.publishStatusChange(ctx, statusPageUrl)
+	}
+}
+
+// publishStatusChange publishes the status of the status page if it changed
+func (s *Server) publishStatusChange(ctx context.Context, statusPageUrl string) {
+	statusPage, found, err := s.getStatusPageFromCache(statusPageUrl)
+	if err != nil || !found {
+		return
+	}
+	incidents, found, err := s.getCurrentIncidents(ctx, statusPageUrl)
+	if err != nil {
+		s.logger.Error("failed to get current incidents", zap.Error(err), zap.String("url", statusPageUrl))
+		return
+	}
+	if !found {
+		return
+	}
+	status := StatusUp
+	if !statusPage.IsIndexed {
+		status = StatusUnknown
+	} else if len(incidents) > 0 {
+		status = StatusDegraded
+	}
+	level, reason := computeStatusLevel(statusPage, incidents, time.Now())
+	if !s.streamBroker.updateLevel(statusPageUrl, level) {
+		return
+	}
+	s.streamBroker.publish(streamEvent{
+		name:          streamEventStatusChanged,
+		data:          StatusStreamEvent{StatusPageUrl: statusPageUrl, Status: status, Level: level, Reason: reason},
+		statusPageUrl: statusPageUrl,
+	})
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+)
+
+func TestStreamBrokerFiltersSubscribers(t *testing.T) {
+	broker := newStreamBroker()
+	all := newStreamSubscriber(nil, nil)
+	github := newStreamSubscriber([]string{"https://www.githubstatus.com"}, []api.Impact{api.ImpactCritical})
+	workspace := newStreamSubscriber(nil, nil)
+	workspace.visibleStatusPageUrls = map[string]bool{"https://status.openai.com": true}
+	broker.subscribe(all)
+	broker.subscribe(github)
+	broker.subscribe(workspace)
+
+	minor := api.ImpactMinor
+	critical := api.ImpactCritical
+	broker.publish(streamEvent{name: streamEventIncidentCreated, statusPageUrl: "https://www.githubstatus.com", impact: &minor})
+	broker.publish(streamEvent{name: streamEventIncidentUpdated, statusPageUrl: "https://status.openai.com", impact: &critical})
+	broker.publish(streamEvent{name: streamEventIncidentResolved, statusPageUrl: "https://www.githubstatus.com", impact: &critical})
+	broker.publish(streamEvent{name: streamEventStatusChanged, statusPageUrl: "https://www.githubstatus.com"})
+
+	if len(all.events) != 4 {
+		t.Errorf("expected every event, got %d", len(all.events))
+	}
+	if len(workspace.events) != 1 {
+		t.Errorf("expected the events of the status page the workspace sees, got %d", len(workspace.events))
+	}
+	if len(github.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(github.events))
+	}
+	if event := <-github.events; event.name != streamEventIncidentResolved {
+		t.Errorf("expected %s, got %s", streamEventIncidentResolved, event.name)
+	}
+	if event := <-github.events; event.name != streamEventStatusChanged {
+		t.Errorf("expected %s, got %s", streamEventStatusChanged, event.name)
+	}
+}
+
+func TestStreamBrokerDisconnectsSlowSubscribers(t *testing.T) {
+	broker := newStreamBroker()
+	subscriber := newStreamSubscriber(nil, nil)
+	broker.subscribe(subscriber)
+
+	for i := 0; i <= streamSubscriberBuffer; i++ {
+		broker.publish(streamEvent{name: streamEventStatusChanged})
+	}
+	for range subscriber.events {
+	}
+	if len(broker.subscribers) != 0 {
+		t.Error("expected the subscriber to be disconnected")
+	}
+	// Unsubscribing a disconnected subscriber must not close its channel again
+	broker.unsubscribe(subscriber)
+}
+
+func TestBatchIncidentChanges(t *testing.T) {
+	changes := make(chan db.IncidentChange, 10)
+	batches := make(chan []db.IncidentChange, 10)
+	done := make(chan struct{})
+	go func() {
+		batchIncidentChanges(changes, time.Hour, 3, func(batch []db.IncidentChange) { batches <- batch })
+		close(done)
+	}()
+
+	for id := int64(1); id <= 4; id++ {
+		changes <- db.IncidentChange{IncidentID: id}
+	}
+	close(changes)
+	<-done
+	close(batches)
+
+	var sizes []int
+	for batch := range batches {
+		sizes = append(sizes, len(batch))
+	}
+	if len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 1 {
+		t.Errorf("expected a full batch and the rest when the channel closed, got batches of %v", sizes)
+	}
+}
+
+func TestBatchIncidentChangesPublishesAfterTheWindow(t *testing.T) {
+	changes := make(chan db.IncidentChange, 2)
+	batches := make(chan []db.IncidentChange)
+	changes <- db.IncidentChange{IncidentID: 1}
+	changes <- db.IncidentChange{IncidentID: 2}
+	go batchIncidentChanges(changes, 10*time.Millisecond, 100, func(batch []db.IncidentChange) { batches <- batch })
+	defer close(changes)
+
+	select {
+	case batch := <-batches:
+		if len(batch) != 2 {
+			t.Errorf("expected both changes, got %d", len(batch))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the batch to be published after the window")
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/uptime"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityMonth Granularity = "month"
+)
+
+const defaultUptimeDays = 30
+const maxUptimeDays = 3 * 366
+
+type UptimeBucket struct {
+	Start                time.Time `json:"start"`
+	PeriodSeconds        float64   `json:"periodSeconds"`
+	FullOutageSeconds    float64   `json:"fullOutageSeconds"`
+	PartialOutageSeconds float64   `json:"partialOutageSeconds"`
+	UptimePercentage     float64   `json:"uptimePercentage"`
+	// SlaBreached is only set when the status page has an sla target
+	SlaBreached *bool `json:"slaBreached,omitempty"`
+}
+
+type UptimeResponse struct {
+	StatusPageUrl    string         `json:"statusPageUrl"`
+	Component        string         `json:"component"`
+	Granularity      Granularity    `json:"granularity"`
+	From             time.Time      `json:"from"`
+	To               time.Time      `json:"to"`
+	SlaTarget        *float64       `json:"slaTarget"`
+	UptimePercentage float64        `json:"uptimePercentage"`
+	SlaBreached      *bool          `json:"slaBreached,omitempty"`
+	Buckets          []UptimeBucket `json:"buckets"`
+}
+
+// uptime is a handler for the /uptime endpoint.
+// It has a required query parameter of statusPageUrl
+// It has optional query parameters of from and to (dates, default is the last 30 days), granularity (day or month, default is day)
+// and component (default is the whole status page)
+// The uptime is served from the daily rollups computed by the jobrunner, days that have not been rolled up yet are missing
+func (s *Server) uptime(context *gin.Context) {
+	ctx := context.Request.Context()
+	statusPageUrl := context.Query("statusPageUrl")
+	if statusPageUrl == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "statusPageUrl is required"})
+		return
+	}
+
+	granularity := Granularity(context.DefaultQuery("granularity", string(GranularityDay)))
+	if granularity != GranularityDay && granularity != GranularityMonth {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be day or month"})
+		return
+	}
+
+	from, to, err := parseUptimeRange(context.Query("from"), context.Query("to"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	component := context.Query("component")
+
+	statusPage, found := s.statusPageCache.Get(statusPageUrl)
+	if !found {
+		context.JSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+		return
+	}
+	statusPageCasted, ok := statusPage.(api.StatusPage)
+	if !ok {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cast status page"})
+		return
+	}
+
+	days, err := s.getUptimeDays(ctx, statusPageUrl, component, from, to)
+	if err != nil {
+		s.logger.Error("failed to get uptime from database", zap.Error(err))
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get uptime from database"})
+		return
+	}
+
+	context.JSON(http.StatusOK, newUptimeResponse(statusPageCasted, component, granularity, from, to, days))
+}
+
+// newUptimeResponse returns the uptime of the rolled up days of a status page, or of one of its components, between from and to
+func newUptimeResponse(statusPage api.StatusPage, component string, granularity Granularity, from time.Time, to time.Time, days []api.UptimeDay) UptimeResponse {
+	response := UptimeResponse{
+		StatusPageUrl: statusPage.URL,
+		Component:     component,
+		Granularity:   granularity,
+		From:          from,
+		To:            to,
+		SlaTarget:     statusPage.SlaTarget,
+		Buckets:       bucketUptimeDays(days, granularity, statusPage.SlaTarget),
+	}
+	_, _, _, response.UptimePercentage = uptime.Aggregate(days)
+	response.SlaBreached = slaBreached(response.UptimePercentage, statusPage.SlaTarget)
+	return response
+}
+
+// parseUptimeRange returns the days between the from and to values (dates or RFC3339 timestamps), the last 30 days if they are empty
+func parseUptimeRange(fromStr string, toStr string) (time.Time, time.Time, error) {
+	to := uptime.StartOfDay(time.Now())
+	if toStr != "" {
+		parsed, err := parseTimeQuery(toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		to = uptime.StartOfDay(parsed)
+	}
+	from := to.AddDate(0, 0, -(defaultUptimeDays - 1))
+	if fromStr != "" {
+		parsed, err := parseTimeQuery(fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a date (2006-01-02) or an RFC3339 timestamp")
+		}
+		from = uptime.StartOfDay(parsed)
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if to.Sub(from) > maxUptimeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errors.New("the time range must not be longer than 3 years")
+	}
+	return from, to, nil
+}
+
+// getUptimeDays returns the rolled up days of a status page between from and to, for the whole status page if component is empty
+func (s *Server) getUptimeDays(ctx context.Context, statusPageUrl string, component string, from time.Time, to time.Time) ([]api.UptimeDay, error) {
+	days, err := s.dbClient.GetUptimeDays(ctx, statusPageUrl, uptime.PageComponent, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if component == uptime.PageComponent {
+		return days, nil
+	}
+	componentDays, err := s.dbClient.GetUptimeDays(ctx, statusPageUrl, component, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return overlayComponentDays(days, componentDays), nil
+}
+
+// overlayComponentDays returns the uptime of a component for each rolled up day of its status page
+// Components only have a rollup on the days they were affected by an incident, every other day they were fully up
+func overlayComponentDays(pageDays []api.UptimeDay, componentDays []api.UptimeDay) []api.UptimeDay {
+	byDay := make(map[time.Time]api.UptimeDay)
+	for _, day := range componentDays {
+		byDay[uptime.StartOfDay(day.Day)] = day
+	}
+
+	days := make([]api.UptimeDay, 0, len(pageDays))
+	for _, pageDay := range pageDays {
+		if componentDay, ok := byDay[uptime.StartOfDay(pageDay.Day)]; ok {
+			days = append(days, componentDay)
+			continue
+		}
+		days = append(days, api.UptimeDay{
+			StatusPageUrl:    pageDay.StatusPageUrl,
+			Day:              pageDay.Day,
+			PeriodSeconds:    pageDay.PeriodSeconds,
+			UptimePercentage: 100,
+			ComputedAt:       pageDay.ComputedAt,
+		})
+	}
+	return days
+}
+
+func bucketUptimeDays(days []api.UptimeDay, granularity Granularity, slaTarget *float64) []UptimeBucket {
+	buckets := []UptimeBucket{}
+	var current []api.UptimeDay
+	var currentStart time.Time
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		bucket := UptimeBucket{Start: currentStart}
+		bucket.PeriodSeconds, bucket.FullOutageSeconds, bucket.PartialOutageSeconds, bucket.UptimePercentage = uptime.Aggregate(current)
+		bucket.SlaBreached = slaBreached(bucket.UptimePercentage, slaTarget)
+		buckets = append(buckets, bucket)
+		current = nil
+	}
+
+	for _, day := range days {
+		start := uptime.StartOfDay(day.Day)
+		if granularity == GranularityMonth {
+			start = uptime.StartOfMonth(day.Day)
+		}
+		if !start.Equal(currentStart) {
+			flush()
+			currentStart = start
+		}
+		current = append(current, day)
+	}
+	flush()
+	return buckets
+}
+
+func slaBreached(uptimePercentage float64, slaTarget *float64) *bool {
+	if slaTarget == nil {
+		return nil
+	}
+	breached := uptimePercentage < *slaTarget
+	return &breached
+}
+
+// parseTimeQuery parses a query parameter that is either a date or an RFC3339 timestamp
+func parseTimeQuery(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+)
+
+const workspaceScopeContextKey = "workspaceScope"
+
+// workspaceScope is what a workspace sees of the status pages
+type workspaceScope struct {
+	workspaceID int64
+	// statusPageUrls are the status pages the workspace subscribed to, it is nil for the default workspace, which sees every status page
+	statusPageUrls map[string]bool
+}
+
+func (w workspaceScope) isDefault() bool {
+	return w.workspaceID == api.DefaultWorkspaceID
+}
+
+// canSee returns whether the status page is visible to the workspace, whether or not it is known to statusphere
+func (w workspaceScope) canSee(statusPageUrl string) bool {
+	return w.statusPageUrls == nil || w.statusPageUrls[statusPageUrl]
+}
+
+// restrict returns the status pages of the urls that the workspace sees, or the ones it subscribed to if urls is empty
+// It returns nil for the default workspace when urls is empty, as database filters take no urls as every status page
+// The second return value is false if the workspace sees none of them, so there is nothing to query
+func (w workspaceScope) restrict(urls []string) ([]string, bool) {
+	if len(urls) == 0 {
+		if w.statusPageUrls == nil {
+			return nil, true
+		}
+		subscribed := make([]string, 0, len(w.statusPageUrls))
+		for statusPageUrl := range w.statusPageUrls {
+			subscribed = append(subscribed, statusPageUrl)
+		}
+		sort.Strings(subscribed)
+		return subscribed, len(subscribed) > 0
+	}
+	var visible []string
+	for _, statusPageUrl := range urls {
+		if w.canSee(statusPageUrl) {
+			visible = append(visible, statusPageUrl)
+		}
+	}
+	return visible, len(visible) > 0
+}
+
+// scopeToWorkspace loads the status pages of the workspace of the principal, it must run after authenticate
+// The subscriptions of a workspace are cached for a minute, so other apiservers can take that long to notice a change
+func (s *Server) scopeToWorkspace() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, _ := context.Get(principalContextKey)
+		p, _ := value.(principal)
+		scope := workspaceScope{workspaceID: p.workspaceID}
+		if scope.workspaceID == 0 {
+			scope.workspaceID = api.DefaultWorkspaceID
+		}
+		if !scope.isDefault() {
+			statusPageUrls, err := s.getWorkspaceStatusPageUrls(context, scope.workspaceID)
+			if err != nil {
+				s.logger.Error("failed to get the status pages of the workspace", zap.Error(err))
+				context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to get the status pages of the workspace"})
+				return
+			}
+			scope.statusPageUrls = statusPageUrls
+		}
+		context.Set(workspaceScopeContextKey, scope)
+		context.Next()
+	}
+}
+
+func (s *Server) getWorkspaceStatusPageUrls(context *gin.Context, workspaceID int64) (map[string]bool, error) {
+	cacheKey := strconv.FormatInt(workspaceID, 10)
+	if cached, found := s.workspaceStatusPageCache.Get(cacheKey); found {
+		if statusPageUrls, ok := cached.(map[string]bool); ok {
+			return statusPageUrls, nil
+		}
+	}
+	urls, err := s.dbClient.GetWorkspaceStatusPageUrls(context.Request.Context(), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	statusPageUrls := make(map[string]bool, len(urls))
+	for _, statusPageUrl := range urls {
+		statusPageUrls[statusPageUrl] = true
+	}
+	s.workspaceStatusPageCache.Set(cacheKey, statusPageUrls, cache.DefaultExpiration)
+	return statusPageUrls, nil
+}
+
+// getWorkspaceScope returns the scope set by scopeToWorkspace, the default workspace if there is none
+func getWorkspaceScope(context *gin.Context) workspaceScope {
+	value, _ := context.Get(workspaceScopeContextKey)
+	scope, ok := value.(workspaceScope)
+	if !ok {
+		return workspaceScope{workspaceID: api.DefaultWorkspaceID}
+	}
+	return scope
+}
+
+// requireVisibleStatusPages responds with a 404 to the requests for a status page that the workspace does not see,
+// like for a status page that is not known to statusphere, it must run after scopeToWorkspace
+func requireVisibleStatusPages() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		scope := getWorkspaceScope(context)
+		for _, statusPageUrl := range context.QueryArray("statusPageUrl") {
+			if !scope.canSee(statusPageUrl) {
+				context.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "status page not known to statusphere"})
+				return
+			}
+		}
+		context.Next()
+	}
+}
+
+// requireDefaultWorkspace rejects the requests that are not made from the default workspace, for what every workspace shares
+func requireDefaultWorkspace() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !getWorkspaceScope(context).isDefault() {
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "only the default workspace can do this"})
+			return
+		}
+		context.Next()
+	}
+}
+
+// getVisibleStatusPages returns the status pages of the status page cache that the workspace sees, in no particular order
+func (s *Server) getVisibleStatusPages(scope workspaceScope) []api.StatusPage {
+	statusPages := make([]api.StatusPage, 0)
+	for _, item := range s.statusPageCache.Items() {
+		statusPage, ok := item.Object.(api.StatusPage)
+		if ok && scope.canSee(statusPage.URL) {
+			statusPages = append(statusPages, statusPage)
+		}
+	}
+	return statusPages
+}
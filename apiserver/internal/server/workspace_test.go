@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestWorkspaceScopeRestrict(t *testing.T) {
+	defaultScope := workspaceScope{workspaceID: api.DefaultWorkspaceID}
+	subscribed := workspaceScope{workspaceID: 2, statusPageUrls: map[string]bool{"https://b.example.com": true, "https://a.example.com": true}}
+	empty := workspaceScope{workspaceID: 3, statusPageUrls: map[string]bool{}}
+
+	tests := []struct {
+		name     string
+		scope    workspaceScope
+		urls     []string
+		expected []string
+		ok       bool
+	}{
+		{name: "default without urls", scope: defaultScope, expected: nil, ok: true},
+		{name: "default with urls", scope: defaultScope, urls: []string{"https://c.example.com"}, expected: []string{"https://c.example.com"}, ok: true},
+		{name: "subscribed without urls", scope: subscribed, expected: []string{"https://a.example.com", "https://b.example.com"}, ok: true},
+		{name: "subscribed with urls", scope: subscribed, urls: []string{"https://b.example.com", "https://c.example.com"}, expected: []string{"https://b.example.com"}, ok: true},
+		{name: "subscribed with unseen urls", scope: subscribed, urls: []string{"https://c.example.com"}, expected: nil, ok: false},
+		{name: "no subscriptions", scope: empty, expected: []string{}, ok: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			urls, ok := test.scope.restrict(test.urls)
+			if ok != test.ok || !reflect.DeepEqual(urls, test.expected) {
+				t.Errorf("expected %v %t, got %v %t", test.expected, test.ok, urls, ok)
+			}
+		})
+	}
+}
+
+func TestWorkspaceScopesRequests(t *testing.T) {
+	s := newContractTestServer(t)
+	s.apiKeyCache.Set(hashApiKey("sp_workspace"), principal{
+		name:        "key:2",
+		scopes:      api.ApiKeyScopes{api.ApiKeyScopeAdmin},
+		workspaceID: 2,
+	}, cache.NoExpiration)
+	s.workspaceStatusPageCache.Set("2", map[string]bool{"https://status.example.com": true}, cache.NoExpiration)
+	r := s.router()
+
+	request := func(target string, token string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", apiV1Prefix+target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	var statusPages StatusPagesResponse
+	recorder := request("/statusPages", "sp_workspace")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &statusPages); err != nil || len(statusPages.StatusPages) != 1 || statusPages.StatusPages[0].URL != "https://status.example.com" {
+		t.Errorf("expected only the subscribed status page, got %s", recorder.Body.String())
+	}
+	if code := request("/currentStatus?statusPageUrl=https://status.unindexed.com", "sp_workspace").Code; code != http.StatusNotFound {
+		t.Errorf("expected a status page the workspace does not see to be unknown, got %d", code)
+	}
+	if code := request("/currentStatus?statusPageUrl=https://status.unindexed.com", "admin-token").Code; code != http.StatusOK {
+		t.Errorf("expected the default workspace to see every status page, got %d", code)
+	}
+
+	var batch CurrentStatusBatchResponse
+	recorder = request("/currentStatus/batch?statusPageUrl=https://status.example.com&statusPageUrl=https://status.unindexed.com", "sp_workspace")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &batch); err != nil || len(batch.Statuses) != 2 || !batch.Statuses[0].Known || batch.Statuses[1].Known {
+		t.Errorf("expected the status page the workspace does not see to be unknown, got %s", recorder.Body.String())
+	}
+
+	if code := request("/admin/statusPages", "sp_workspace").Code; code != http.StatusForbidden {
+		t.Errorf("expected only the default workspace to manage status pages, got %d", code)
+	}
+}
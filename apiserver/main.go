@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/metoro-io/statusphere/apiserver/internal/config"
 	"github.com/metoro-io/statusphere/apiserver/internal/server"
 	"github.com/metoro-io/statusphere/common/db"
 	"github.com/metoro-io/statusphere/common/utils"
@@ -28,20 +29,30 @@ func main() {
 		panic(err)
 	}
 
-	s := server.NewServer(logger, dbClient)
+	config, err := config.GetConfigFromEnvironment()
+	if err != nil {
+		panic(err)
+	}
+
+	s := server.NewServer(logger, config, dbClient)
 	s.StartCaches(ctx)
 
 	go func() {
+		logger.Info("Starting server", zap.String("address", config.ListenAddress))
 		if err := s.Serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			utils.GetLogger(ctx, logger).Fatal("Failed to start server", zap.Error(err))
 		}
-		logger.Info("Server started")
 	}()
 
-	// Listen for shutdown signal, then cancel the context
+	// Listen for shutdown signal, then let the requests in flight finish before cancelling the context
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
-	logger.Info("Shutting down", zap.String("signal", sig.String()))
+	logger.Info("Shutting down", zap.String("signal", sig.String()), zap.Duration("timeout", config.ShutdownTimeout))
 
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down gracefully", zap.Error(err))
+	}
 }
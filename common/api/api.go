@@ -19,6 +19,24 @@ const (
 	ImpactNone        Impact = "none"
 )
 
+// ImpactSeverity orders impacts from the least to the most severe, unknown impacts are the least severe
+func ImpactSeverity(impact Impact) int {
+	switch impact {
+	case ImpactNone:
+		return 1
+	case ImpactMaintenance:
+		return 2
+	case ImpactMinor:
+		return 3
+	case ImpactMajor:
+		return 4
+	case ImpactCritical:
+		return 5
+	default:
+		return 0
+	}
+}
+
 type HttpMethod string
 
 const (
@@ -72,20 +90,28 @@ func NewIncidentEvent(title string, description string, time time.Time) Incident
 }
 
 type Incident struct {
-	Title                   string             `json:"title"`
-	Components              []string           `gorm:"column:components;type:jsonb" json:"components"`
-	Events                  IncidentEventArray `gorm:"column:events;type:jsonb" json:"events"`
-	StartTime               time.Time          `gorm:"column:start_time;secondarykey" json:"startTime"`
-	EndTime                 *time.Time         `gorm:"column:end_time;secondarykey" json:"endTime"`
-	Description             *string            `gorm:"column:description" json:"description"`
-	DeepLink                string             `gorm:"column:deep_link;primarykey" json:"deepLink"`
-	Impact                  Impact             `gorm:"column:impact;secondarykey" json:"impact"`
-	StatusPageUrl           string             `gorm:"column:status_page_url;secondarykey" json:"statusPageUrl"`
-	NotificationJobsStarted bool               `gorm:"column:notification_jobs_started;secondarykey" json:"notificationJobsStarted"`
-	Scraper                 string             `gorm:"column:scraper" json:"scraper"`
-}
-
-func NewIncident(title string, components []string, events []IncidentEvent, startTime time.Time, endTime *time.Time, description *string, deepLink string, impact Impact, statusPageUrl string, scraper string) Incident {
+	// ID is the surrogate key of the incident, it is assigned by the database and never changes
+	ID          int64              `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Title       string             `json:"title"`
+	Components  []string           `gorm:"column:components;type:jsonb" json:"components"`
+	Events      IncidentEventArray `gorm:"column:events;type:jsonb" json:"events"`
+	StartTime   time.Time          `gorm:"column:start_time;secondarykey" json:"startTime"`
+	EndTime     *time.Time         `gorm:"column:end_time;secondarykey" json:"endTime"`
+	Description *string            `gorm:"column:description" json:"description"`
+	// DeepLink is a link to the incident on the status page, it is plain data and can change between scrapes
+	DeepLink string `gorm:"column:deep_link" json:"deepLink"`
+	// ExternalID is the identifier of the incident as given by the provider, e.g. the atlassian incident code
+	// It is unique per status page and provider
+	ExternalID    string `gorm:"column:external_id" json:"externalId"`
+	Impact        Impact `gorm:"column:impact;secondarykey" json:"impact"`
+	StatusPageUrl string `gorm:"column:status_page_url;secondarykey" json:"statusPageUrl"`
+	// Provider is the name of the provider that scraped the incident
+	Provider string `gorm:"column:provider" json:"provider"`
+	// State is derived from the end time and the updates of the incident whenever it is stored
+	State IncidentState `gorm:"column:state" json:"state"`
+}
+
+func NewIncident(title string, components []string, events []IncidentEvent, startTime time.Time, endTime *time.Time, description *string, deepLink string, externalID string, impact Impact, statusPageUrl string, provider string) Incident {
 	return Incident{
 		Title:         title,
 		Components:    components,
@@ -94,9 +120,10 @@ func NewIncident(title string, components []string, events []IncidentEvent, star
 		EndTime:       endTime,
 		Description:   description,
 		DeepLink:      deepLink,
+		ExternalID:    externalID,
 		Impact:        impact,
 		StatusPageUrl: statusPageUrl,
-		Scraper:       scraper,
+		Provider:      provider,
 	}
 }
 
@@ -138,13 +165,60 @@ type StatusPage struct {
 	// Used to determine if we should run a scrape for this status page
 	LastHistoricallyScraped time.Time `json:"lastHistoricallyScraped"`
 	LastCurrentlyScraped    time.Time `json:"lastCurrentlyScraped"`
+	// LastSuccessfullyScraped is nil if the status page has never been scraped successfully
+	LastSuccessfullyScraped *time.Time `json:"lastSuccessfullyScraped"`
 	// IsIndexed is used to determine if the status page has ever been indexed in the search engine successfully
-	IsIndexed        bool       `json:"isIndexed"`
-	PreferredScraper string     `json:"preferredScraper"`
-	Headers          JSONMap    `gorm:"type:jsonb" json:"headers"`
-	RequestPayload   JSONStruct `gorm:"type:jsonb" json:"payload"`
-	Method           HttpMethod `json:"httpMethod"`
-	ValidationRules  JSONStruct `gorm:"type:jsonb" json:"rules"`
+	IsIndexed        bool   `json:"isIndexed"`
+	PreferredScraper string `json:"preferredScraper"`
+	// Headers, RequestPayload and ValidationRules may hold credentials, they are only returned by the admin api as an
+	// AdminStatusPage
+	Headers         JSONMap    `gorm:"type:jsonb" json:"-"`
+	RequestPayload  JSONStruct `gorm:"type:jsonb" json:"-"`
+	Method          HttpMethod `json:"httpMethod"`
+	ValidationRules JSONStruct `gorm:"type:jsonb" json:"-"`
+	// SlaTarget is the uptime percentage the vendor is expected to meet, e.g. 99.9
+	SlaTarget *float64 `json:"slaTarget"`
+	// ScrapeIntervalSeconds is how often the status page is scraped, 0 means DefaultScrapeInterval
+	ScrapeIntervalSeconds int `json:"scrapeIntervalSeconds"`
+	// Source is where the status page is defined, only status pages defined in code are removed when they leave the code
+	Source StatusPageSource `json:"source"`
+}
+
+// AdminStatusPage is a status page along with the headers, payload and rules of its scrapes
+type AdminStatusPage struct {
+	StatusPage
+	Headers         JSONMap    `json:"headers"`
+	RequestPayload  JSONStruct `json:"payload"`
+	ValidationRules JSONStruct `json:"rules"`
+}
+
+// NewAdminStatusPage returns the status page with the headers, payload and rules of its scrapes
+func NewAdminStatusPage(statusPage StatusPage) AdminStatusPage {
+	return AdminStatusPage{
+		StatusPage:      statusPage,
+		Headers:         statusPage.Headers,
+		RequestPayload:  statusPage.RequestPayload,
+		ValidationRules: statusPage.ValidationRules,
+	}
+}
+
+type StatusPageSource string
+
+const (
+	StatusPageSourceCode  StatusPageSource = "code"
+	StatusPageSourceAdmin StatusPageSource = "admin"
+	StatusPageSourceFile  StatusPageSource = "file"
+)
+
+// DefaultScrapeInterval is how often status pages without a scrape interval are scraped
+const DefaultScrapeInterval = 5 * time.Minute
+
+// ScrapeInterval returns how often the status page is scraped
+func (p StatusPage) ScrapeInterval() time.Duration {
+	if p.ScrapeIntervalSeconds <= 0 {
+		return DefaultScrapeInterval
+	}
+	return time.Duration(p.ScrapeIntervalSeconds) * time.Second
 }
 
 func NewStatusPage(name string, url string) StatusPage {
@@ -155,3 +229,17 @@ func NewStatusPage(name string, url string) StatusPage {
 		LastCurrentlyScraped:    time.Time{},
 	}
 }
+
+// UptimeDay is the uptime of a status page, or one of its components, during a UTC day
+type UptimeDay struct {
+	StatusPageUrl string `gorm:"column:status_page_url;primaryKey" json:"statusPageUrl"`
+	// Component is empty for the uptime of the whole status page
+	Component string    `gorm:"column:component;primaryKey" json:"component"`
+	Day       time.Time `gorm:"column:day;primaryKey;type:date" json:"day"`
+	// PeriodSeconds is shorter than a day for the current day
+	PeriodSeconds        float64   `gorm:"column:period_seconds" json:"periodSeconds"`
+	FullOutageSeconds    float64   `gorm:"column:full_outage_seconds" json:"fullOutageSeconds"`
+	PartialOutageSeconds float64   `gorm:"column:partial_outage_seconds" json:"partialOutageSeconds"`
+	UptimePercentage     float64   `gorm:"column:uptime_percentage" json:"uptimePercentage"`
+	ComputedAt           time.Time `gorm:"column:computed_at" json:"computedAt"`
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ApiKeyScope string
+
+const (
+	// ApiKeyScopeRead gives access to the public endpoints
+	ApiKeyScopeRead ApiKeyScope = "read"
+	// ApiKeyScopeAdmin gives access to the admin endpoints, and to the public ones too
+	ApiKeyScopeAdmin ApiKeyScope = "admin"
+)
+
+func ParseApiKeyScope(scope string) (ApiKeyScope, error) {
+	switch scope {
+	case "read":
+		return ApiKeyScopeRead, nil
+	case "admin":
+		return ApiKeyScopeAdmin, nil
+	default:
+		return "", fmt.Errorf("invalid scope %q", scope)
+	}
+}
+
+type ApiKeyScopes []ApiKeyScope
+
+func (s *ApiKeyScopes) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *ApiKeyScopes", src)
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s ApiKeyScopes) Value() (driver.Value, error) {
+	val, err := json.Marshal(s)
+	return string(val), err
+}
+
+// Has returns whether the scopes give access to the scope, the admin scope gives access to every scope
+func (s ApiKeyScopes) Has(scope ApiKeyScope) bool {
+	for _, granted := range s {
+		if granted == scope || granted == ApiKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type ApiKey struct {
+	ID int64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	// WorkspaceID is the workspace the requests made with the key are scoped to
+	WorkspaceID int64  `gorm:"column:workspace_id" json:"-"`
+	Name        string `gorm:"column:name" json:"name"`
+	// KeyPrefix is the start of the key, to recognise it without storing it
+	KeyPrefix string       `gorm:"column:key_prefix" json:"keyPrefix"`
+	KeyHash   string       `gorm:"column:key_hash" json:"-"`
+	Scopes    ApiKeyScopes `gorm:"column:scopes;type:jsonb" json:"scopes"`
+	// RateLimitPerMinute is the number of requests the key can make per minute, 0 means the default rate limit
+	RateLimitPerMinute int        `gorm:"column:rate_limit_per_minute" json:"rateLimitPerMinute"`
+	CreatedAt          time.Time  `gorm:"column:created_at" json:"createdAt"`
+	RevokedAt          *time.Time `gorm:"column:revoked_at" json:"revokedAt"`
+}
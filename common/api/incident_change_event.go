@@ -0,0 +1,123 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IncidentChangeEventType is a change of an incident that channels can be notified of
+type IncidentChangeEventType string
+
+const (
+	IncidentChangeEventCreated       IncidentChangeEventType = "created"
+	IncidentChangeEventImpactChanged IncidentChangeEventType = "impact_changed"
+	IncidentChangeEventNewUpdate     IncidentChangeEventType = "new_update"
+	IncidentChangeEventResolved      IncidentChangeEventType = "resolved"
+)
+
+// IncidentChangeEventMaxAge is how long after an incident started, or after it ended, its changes are still notified
+// Older changes are the history of a status page that was scraped for the first time, or that was scraped late
+const IncidentChangeEventMaxAge = time.Hour
+
+// IncidentChangeEventTypes are every type of change event, in the order they happen to an incident
+var IncidentChangeEventTypes = []IncidentChangeEventType{
+	IncidentChangeEventCreated,
+	IncidentChangeEventImpactChanged,
+	IncidentChangeEventNewUpdate,
+	IncidentChangeEventResolved,
+}
+
+func ParseIncidentChangeEventType(eventType string) (IncidentChangeEventType, error) {
+	for _, known := range IncidentChangeEventTypes {
+		if string(known) == eventType {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("invalid incident change event type %q", eventType)
+}
+
+// IncidentChangeEventTypeArray is stored as a jsonb array
+type IncidentChangeEventTypeArray []IncidentChangeEventType
+
+func (a *IncidentChangeEventTypeArray) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *IncidentChangeEventTypeArray", src)
+	}
+	return json.Unmarshal(bytes, a)
+}
+
+func (a IncidentChangeEventTypeArray) Value() (driver.Value, error) {
+	val, err := json.Marshal(a)
+	return string(val), err
+}
+
+// Contains returns whether the event type is in the array
+func (a IncidentChangeEventTypeArray) Contains(eventType IncidentChangeEventType) bool {
+	for _, known := range a {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// IncidentChangeEvent is a change of an incident that is notified, it is stored along with the revision of the change
+type IncidentChangeEvent struct {
+	ID         int64                   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IncidentID int64                   `gorm:"column:incident_id" json:"incidentId"`
+	Type       IncidentChangeEventType `gorm:"column:type" json:"type"`
+	// PreviousImpact is the impact of the incident before an impact_changed event
+	PreviousImpact Impact    `gorm:"column:previous_impact" json:"previousImpact,omitempty"`
+	ObservedAt     time.Time `gorm:"column:observed_at" json:"observedAt"`
+	// NotifiedAt is when the notifications of the event were queued, it is nil until then
+	NotifiedAt *time.Time `gorm:"column:notified_at" json:"notifiedAt,omitempty"`
+}
+
+// GetIncidentChangeEvents returns the events of the change from the previously stored incident, nil for a new incident,
+// to the newly observed one. The IncidentID is not set.
+// A new incident and a resolution already show the latest update, they are not also reported as a new update.
+func GetIncidentChangeEvents(previous *Incident, current Incident, observedAt time.Time) []IncidentChangeEvent {
+	if previous == nil {
+		return []IncidentChangeEvent{{Type: IncidentChangeEventCreated, ObservedAt: observedAt}}
+	}
+
+	var events []IncidentChangeEvent
+	if previous.Impact != current.Impact {
+		events = append(events, IncidentChangeEvent{Type: IncidentChangeEventImpactChanged, PreviousImpact: previous.Impact, ObservedAt: observedAt})
+	}
+	if current.State == IncidentStateResolved && previous.State != IncidentStateResolved {
+		return append(events, IncidentChangeEvent{Type: IncidentChangeEventResolved, ObservedAt: observedAt})
+	}
+	if hasNewUpdate(previous.Events, current.Events) {
+		events = append(events, IncidentChangeEvent{Type: IncidentChangeEventNewUpdate, ObservedAt: observedAt})
+	}
+	return events
+}
+
+// IsStale returns whether the event is too old to be notified, see IncidentChangeEventMaxAge
+// A new incident is stale when it started long before it was observed, its other events when it ended long before
+func (e IncidentChangeEvent) IsStale(incident Incident) bool {
+	cutoff := e.ObservedAt.Add(-IncidentChangeEventMaxAge)
+	if e.Type == IncidentChangeEventCreated {
+		return incident.StartTime.Before(cutoff)
+	}
+	return incident.EndTime != nil && incident.EndTime.Before(cutoff)
+}
+
+// hasNewUpdate returns whether the current events have an update that the previous events did not
+// Updates that were edited count as new, updates that were removed do not
+func hasNewUpdate(previous IncidentEventArray, current IncidentEventArray) bool {
+	known := make(map[IncidentEvent]bool, len(previous))
+	for _, event := range normalizeEvents(previous) {
+		known[event] = true
+	}
+	for _, event := range normalizeEvents(current) {
+		if !known[event] {
+			return true
+		}
+	}
+	return false
+}
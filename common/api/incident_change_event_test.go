@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetIncidentChangeEvents(t *testing.T) {
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	observedAt := end.Add(time.Minute)
+	previous := Incident{
+		Impact: ImpactMinor,
+		Events: []IncidentEvent{{Title: "Investigating", Time: start}},
+		State:  IncidentStateInvestigating,
+	}
+	withUpdate := func(incident Incident, title string, at time.Time) Incident {
+		incident.Events = append([]IncidentEvent{{Title: title, Time: at}}, incident.Events...)
+		return incident
+	}
+
+	escalated := withUpdate(previous, "Identified", start.Add(10*time.Minute))
+	escalated.Impact = ImpactMajor
+	resolved := withUpdate(previous, "Resolved", end)
+	resolved.EndTime = &end
+	resolved.State = IncidentStateResolved
+	reordered := previous
+	reordered.Events = []IncidentEvent{{Title: "Investigating", Time: start.In(time.FixedZone("CET", 3600))}}
+
+	tests := []struct {
+		name     string
+		previous *Incident
+		current  Incident
+		expected []IncidentChangeEventType
+	}{
+		{name: "new incident", previous: nil, current: previous, expected: []IncidentChangeEventType{IncidentChangeEventCreated}},
+		{name: "unchanged", previous: &previous, current: reordered, expected: nil},
+		{name: "new update", previous: &previous, current: withUpdate(previous, "Identified", start.Add(10*time.Minute)), expected: []IncidentChangeEventType{IncidentChangeEventNewUpdate}},
+		{name: "escalated with an update", previous: &previous, current: escalated, expected: []IncidentChangeEventType{IncidentChangeEventImpactChanged, IncidentChangeEventNewUpdate}},
+		{name: "resolved", previous: &previous, current: resolved, expected: []IncidentChangeEventType{IncidentChangeEventResolved}},
+		{name: "update after the resolution", previous: &resolved, current: withUpdate(resolved, "Postmortem", end.Add(time.Hour)), expected: []IncidentChangeEventType{IncidentChangeEventNewUpdate}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := GetIncidentChangeEvents(test.previous, test.current, observedAt)
+			if len(events) != len(test.expected) {
+				t.Fatalf("expected %v, got %+v", test.expected, events)
+			}
+			for i, event := range events {
+				if event.Type != test.expected[i] || !event.ObservedAt.Equal(observedAt) {
+					t.Errorf("expected a %s event, got %+v", test.expected[i], event)
+				}
+			}
+		})
+	}
+
+	if events := GetIncidentChangeEvents(&previous, escalated, observedAt); events[0].PreviousImpact != ImpactMinor {
+		t.Errorf("expected the previous impact, got %q", events[0].PreviousImpact)
+	}
+}
+
+func TestIncidentChangeEventIsStale(t *testing.T) {
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+	incident := Incident{StartTime: start}
+
+	if (IncidentChangeEvent{Type: IncidentChangeEventCreated, ObservedAt: start.Add(5 * time.Minute)}).IsStale(incident) {
+		t.Errorf("expected a new incident to be notified")
+	}
+	if !(IncidentChangeEvent{Type: IncidentChangeEventCreated, ObservedAt: end}).IsStale(incident) {
+		t.Errorf("expected an incident first observed hours after it started to be stale")
+	}
+	if (IncidentChangeEvent{Type: IncidentChangeEventNewUpdate, ObservedAt: end}).IsStale(incident) {
+		t.Errorf("expected the updates of an ongoing incident to be notified")
+	}
+	incident.EndTime = &end
+	if (IncidentChangeEvent{Type: IncidentChangeEventResolved, ObservedAt: end.Add(time.Minute)}).IsStale(incident) {
+		t.Errorf("expected a resolution to be notified")
+	}
+	if !(IncidentChangeEvent{Type: IncidentChangeEventResolved, ObservedAt: end.Add(2 * time.Hour)}).IsStale(incident) {
+		t.Errorf("expected a resolution observed hours later to be stale")
+	}
+}
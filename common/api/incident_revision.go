@@ -0,0 +1,178 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IncidentState is the lifecycle state of an incident, derived from its end time and latest update
+type IncidentState string
+
+const (
+	IncidentStateInvestigating IncidentState = "investigating"
+	IncidentStateIdentified    IncidentState = "identified"
+	IncidentStateMonitoring    IncidentState = "monitoring"
+	IncidentStateResolved      IncidentState = "resolved"
+)
+
+// incidentStateKeywords maps the titles vendors use for incident updates to lifecycle states
+// Maintenance updates use their own titles, e.g. "Completed" for a finished maintenance
+var incidentStateKeywords = []struct {
+	keyword string
+	state   IncidentState
+}{
+	{"resolved", IncidentStateResolved},
+	{"completed", IncidentStateResolved},
+	{"postmortem", IncidentStateResolved},
+	{"monitoring", IncidentStateMonitoring},
+	{"verifying", IncidentStateMonitoring},
+	{"identified", IncidentStateIdentified},
+	{"in progress", IncidentStateIdentified},
+	{"investigating", IncidentStateInvestigating},
+}
+
+// DeriveIncidentState returns the lifecycle state of the incident at the given time
+// An incident that has ended is resolved, otherwise the state is taken from the most recent update with a known title
+func DeriveIncidentState(incident Incident, now time.Time) IncidentState {
+	if incident.EndTime != nil && !incident.EndTime.After(now) {
+		return IncidentStateResolved
+	}
+
+	events := make([]IncidentEvent, len(incident.Events))
+	copy(events, incident.Events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	for _, event := range events {
+		title := strings.ToLower(event.Title)
+		for _, keyword := range incidentStateKeywords {
+			if strings.Contains(title, keyword.keyword) {
+				return keyword.state
+			}
+		}
+	}
+	return IncidentStateInvestigating
+}
+
+// FieldChange is the change of a single incident field between two observations
+// Old is nil when the incident was observed for the first time
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type IncidentFieldChanges map[string]FieldChange
+
+func (c *IncidentFieldChanges) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *IncidentFieldChanges", value)
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+func (c IncidentFieldChanges) Value() (driver.Value, error) {
+	val, err := json.Marshal(c)
+	return string(val), err
+}
+
+// IncidentRevision records a change of an incident as observed by a scrape
+type IncidentRevision struct {
+	ID         int64                `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IncidentID int64                `gorm:"column:incident_id" json:"incidentId"`
+	ObservedAt time.Time            `gorm:"column:observed_at" json:"observedAt"`
+	State      IncidentState        `gorm:"column:state" json:"state"`
+	Changes    IncidentFieldChanges `gorm:"column:changes;type:jsonb" json:"changes"`
+}
+
+// DiffIncidents returns the changed fields between the previously stored incident and the newly observed one
+// If previous is nil, every populated field of the new incident is returned as a change
+// Identity and bookkeeping fields (id, external id, status page, provider) are ignored
+func DiffIncidents(previous *Incident, current Incident) IncidentFieldChanges {
+	changes := IncidentFieldChanges{}
+	add := func(field string, old interface{}, new interface{}) {
+		if previous != nil && reflect.DeepEqual(old, new) {
+			return
+		}
+		if previous == nil {
+			old = nil
+			if isEmptyValue(new) {
+				return
+			}
+		}
+		changes[field] = FieldChange{Old: old, New: new}
+	}
+
+	var old Incident
+	if previous != nil {
+		old = *previous
+	}
+	add("title", old.Title, current.Title)
+	add("components", normalizeComponents(old.Components), normalizeComponents(current.Components))
+	add("events", normalizeEvents(old.Events), normalizeEvents(current.Events))
+	add("startTime", normalizeTime(&old.StartTime), normalizeTime(&current.StartTime))
+	add("endTime", normalizeTime(old.EndTime), normalizeTime(current.EndTime))
+	add("description", stringOrNil(old.Description), stringOrNil(current.Description))
+	add("impact", old.Impact, current.Impact)
+	add("deepLink", old.DeepLink, current.DeepLink)
+	add("state", old.State, current.State)
+	return changes
+}
+
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Times are compared in UTC with second precision, the database does not keep the location or nanoseconds
+func normalizeTime(t *time.Time) *string {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	formatted := t.UTC().Truncate(time.Second).Format(time.RFC3339)
+	return &formatted
+}
+
+func normalizeComponents(components []string) []string {
+	if len(components) == 0 {
+		return nil
+	}
+	return components
+}
+
+func normalizeEvents(events IncidentEventArray) []IncidentEvent {
+	if len(events) == 0 {
+		return nil
+	}
+	normalized := make([]IncidentEvent, len(events))
+	for i, event := range events {
+		normalized[i] = IncidentEvent{
+			Title:       event.Title,
+			Description: event.Description,
+			Time:        event.Time.UTC().Truncate(time.Second),
+		}
+	}
+	return normalized
+}
+
+func stringOrNil(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
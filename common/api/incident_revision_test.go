@@ -0,0 +1,116 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeriveIncidentState(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		incident Incident
+		expected IncidentState
+	}{
+		{
+			name:     "no updates",
+			incident: Incident{},
+			expected: IncidentStateInvestigating,
+		},
+		{
+			name:     "ended",
+			incident: Incident{EndTime: &past},
+			expected: IncidentStateResolved,
+		},
+		{
+			name:     "ends in the future",
+			incident: Incident{EndTime: &future, Events: []IncidentEvent{{Title: "Scheduled", Time: past}}},
+			expected: IncidentStateInvestigating,
+		},
+		{
+			name: "latest known update wins",
+			incident: Incident{Events: []IncidentEvent{
+				{Title: "Monitoring", Time: now.Add(-10 * time.Minute)},
+				{Title: "Investigating", Time: now.Add(-30 * time.Minute)},
+				{Title: "Update", Time: now.Add(-5 * time.Minute)},
+				{Title: "Identified", Time: now.Add(-20 * time.Minute)},
+			}},
+			expected: IncidentStateMonitoring,
+		},
+		{
+			name:     "resolved update without end time",
+			incident: Incident{Events: []IncidentEvent{{Title: "Resolved", Time: past}}},
+			expected: IncidentStateResolved,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if state := DeriveIncidentState(test.incident, now); state != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, state)
+			}
+		})
+	}
+}
+
+func TestDiffIncidentsNewIncident(t *testing.T) {
+	description := "Payments are failing"
+	incident := Incident{
+		Title:       "Degraded payments",
+		Description: &description,
+		StartTime:   time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC),
+		Impact:      ImpactMajor,
+		State:       IncidentStateInvestigating,
+	}
+
+	changes := DiffIncidents(nil, incident)
+	for _, field := range []string{"title", "description", "startTime", "impact", "state"} {
+		change, ok := changes[field]
+		if !ok {
+			t.Errorf("expected a change for %s", field)
+			continue
+		}
+		if change.Old != nil {
+			t.Errorf("expected no old value for %s, got %v", field, change.Old)
+		}
+	}
+	for _, field := range []string{"endTime", "components", "events", "deepLink"} {
+		if _, ok := changes[field]; ok {
+			t.Errorf("expected no change for empty field %s", field)
+		}
+	}
+}
+
+func TestDiffIncidentsOnlyReportsChangedFields(t *testing.T) {
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	previous := Incident{
+		ID:        1,
+		Title:     "Degraded payments",
+		StartTime: start,
+		Impact:    ImpactMinor,
+		Events:    []IncidentEvent{{Title: "Investigating", Time: start}},
+		State:     IncidentStateInvestigating,
+	}
+	current := previous
+	current.ID = 0
+	// The database returns times in the local time zone with microsecond precision
+	current.StartTime = start.In(time.FixedZone("CET", 3600)).Add(300 * time.Nanosecond)
+	current.Events = []IncidentEvent{{Title: "Investigating", Time: start}}
+	current.Impact = ImpactMajor
+	current.EndTime = &end
+
+	changes := DiffIncidents(&previous, current)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %v", len(changes), changes)
+	}
+	if changes["impact"].Old != ImpactMinor || changes["impact"].New != ImpactMajor {
+		t.Errorf("unexpected impact change: %+v", changes["impact"])
+	}
+	if _, ok := changes["endTime"]; !ok {
+		t.Errorf("expected an end time change")
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ServiceGroupAggregation string
+
+const (
+	// ServiceGroupAggregationWorst gives the group the status of its most affected member
+	ServiceGroupAggregationWorst ServiceGroupAggregation = "worst"
+	// ServiceGroupAggregationWeighted gives the group the weighted average of the statuses of its members
+	ServiceGroupAggregationWeighted ServiceGroupAggregation = "weighted"
+)
+
+func ParseServiceGroupAggregation(aggregation string) (ServiceGroupAggregation, error) {
+	switch aggregation {
+	case "worst":
+		return ServiceGroupAggregationWorst, nil
+	case "weighted":
+		return ServiceGroupAggregationWeighted, nil
+	default:
+		return "", fmt.Errorf("invalid aggregation %q", aggregation)
+	}
+}
+
+// ServiceGroupMember is a status page, or a single component of it, that a service group depends on
+type ServiceGroupMember struct {
+	StatusPageUrl string `json:"statusPageUrl"`
+	// Component is empty when the group depends on the whole status page
+	Component string `json:"component,omitempty"`
+	// Weight is how much the member counts in the weighted aggregation, relative to the other members
+	Weight float64 `json:"weight"`
+}
+
+type ServiceGroupMembers []ServiceGroupMember
+
+func (m *ServiceGroupMembers) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *ServiceGroupMembers", src)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m ServiceGroupMembers) Value() (driver.Value, error) {
+	val, err := json.Marshal(m)
+	return string(val), err
+}
+
+type ServiceGroup struct {
+	ID          int64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	WorkspaceID int64 `gorm:"column:workspace_id" json:"-"`
+	// Name identifies the group in the urls of the api within its workspace, it is lower case letters, digits and dashes
+	Name        string                  `gorm:"column:name" json:"name"`
+	Description string                  `gorm:"column:description" json:"description"`
+	Aggregation ServiceGroupAggregation `gorm:"column:aggregation" json:"aggregation"`
+	Members     ServiceGroupMembers     `gorm:"column:members;type:jsonb" json:"members"`
+	CreatedAt   time.Time               `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time               `gorm:"column:updated_at" json:"updatedAt"`
+}
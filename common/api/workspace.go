@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultWorkspaceID is the workspace that sees every status page, requests without an api key belong to it
+	DefaultWorkspaceID int64 = 1
+	// DefaultWorkspaceName is the name of the default workspace
+	DefaultWorkspaceName = "default"
+)
+
+type Workspace struct {
+	ID int64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	// Name is lower case letters, digits and dashes
+	Name      string    `gorm:"column:name" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+// IsDefault returns whether the workspace is the default workspace, which sees every status page
+func (w Workspace) IsDefault() bool {
+	return w.ID == DefaultWorkspaceID
+}
+
+type NotificationChannelType string
+
+const (
+	NotificationChannelTypeSlack   NotificationChannelType = "slack"
+	NotificationChannelTypeTwitter NotificationChannelType = "twitter"
+)
+
+func ParseNotificationChannelType(channelType string) (NotificationChannelType, error) {
+	switch channelType {
+	case "slack":
+		return NotificationChannelTypeSlack, nil
+	case "twitter":
+		return NotificationChannelTypeTwitter, nil
+	default:
+		return "", fmt.Errorf("invalid notification channel type %q", channelType)
+	}
+}
+
+// NotificationChannel is where a workspace is notified of the changes of the incidents of its status pages
+type NotificationChannel struct {
+	ID          int64                   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	WorkspaceID int64                   `gorm:"column:workspace_id" json:"-"`
+	Name        string                  `gorm:"column:name" json:"name"`
+	Type        NotificationChannelType `gorm:"column:type" json:"type"`
+	// WebhookUrl is empty for the slack channels notified with a bot token
+	WebhookUrl string `gorm:"column:webhook_url" json:"webhookUrl"`
+	// SlackBotToken posts to the SlackChannel with the slack web api, which threads the updates of an incident
+	// The token is never returned
+	SlackBotToken string `gorm:"column:slack_bot_token" json:"-"`
+	SlackChannel  string `gorm:"column:slack_channel" json:"slackChannel,omitempty"`
+	// Events are the types of incident changes the channel is notified of
+	Events    IncidentChangeEventTypeArray `gorm:"column:events;type:jsonb" json:"events"`
+	CreatedAt time.Time                    `gorm:"column:created_at" json:"createdAt"`
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const apiKeysTableName = "api_keys"
+
+// CreateApiKey stores the api key and sets its id
+func (d *DbClient) CreateApiKey(ctx context.Context, apiKey *api.ApiKey) error {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).Create(apiKey)
+	return result.Error
+}
+
+// GetApiKeyByHash returns the api key with the given hash, or nil if it does not exist or was revoked
+func (d *DbClient) GetApiKeyByHash(ctx context.Context, keyHash string) (*api.ApiKey, error) {
+	var apiKey api.ApiKey
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).
+		Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&apiKey)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &apiKey, nil
+}
+
+// ListApiKeys returns the api keys of the workspace, including the revoked ones, oldest first
+func (d *DbClient) ListApiKeys(ctx context.Context, workspaceID int64) ([]api.ApiKey, error) {
+	var apiKeys []api.ApiKey
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).
+		Where("workspace_id = ?", workspaceID).Order("id").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return apiKeys, nil
+}
+
+// RevokeApiKey revokes the api key of the workspace, it returns false if it does not exist or was already revoked
+func (d *DbClient) RevokeApiKey(ctx context.Context, workspaceID int64, id int64) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, apiKeysTableName)).
+		Where("workspace_id = ? AND id = ? AND revoked_at IS NULL", workspaceID, id).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// statusPageChangesChannel is the postgres notification channel the status_page triggers publish on
+const statusPageChangesChannel = "statusphere_status_page_changes"
+
+type StatusPageOperation string
+
+const (
+	StatusPageInserted StatusPageOperation = "INSERT"
+	StatusPageUpdated  StatusPageOperation = "UPDATE"
+	StatusPageDeleted  StatusPageOperation = "DELETE"
+	// StatusPageTruncated means every status page was deleted, the url is empty
+	StatusPageTruncated StatusPageOperation = "TRUNCATE"
+)
+
+// StatusPageChange is published by the database whenever a status page is written
+type StatusPageChange struct {
+	Operation StatusPageOperation `json:"operation"`
+	Url       string              `json:"url"`
+}
+
+// ChangeHandlers are called by ListenChanges, every handler is optional
+type ChangeHandlers struct {
+	// OnListening is called every time listening starts, changes published before it may have been missed
+	OnListening        func()
+	OnIncidentChange   func(IncidentChange)
+	OnStatusPageChange func(StatusPageChange)
+}
+
+// ListenChanges calls the handlers for every incident and status page change published by any statusphere process
+// It holds a connection of the pool until the context is cancelled or the connection fails, in which case it returns the error
+// The handlers are called one at a time, changes published while nobody is listening are lost
+func (d *DbClient) ListenChanges(ctx context.Context, handlers ChangeHandlers) error {
+	poolConn, err := d.PgxPool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	// The connection is listening, so it must not go back to the pool
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	for _, channel := range []string{incidentChangesChannel, statusPageChangesChannel} {
+		_, err = conn.Exec(ctx, fmt.Sprintf("LISTEN %s", pgx.Identifier{channel}.Sanitize()))
+		if err != nil {
+			return errors.Wrapf(err, "failed to listen on %s", channel)
+		}
+	}
+	if handlers.OnListening != nil {
+		handlers.OnListening()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for changes")
+		}
+		switch notification.Channel {
+		case incidentChangesChannel:
+			var change IncidentChange
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+				d.logger.Warn("ignoring invalid incident change notification", zap.Error(err))
+				continue
+			}
+			if handlers.OnIncidentChange != nil {
+				handlers.OnIncidentChange(change)
+			}
+		case statusPageChangesChannel:
+			var change StatusPageChange
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+				d.logger.Warn("ignoring invalid status page change notification", zap.Error(err))
+				continue
+			}
+			if handlers.OnStatusPageChange != nil {
+				handlers.OnStatusPageChange(change)
+			}
+		}
+	}
+}
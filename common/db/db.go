@@ -7,11 +7,11 @@ import (
 	"os"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/metoro-io/statusphere/common/api"
-	"github.com/metoro-io/statusphere/common/status_pages"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
@@ -48,33 +48,8 @@ func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
 		return nil, err
 	}
 
-	// Check to see if the database exists in postgres
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.Database)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to postgres")
-	}
-	// Create the database if it does not exist
-	err = db.Exec(fmt.Sprintf("CREATE DATABASE %s", config.Database)).Error
-	wasCreatedSuccessfully := false
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "42P04" {
-				// This is the code for database already exists
-				// We can ignore this error
-				wasCreatedSuccessfully = true
-			}
-		}
-		if !wasCreatedSuccessfully {
-			return nil, errors.Wrap(err, "failed to create postgres database")
-		}
-	}
-
 	// Connect to the database
-	dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.Database)
+	dsn := getDsn(config, config.Database)
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
 		logger.Config{
@@ -85,7 +60,7 @@ func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
 			Colorful:                  false,         // Disable color
 		},
 	)
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: newLogger,
 	})
 	if err != nil {
@@ -100,33 +75,51 @@ func NewDbClientFromEnvironment(lg *zap.Logger) (*DbClient, error) {
 	return &DbClient{db: db, logger: lg, PgxPool: pgxPool}, nil
 }
 
-const statusPageTableName = "status_page"
-const incidentsTableName = "incidents"
+// Ping checks that the database can be reached
+func (d *DbClient) Ping(ctx context.Context) error {
+	sqlDb, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDb.PingContext(ctx)
+}
 
-func (d *DbClient) AutoMigrate(ctx context.Context) error {
-	d.logger.Info("DbClient.AutoMigrate()")
-	// Create the schema if it does not exist
-	d.db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schemaName))
+func getDsn(config Config, database string) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		config.Host, config.Port, config.User, config.Password, database)
+}
 
-	// Create the statuspage table
-	err := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).AutoMigrate(&api.StatusPage{})
+// CreateDatabaseFromEnvironmentIfNotExists creates the configured database if it does not exist yet
+// It connects to the postgres maintenance database to do so, so it should only be called by the migrate command
+func CreateDatabaseFromEnvironmentIfNotExists(ctx context.Context) error {
+	config, err := getConfigFromEnvironment()
 	if err != nil {
-		return errors.Wrap(err, "failed to auto-migrate status_page table")
+		return err
 	}
-	err = d.SeedStatusPages()
+
+	conn, err := pgx.Connect(ctx, getDsn(config, "postgres"))
 	if err != nil {
-		return errors.Wrap(err, "failed to seed status pages")
+		return errors.Wrap(err, "failed to connect to postgres")
 	}
+	defer conn.Close(ctx)
 
-	// Create the incidents table
-	err = d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, incidentsTableName))).AutoMigrate(&api.Incident{})
+	_, err = conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", pgx.Identifier{config.Database}.Sanitize()))
 	if err != nil {
-		return errors.Wrap(err, "failed to auto-migrate incidents table")
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "42P04" {
+			// This is the code for database already exists
+			// We can ignore this error
+			return nil
+		}
+		return errors.Wrap(err, "failed to create postgres database")
 	}
-
 	return nil
 }
 
+const statusPageTableName = "status_page"
+const incidentsTableName = "incidents"
+const incidentRevisionsTableName = "incident_revisions"
+
 func (d *DbClient) GetAllStatusPages(ctx context.Context) ([]api.StatusPage, error) {
 	var statusPages []api.StatusPage
 	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).Find(&statusPages)
@@ -156,7 +149,36 @@ func (d *DbClient) UpdateStatusPage(ctx context.Context, statusPage api.StatusPa
 	return nil
 }
 
+// UpdateStatusPageScraped records a current scrape of the status page, a successful scrape also indexes it
+// Only the scraping state is written, the definition may have been edited since the status page was read
+// It returns false if the status page does not exist
+func (d *DbClient) UpdateStatusPageScraped(ctx context.Context, url string, scrapedAt time.Time, scraped bool) (bool, error) {
+	updates := map[string]interface{}{"last_currently_scraped": scrapedAt}
+	if scraped {
+		updates["last_successfully_scraped"] = scrapedAt
+		updates["is_indexed"] = true
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", url).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateStatusPageHistoricallyScraped records a historical scrape of the status page, it returns false if it does not exist
+func (d *DbClient) UpdateStatusPageHistoricallyScraped(ctx context.Context, url string, scrapedAt time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Where("url = ?", url).
+		Update("last_historically_scraped", scrapedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (d *DbClient) InsertStatusPage(ctx context.Context, statusPage api.StatusPage) error {
+	if statusPage.Source == "" {
+		statusPage.Source = api.StatusPageSourceCode
+	}
 	result := d.db.Table(fmt.Sprintf(fmt.Sprintf("%s.%s", schemaName, statusPageTableName))).Create(&statusPage)
 	if result.Error != nil {
 		return result.Error
@@ -199,29 +221,23 @@ func (d *DbClient) GetCurrentIncidents(ctx context.Context, statusPageUrl string
 	return incidents, nil
 }
 
-func (d *DbClient) GetIncidentsWithoutJobsStarted(ctx context.Context, limit int) ([]api.Incident, error) {
+// ListCurrentIncidents returns the current incidents of each of the status pages, see GetCurrentIncidents
+// The status pages without current incidents are missing from the map
+func (d *DbClient) ListCurrentIncidents(ctx context.Context, statusPageUrls []string) (map[string][]api.Incident, error) {
 	var incidents []api.Incident
-	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("notification_jobs_started is distinct from true limit ?", limit).Find(&incidents)
+	now := time.Now()
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Where("status_page_url IN ? AND start_time <= ? AND (end_time IS NULL OR end_time > ?)", statusPageUrls, now, now).
+		Find(&incidents)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return incidents, nil
-}
 
-func (d *DbClient) SetIncidentNotificationStartedToTrue(ctx context.Context, incidents []api.Incident) error {
-	for i, _ := range incidents {
-		incidents[i].NotificationJobsStarted = true
+	byStatusPage := make(map[string][]api.Incident)
+	for _, incident := range incidents {
+		byStatusPage[incident.StatusPageUrl] = append(byStatusPage[incident.StatusPageUrl], incident)
 	}
-	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Clauses(
-		clause.OnConflict{
-			Columns:   []clause.Column{{Name: "deep_link"}},                            // Primary key
-			DoUpdates: clause.AssignmentColumns([]string{"notification_jobs_started"}), // Update the data column
-		},
-	).Create(&incidents)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+	return byStatusPage, nil
 }
 
 // specificky scraper ktery neparsuje status page s incidenty ale overuje dostupnost adresy / API
@@ -244,61 +260,319 @@ func (s *DbClient) ProcessAndCloseOngoingIncident(ctx context.Context, incidents
 		return nil
 	}
 
-	// 1. Najdi probíhající incidenty (kde end_time == NULL) pro daný scraper
-	var ongoingIncidents []api.Incident
-	result := s.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
-		Where("scraper = ? AND status_page_url = ? AND end_time IS NULL", scraper, url).Find(&ongoingIncidents)
-
-	if result.Error != nil {
-		return result.Error
-	}
+	// Uzavri probihajici incidenty (kde end_time == NULL) pro dany scraper
+	// Identita incidentu (id, external_id) se nemeni, dalsi vypadek dostane nove external_id
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ongoingIncidents []api.Incident
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+			Where("provider = ? AND status_page_url = ? AND end_time IS NULL", scraper, url).Find(&ongoingIncidents)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	now := time.Now()
-	// Formátovaný čas bez prázdných řetězců
-	formattedTime := now.Format("2006-01-02-15-04-05")
-
-	// 2. Pokud nějaké probíhající incidenty existují uzavři je:
-	// - nastav end_time na aktuální čas
-	// - uprav deep_link ktery se pouziva jako identifikator incidentu
-	if len(ongoingIncidents) > 0 {
-
-		result = s.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
-			Where("scraper = ? AND status_page_url = ? AND end_time IS NULL", scraper, url).
-			Updates(map[string]interface{}{
-				"end_time":  now,
-				"deep_link": gorm.Expr("CONCAT(deep_link::text, ?::text)", "_"+formattedTime),
+		now := time.Now()
+		var revisions []api.IncidentRevision
+		var incidentChanges []IncidentChange
+		var closedIncidents []api.Incident
+		var changeEvents [][]api.IncidentChangeEvent
+		for _, ongoing := range ongoingIncidents {
+			closed := ongoing
+			closed.EndTime = &now
+			closed.State = api.IncidentStateResolved
+			result = tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("id = ?", ongoing.ID).
+				Updates(map[string]interface{}{"end_time": now, "state": closed.State})
+			if result.Error != nil {
+				return result.Error
+			}
+			revisions = append(revisions, api.IncidentRevision{
+				IncidentID: ongoing.ID,
+				ObservedAt: now,
+				State:      closed.State,
+				Changes:    api.DiffIncidents(&ongoing, closed),
 			})
+			incidentChanges = append(incidentChanges, IncidentChange{
+				Type:          getIncidentChangeType(&ongoing, closed),
+				IncidentID:    ongoing.ID,
+				StatusPageUrl: ongoing.StatusPageUrl,
+				Impact:        ongoing.Impact,
+				ObservedAt:    now,
+			})
+			closedIncidents = append(closedIncidents, closed)
+			changeEvents = append(changeEvents, api.GetIncidentChangeEvents(&ongoing, closed, now))
+		}
+		if err := createIncidentRevisions(tx, revisions); err != nil {
+			return err
+		}
+		if err := createIncidentChangeEvents(tx, closedIncidents, changeEvents); err != nil {
+			return err
+		}
+		return notifyIncidentChanges(tx, incidentChanges)
+	})
+}
 
+// resolveAvailabilityExternalIds assigns the external ids of incidents produced by api availability scrapers
+// These scrapers only know which check failed (e.g. status_code), so every outage of that check gets its own external id
+// made of the check and the start of the outage. While the outage is ongoing, the incoming incident is matched to it
+// so that the start time is kept and no duplicate is created.
+func (d *DbClient) resolveAvailabilityExternalIds(ctx context.Context, incidents []api.Incident, scraper string, url string) error {
+	for i := range incidents {
+		check := incidents[i].ExternalID
+		var ongoing []api.Incident
+		result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+			Where(`provider = ? AND status_page_url = ? AND end_time IS NULL AND external_id LIKE ? ESCAPE '\'`, scraper, url, escapeLike(check)+"@%").
+			Order("start_time desc").Limit(1).Find(&ongoing)
 		if result.Error != nil {
 			return result.Error
 		}
+		if len(ongoing) > 0 {
+			incidents[i].ExternalID = ongoing[0].ExternalID
+			incidents[i].StartTime = ongoing[0].StartTime
+			continue
+		}
+		incidents[i].ExternalID = fmt.Sprintf("%s@%s", check, incidents[i].StartTime.UTC().Format(time.RFC3339))
 	}
+	return nil
+}
 
+// resolveLegacyExternalIds keeps the deep link as the external id of the incidents stored under it
+// The incidents stored before they were identified by the id the provider gives them got their deep link as external id,
+// unless it could be derived from it (see the 0002_incident_identity migration), e.g. the incidents of RSS feeds whose
+// guids differ from their links. They would otherwise be stored again under their new external id.
+func (d *DbClient) resolveLegacyExternalIds(ctx context.Context, incidents []api.Incident, scraper string, url string) error {
+	var candidates []string
+	for _, incident := range incidents {
+		if incident.DeepLink != "" && incident.DeepLink != incident.ExternalID {
+			candidates = append(candidates, incident.DeepLink, incident.ExternalID)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	var stored []string
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Where("provider = ? AND status_page_url = ? AND external_id IN ?", scraper, url, candidates).
+		Pluck("external_id", &stored)
+	if result.Error != nil {
+		return result.Error
+	}
+	storedExternalIds := make(map[string]bool, len(stored))
+	for _, externalID := range stored {
+		storedExternalIds[externalID] = true
+	}
+	for i := range incidents {
+		if storedExternalIds[incidents[i].DeepLink] && !storedExternalIds[incidents[i].ExternalID] {
+			incidents[i].ExternalID = incidents[i].DeepLink
+		}
+	}
 	return nil
 }
 
+type incidentIdentity struct {
+	statusPageUrl string
+	provider      string
+	externalID    string
+}
+
+func getIncidentIdentity(incident api.Incident) incidentIdentity {
+	return incidentIdentity{incident.StatusPageUrl, incident.Provider, incident.ExternalID}
+}
+
+// dedupeIncidents removes incidents with the same identity, keeping the last one
+// Postgres refuses to update the same row twice in a single upsert
+func dedupeIncidents(incidents []api.Incident) []api.Incident {
+	positions := make(map[incidentIdentity]int)
+	deduped := make([]api.Incident, 0, len(incidents))
+	for _, incident := range incidents {
+		key := getIncidentIdentity(incident)
+		if position, ok := positions[key]; ok {
+			deduped[position] = incident
+			continue
+		}
+		positions[key] = len(deduped)
+		deduped = append(deduped, incident)
+	}
+	return deduped
+}
+
+// CreateOrUpdateIncidents stores the scraped incidents
+// Only new incidents and incidents that changed since they were last stored are written, every write is recorded as a revision
+// along with the change events that notification channels are notified of
 func (d *DbClient) CreateOrUpdateIncidents(ctx context.Context, incidents []api.Incident, scraper string, url string) error {
 	if len(incidents) == 0 && IsApiAvailabilityScraper(scraper) {
 		return d.ProcessAndCloseOngoingIncident(ctx, incidents, scraper, url)
+	}
+	if len(incidents) == 0 {
+		return nil
+	}
+
+	for i := range incidents {
+		if incidents[i].Provider == "" {
+			incidents[i].Provider = scraper
+		}
+		if incidents[i].ExternalID == "" {
+			// Providers should always set the external id, the deep link is the best identity we have otherwise
+			incidents[i].ExternalID = incidents[i].DeepLink
+		}
+	}
+	if IsApiAvailabilityScraper(scraper) {
+		err := d.resolveAvailabilityExternalIds(ctx, incidents, scraper, url)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve external ids")
+		}
 	} else {
-		// originalni implementace
-		result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Clauses(
+		err := d.resolveLegacyExternalIds(ctx, incidents, scraper, url)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve legacy external ids")
+		}
+	}
+	incidents = dedupeIncidents(incidents)
+
+	now := time.Now()
+	for i := range incidents {
+		incidents[i].ID = 0
+		incidents[i].State = api.DeriveIncidentState(incidents[i], now)
+	}
+
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		previousIncidents, err := getIncidentsByIdentity(tx, incidents)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the stored incidents")
+		}
+
+		var changedIncidents []api.Incident
+		var changes []api.IncidentFieldChanges
+		var changeTypes []IncidentChangeType
+		var changeEvents [][]api.IncidentChangeEvent
+		for _, incident := range incidents {
+			var previous *api.Incident
+			if stored, ok := previousIncidents[getIncidentIdentity(incident)]; ok {
+				previous = &stored
+			}
+			diff := api.DiffIncidents(previous, incident)
+			if previous != nil && len(diff) == 0 {
+				continue
+			}
+			changedIncidents = append(changedIncidents, incident)
+			changes = append(changes, diff)
+			changeTypes = append(changeTypes, getIncidentChangeType(previous, incident))
+			changeEvents = append(changeEvents, api.GetIncidentChangeEvents(previous, incident, now))
+		}
+		if len(changedIncidents) == 0 {
+			return nil
+		}
+
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Clauses(
 			clause.OnConflict{
-				Columns:   []clause.Column{{Name: "deep_link"}},                                                                                                                 // Primary key
-				DoUpdates: clause.AssignmentColumns([]string{"title", "components", "events", "start_time", "end_time", "description", "impact", "status_page_url", "scraper"}), // Update the data column
+				Columns:   []clause.Column{{Name: "status_page_url"}, {Name: "provider"}, {Name: "external_id"}},                                                        // Incident identity
+				DoUpdates: clause.AssignmentColumns([]string{"title", "components", "events", "start_time", "end_time", "description", "impact", "deep_link", "state"}), // Update the data column
 			},
-		).Create(&incidents)
+		).Create(&changedIncidents)
 		if result.Error != nil {
 			return result.Error
 		}
+
+		revisions := make([]api.IncidentRevision, 0, len(changedIncidents))
+		incidentChanges := make([]IncidentChange, 0, len(changedIncidents))
+		for i, incident := range changedIncidents {
+			revisions = append(revisions, api.IncidentRevision{
+				IncidentID: incident.ID,
+				ObservedAt: now,
+				State:      incident.State,
+				Changes:    changes[i],
+			})
+			incidentChanges = append(incidentChanges, IncidentChange{
+				Type:          changeTypes[i],
+				IncidentID:    incident.ID,
+				StatusPageUrl: incident.StatusPageUrl,
+				Impact:        incident.Impact,
+				ObservedAt:    now,
+			})
+		}
+		if err := createIncidentRevisions(tx, revisions); err != nil {
+			return err
+		}
+		if err := createIncidentChangeEvents(tx, changedIncidents, changeEvents); err != nil {
+			return err
+		}
+		return notifyIncidentChanges(tx, incidentChanges)
+	})
+}
+
+// getIncidentsByIdentity returns the stored versions of the given incidents, keyed by their identity
+func getIncidentsByIdentity(tx *gorm.DB, incidents []api.Incident) (map[incidentIdentity]api.Incident, error) {
+	const batchSize = 500
+	stored := make(map[incidentIdentity]api.Incident)
+	for start := 0; start < len(incidents); start += batchSize {
+		end := start + batchSize
+		if end > len(incidents) {
+			end = len(incidents)
+		}
+		keys := make([][]interface{}, 0, end-start)
+		for _, incident := range incidents[start:end] {
+			keys = append(keys, []interface{}{incident.StatusPageUrl, incident.Provider, incident.ExternalID})
+		}
+		var existing []api.Incident
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("(status_page_url, provider, external_id) IN ?", keys).Find(&existing)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		for _, incident := range existing {
+			stored[getIncidentIdentity(incident)] = incident
+		}
+	}
+	return stored, nil
+}
+
+func createIncidentRevisions(tx *gorm.DB, revisions []api.IncidentRevision) error {
+	if len(revisions) == 0 {
 		return nil
 	}
+	result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentRevisionsTableName)).Create(&revisions)
+	return result.Error
+}
+
+// GetIncident returns the incident with the given id, or nil if it does not exist
+func (d *DbClient) GetIncident(ctx context.Context, id int64) (*api.Incident, error) {
+	var incident api.Incident
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("id = ?", id).First(&incident)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &incident, nil
+}
+
+// GetIncidentsByIDs returns the incidents with the given ids, keyed by id. The incidents that do not exist are missing.
+func (d *DbClient) GetIncidentsByIDs(ctx context.Context, ids []int64) (map[int64]api.Incident, error) {
+	var incidents []api.Incident
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).Where("id IN ?", ids).Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	byID := make(map[int64]api.Incident, len(incidents))
+	for _, incident := range incidents {
+		byID[incident.ID] = incident
+	}
+	return byID, nil
+}
+
+// GetIncidentRevisions returns the revisions of the incident with the given id, oldest first
+func (d *DbClient) GetIncidentRevisions(ctx context.Context, incidentId int64) ([]api.IncidentRevision, error) {
+	var revisions []api.IncidentRevision
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentRevisionsTableName)).Where("incident_id = ?", incidentId).Order("observed_at asc, id asc").Find(&revisions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return revisions, nil
 }
 
-func (d *DbClient) SeedStatusPages() error {
+// SeedStatusPages inserts the given status pages that do not exist yet, existing status pages are left as they are
+func (d *DbClient) SeedStatusPages(statusPages []api.StatusPage) error {
 	d.logger.Info("DbClient.SeedStatusPages()")
 
-	for _, statusPage := range status_pages.StatusPages {
+	for _, statusPage := range statusPages {
 		if page, err := d.GetStatusPage(context.Background(), statusPage.URL); err != nil || page == nil {
 			// Status page already exists
 			err := d.InsertStatusPage(context.Background(), statusPage)
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/metoro-io/statusphere/common/api"
+	"gorm.io/gorm"
+)
+
+const incidentChangeEventsTableName = "incident_change_events"
+
+// createIncidentChangeEvents stores the change events of the incidents, the events too old to be notified are left out
+func createIncidentChangeEvents(tx *gorm.DB, incidents []api.Incident, events [][]api.IncidentChangeEvent) error {
+	var notified []api.IncidentChangeEvent
+	for i, incident := range incidents {
+		for _, event := range events[i] {
+			if event.IsStale(incident) {
+				continue
+			}
+			event.IncidentID = incident.ID
+			notified = append(notified, event)
+		}
+	}
+	if len(notified) == 0 {
+		return nil
+	}
+	result := tx.Table(fmt.Sprintf("%s.%s", schemaName, incidentChangeEventsTableName)).Create(&notified)
+	return result.Error
+}
+
+// ListPendingIncidentChangeEvents returns the oldest change events whose notifications were not queued yet, in the
+// order they were stored
+func (d *DbClient) ListPendingIncidentChangeEvents(ctx context.Context, limit int) ([]api.IncidentChangeEvent, error) {
+	var events []api.IncidentChangeEvent
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentChangeEventsTableName)).
+		Where("notified_at IS NULL").Order("id").Limit(limit).Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// SetIncidentChangeEventsNotified records that the notifications of the change events were queued
+// It runs in the transaction the notification jobs are inserted in, so that the events are notified exactly once
+func (d *DbClient) SetIncidentChangeEventsNotified(ctx context.Context, tx pgx.Tx, ids []int64, notifiedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf("UPDATE %s.%s SET notified_at = $1 WHERE id = ANY($2)", schemaName, incidentChangeEventsTableName), notifiedAt, ids)
+	return err
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// incidentChangesChannel is the postgres notification channel incident changes are published on
+const incidentChangesChannel = "statusphere_incident_changes"
+
+type IncidentChangeType string
+
+const (
+	IncidentChangeCreated  IncidentChangeType = "created"
+	IncidentChangeUpdated  IncidentChangeType = "updated"
+	IncidentChangeResolved IncidentChangeType = "resolved"
+)
+
+// IncidentChange is published whenever an incident is written
+// It only identifies the incident as notification payloads are limited to 8000 bytes, listeners load the incident itself
+type IncidentChange struct {
+	Type          IncidentChangeType `json:"type"`
+	IncidentID    int64              `json:"incidentId"`
+	StatusPageUrl string             `json:"statusPageUrl"`
+	Impact        api.Impact         `json:"impact"`
+	ObservedAt    time.Time          `json:"observedAt"`
+}
+
+// getIncidentChangeType returns how the incident changed compared to its previously stored version, which is nil for new incidents
+func getIncidentChangeType(previous *api.Incident, current api.Incident) IncidentChangeType {
+	if previous == nil {
+		return IncidentChangeCreated
+	}
+	if current.State == api.IncidentStateResolved && previous.State != api.IncidentStateResolved {
+		return IncidentChangeResolved
+	}
+	return IncidentChangeUpdated
+}
+
+// notifyIncidentChanges publishes the changes as part of the transaction, so listeners only hear about committed writes
+func notifyIncidentChanges(tx *gorm.DB, changes []IncidentChange) error {
+	for _, change := range changes {
+		payload, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		result := tx.Exec("SELECT pg_notify(?, ?)", incidentChangesChannel, string(payload))
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to notify incident change")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"gorm.io/gorm"
+)
+
+// IncidentCursor points at the last incident of a page of incidents ordered by start time and id, newest first
+type IncidentCursor struct {
+	StartTime time.Time
+	ID        int64
+}
+
+// IncidentSource is a status page, or a single component of it, whose incidents are selected
+type IncidentSource struct {
+	StatusPageUrl string
+	// Component is empty to select every incident of the status page
+	Component string
+}
+
+// IncidentFilter selects incidents, every zero valued field is ignored
+type IncidentFilter struct {
+	StatusPageUrls []string
+	Impacts        []api.Impact
+	// Sources selects the incidents of any of the sources, on top of the other conditions
+	Sources []IncidentSource
+	// From and To select the incidents that overlap the window [From, To)
+	From *time.Time
+	To   *time.Time
+	// OpenEndedDuration is how long after their start From considers the incidents without an end to last,
+	// 0 means they last until they end
+	OpenEndedDuration time.Duration
+	// Ongoing selects the incidents that have started and not ended yet
+	Ongoing bool
+	// Component selects the incidents that affect the component
+	Component string
+	// Query selects the incidents whose title or description contains the text, case insensitive
+	Query  string
+	Cursor *IncidentCursor
+	// Limit is the maximum number of incidents returned, 0 means no limit
+	Limit int
+}
+
+// ListIncidents returns the incidents matching the filter, newest first
+func (d *DbClient) ListIncidents(ctx context.Context, filter IncidentFilter) ([]api.Incident, error) {
+	query, err := d.incidentFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []api.Incident
+	result := query.WithContext(ctx).Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// ListIncidentOutages returns the incidents matching the filter with only the fields the uptime and the stats summaries need:
+// the id, status page, impact and times. The updates, which the state of an incident without an end time is derived from,
+// are only read for those incidents, so that the incidents of every status page over a long period fit in memory
+func (d *DbClient) ListIncidentOutages(ctx context.Context, filter IncidentFilter) ([]api.Incident, error) {
+	query, err := d.incidentFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []api.Incident
+	result := query.WithContext(ctx).
+		Select("id, status_page_url, impact, start_time, end_time, CASE WHEN end_time IS NULL THEN events ELSE '[]'::jsonb END AS events").
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// StreamIncidents calls fn with each incident matching the filter, newest first
+// The rows are read from the database cursor one at a time rather than loaded in memory, so the filter may have no limit
+// It stops and returns the error of fn if fn fails
+func (d *DbClient) StreamIncidents(ctx context.Context, filter IncidentFilter, fn func(api.Incident) error) error {
+	query, err := d.incidentFilterQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.WithContext(ctx).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var incident api.Incident
+		if err := d.db.ScanRows(rows, &incident); err != nil {
+			return err
+		}
+		if err := fn(incident); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListIncidentsPerStatusPage returns up to limit incidents matching the filter for each of the status pages of the filter, newest first
+// The status pages without incidents are missing from the map, the limit of the filter is ignored
+func (d *DbClient) ListIncidentsPerStatusPage(ctx context.Context, filter IncidentFilter, limit int) (map[string][]api.Incident, error) {
+	filter.Limit = 0
+	query, err := d.incidentFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	ranked := query.Select("*, row_number() OVER (PARTITION BY status_page_url ORDER BY start_time desc, id desc) AS status_page_rank")
+
+	var incidents []api.Incident
+	result := d.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Where("status_page_rank <= ?", limit).
+		Order("start_time desc, id desc").
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	byStatusPage := make(map[string][]api.Incident)
+	for _, incident := range incidents {
+		byStatusPage[incident.StatusPageUrl] = append(byStatusPage[incident.StatusPageUrl], incident)
+	}
+	return byStatusPage, nil
+}
+
+func (d *DbClient) incidentFilterQuery(filter IncidentFilter) (*gorm.DB, error) {
+	query, err := applyIncidentFilter(d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)), filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Cursor != nil {
+		query = query.Where("(start_time, id) < (?, ?)", filter.Cursor.StartTime, filter.Cursor.ID)
+	}
+	query = query.Order("start_time desc, id desc")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	return query, nil
+}
+
+// applyIncidentFilter adds the conditions of the filter to the query, the cursor and the limit are left to the caller
+func applyIncidentFilter(query *gorm.DB, filter IncidentFilter) (*gorm.DB, error) {
+	if len(filter.StatusPageUrls) > 0 {
+		query = query.Where("status_page_url IN ?", filter.StatusPageUrls)
+	}
+	if len(filter.Sources) > 0 {
+		var conditions []string
+		var args []interface{}
+		for _, source := range filter.Sources {
+			if source.Component == "" {
+				conditions = append(conditions, "status_page_url = ?")
+				args = append(args, source.StatusPageUrl)
+				continue
+			}
+			component, err := json.Marshal([]string{source.Component})
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, "(status_page_url = ? AND components @> ?::jsonb)")
+			args = append(args, source.StatusPageUrl, string(component))
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	if len(filter.Impacts) > 0 {
+		query = query.Where("impact IN ?", filter.Impacts)
+	}
+	if filter.From != nil && filter.OpenEndedDuration > 0 {
+		query = query.Where("((end_time IS NULL AND start_time > ?) OR end_time > ?)", filter.From.Add(-filter.OpenEndedDuration), *filter.From)
+	} else if filter.From != nil {
+		query = query.Where("(end_time IS NULL OR end_time > ?)", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_time < ?", *filter.To)
+	}
+	if filter.Ongoing {
+		now := time.Now()
+		query = query.Where("start_time <= ? AND (end_time IS NULL OR end_time > ?)", now, now)
+	}
+	if filter.Component != "" {
+		component, err := json.Marshal([]string{filter.Component})
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("components @> ?::jsonb", string(component))
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("(title ILIKE ? OR description ILIKE ?)", pattern, pattern)
+	}
+	return query, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db/migrations"
+	"go.uber.org/zap"
+)
+
+// newTestDbClient returns a client of the database named by STATUSPHERE_TEST_POSTGRES_DATABASE, on the server of the
+// other STATUSPHERE_POSTGRES_ variables. The tests drop everything in it, the test is skipped if it is not set.
+func newTestDbClient(t *testing.T) *DbClient {
+	t.Helper()
+	database := os.Getenv("STATUSPHERE_TEST_POSTGRES_DATABASE")
+	if database == "" {
+		t.Skip("STATUSPHERE_TEST_POSTGRES_DATABASE is not set")
+	}
+	t.Setenv("STATUSPHERE_POSTGRES_DATABASE", database)
+	d, err := NewDbClientFromEnvironment(zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(d.PgxPool.Close)
+	return d
+}
+
+func TestLegacyExternalIdIsKept(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDbClient(t)
+	if _, err := d.MigrateDown(ctx, 1000); err != nil {
+		t.Fatalf("failed to reset the database: %v", err)
+	}
+
+	// An RSS incident stored before incidents had an external id, when they were identified by their deep link
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		if _, err := conn.Exec(ctx, all[0].Up); err != nil {
+			return err
+		}
+		_, err := conn.Exec(ctx, fmt.Sprintf("INSERT INTO %s.%s (version, name) VALUES ($1, $2)", schemaName, schemaMigrationsTableName), all[0].Version, all[0].Name)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to apply the initial schema: %v", err)
+	}
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	_, err = d.PgxPool.Exec(ctx, `INSERT INTO statusphere.incidents (title, components, events, start_time, end_time, deep_link, impact, status_page_url, scraper)
+VALUES ('Elevated errors', '[]', '[]', $1, $1, 'https://status.example.com/incidents/1', 'none', 'https://status.example.com', 'RSS')`, start)
+	if err != nil {
+		t.Fatalf("failed to store the legacy incident: %v", err)
+	}
+	if _, err := d.MigrateUp(ctx); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	// The feed gives the incident a guid that differs from its link
+	description := "Resolved"
+	incident := api.NewIncident("Elevated errors", nil, nil, start, &start, &description, "https://status.example.com/incidents/1",
+		"tag:status.example.com,2024:1", api.ImpactNone, "https://status.example.com", "RSS")
+	if err := d.CreateOrUpdateIncidents(ctx, []api.Incident{incident}, "RSS", "https://status.example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	incidents, err := d.GetIncidents(ctx, "https://status.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(incidents) != 1 || incidents[0].ExternalID != "https://status.example.com/incidents/1" || incidents[0].Description == nil || *incidents[0].Description != description {
+		t.Errorf("expected the legacy incident to be updated rather than stored again, got %+v", incidents)
+	}
+}
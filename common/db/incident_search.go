@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// searchConfiguration is the text search configuration used for incidents
+// Vendors write in several languages, so words are only lower-cased and not stemmed
+const searchConfiguration = "simple"
+
+// searchHeadlineDocument is the text the snippets of search results are taken from
+const searchHeadlineDocument = `concat_ws(' ', title, description,
+	array_to_string(ARRAY(SELECT jsonb_array_elements_text(jsonb_path_query_array(events, '$[*].description'))), ' '))`
+
+// The matches are delimited with private use characters, the snippet is html escaped before they are replaced with <mark> tags
+const searchHeadlineStartSel = "\uE000"
+const searchHeadlineStopSel = "\uE001"
+
+var searchHeadlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" ... \"", searchHeadlineStartSel, searchHeadlineStopSel)
+
+type IncidentSearchResult struct {
+	Incident api.Incident `gorm:"embedded" json:"incident"`
+	// Rank is the relevance of the incident to the search query, higher is more relevant
+	Rank float64 `gorm:"column:rank" json:"rank"`
+	// Snippet is the part of the incident that matches the search query as html, with the matches wrapped in <mark> tags
+	// The text of the incident is escaped
+	Snippet string `gorm:"column:snippet" json:"snippet"`
+}
+
+// SearchIncidents runs a full text search over the title, description and updates of incidents
+// The search query supports the web search syntax, e.g. "us-east-1" or kafka -maintenance
+// The status pages, impacts and time range of the filter are applied, the component, query, cursor and limit are ignored
+// Results are ordered by relevance, then newest first
+func (d *DbClient) SearchIncidents(ctx context.Context, searchQuery string, filter IncidentFilter, limit int, offset int) ([]IncidentSearchResult, error) {
+	filter.Component = ""
+	filter.Query = ""
+	query, err := applyIncidentFilter(
+		d.db.Table(fmt.Sprintf("%s.%s, websearch_to_tsquery('%s', ?) search_query", schemaName, incidentsTableName, searchConfiguration), searchQuery),
+		filter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []IncidentSearchResult
+	result := query.
+		Select(fmt.Sprintf("%s.*, ts_rank(search_vector, search_query) AS rank, ts_headline('%s', %s, search_query, '%s') AS snippet",
+			incidentsTableName, searchConfiguration, searchHeadlineDocument, searchHeadlineOptions)).
+		Where("search_vector @@ search_query").
+		Order("rank desc, start_time desc, id desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&results)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for i := range results {
+		results[i].Snippet = escapeSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// escapeSnippet escapes the text of the incident in the snippet, the vendors' markup is shown as text
+// Only the delimiters of the matches become <mark> tags
+func escapeSnippet(snippet string) string {
+	return strings.NewReplacer(searchHeadlineStartSel, "<mark>", searchHeadlineStopSel, "</mark>").Replace(html.EscapeString(snippet))
+}
@@ -0,0 +1,13 @@
+package db
+
+import "testing"
+
+func TestEscapeSnippet(t *testing.T) {
+	// As returned by ts_headline for the query "payments" over a description with markup
+	snippet := `Elevated errors ... <script>alert("x")</script> ` + searchHeadlineStartSel + `payments` + searchHeadlineStopSel + ` & <b>checkout</b>`
+
+	expected := `Elevated errors ... &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>payments</mark> &amp; &lt;b&gt;checkout&lt;/b&gt;`
+	if escaped := escapeSnippet(snippet); escaped != expected {
+		t.Errorf("expected %q, got %q", expected, escaped)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// GetLastIncidentStartTimes returns the start time of the latest incident of each status page that has started, maintenance excluded
+// Every status page is included if statusPageUrls is empty, the status pages without incidents are missing from the map
+func (d *DbClient) GetLastIncidentStartTimes(ctx context.Context, statusPageUrls []string) (map[string]time.Time, error) {
+	var rows []struct {
+		StatusPageUrl string
+		LastStartTime time.Time
+	}
+	query := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Select("status_page_url, max(start_time) AS last_start_time").
+		Where("impact <> ? AND start_time <= ?", api.ImpactMaintenance, time.Now())
+	if len(statusPageUrls) > 0 {
+		query = query.Where("status_page_url IN ?", statusPageUrls)
+	}
+	result := query.Group("status_page_url").Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	lastStartTimes := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		lastStartTimes[row.StatusPageUrl] = row.LastStartTime
+	}
+	return lastStartTimes, nil
+}
+
+// GetLastComponentIncidentStartTimes returns the start time of the latest incident of each component of each status page,
+// maintenance excluded, for the components that had an incident start since the given time
+// Every status page is included if statusPageUrls is empty
+func (d *DbClient) GetLastComponentIncidentStartTimes(ctx context.Context, statusPageUrls []string, since time.Time) (map[string]map[string]time.Time, error) {
+	var rows []struct {
+		StatusPageUrl string
+		Component     string
+		LastStartTime time.Time
+	}
+	// Incidents without components have a JSON null, which cannot be expanded
+	query := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s, jsonb_array_elements_text(CASE WHEN jsonb_typeof(components) = 'array' THEN components ELSE '[]'::jsonb END) AS component",
+		schemaName, incidentsTableName)).
+		Select("status_page_url, component, max(start_time) AS last_start_time").
+		Where("impact <> ? AND start_time > ? AND start_time <= ?", api.ImpactMaintenance, since, time.Now())
+	if len(statusPageUrls) > 0 {
+		query = query.Where("status_page_url IN ?", statusPageUrls)
+	}
+	result := query.Group("status_page_url, component").Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	lastStartTimes := make(map[string]map[string]time.Time)
+	for _, row := range rows {
+		if lastStartTimes[row.StatusPageUrl] == nil {
+			lastStartTimes[row.StatusPageUrl] = make(map[string]time.Time)
+		}
+		lastStartTimes[row.StatusPageUrl][row.Component] = row.LastStartTime
+	}
+	return lastStartTimes, nil
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/metoro-io/statusphere/common/db/migrations"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const schemaMigrationsTableName = "schema_migrations"
+
+// migrationLockKey is the key of the postgres advisory lock held while migrating
+// so that two concurrent migrate runs never apply the same version twice
+const migrationLockKey = 7_346_201_115
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads all the migrations from the given filesystem and returns them ordered by version
+// Every version must have both an up and a down file, and the versions must be 1, 2, 3 and so on
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migrations directory")
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		matches := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, errors.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid migration version: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read migration %s", entry.Name())
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, errors.Errorf("migration version %d has conflicting names %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var result []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, errors.Errorf("migration %d_%s must have both an up and a down file", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	for i, migration := range result {
+		if migration.Version != i+1 {
+			return nil, errors.Errorf("migration versions must start at 1 and have no gaps, expected version %d, got %d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+	return result, nil
+}
+
+// MigrateUp applies every migration that has not been applied yet, in order
+// It returns the migrations that were applied
+func (d *DbClient) MigrateUp(ctx context.Context) ([]Migration, error) {
+	d.logger.Info("DbClient.MigrateUp()")
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		appliedVersions, err := getAppliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkAppliedVersions(all, appliedVersions); err != nil {
+			return err
+		}
+		for _, migration := range all {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+			d.logger.Info("applying migration", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s.%s (version, name) VALUES ($1, $2)", schemaName, schemaMigrationsTableName), migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply migration %d_%s", migration.Version, migration.Name)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown reverts the given number of most recently applied migrations
+// It returns the migrations that were reverted
+func (d *DbClient) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	d.logger.Info("DbClient.MigrateDown()", zap.Int("steps", steps))
+	if steps <= 0 {
+		return nil, errors.New("steps must be greater than zero")
+	}
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		appliedVersions, err := getAppliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(all) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := all[i]
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+			d.logger.Info("reverting migration", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s.%s WHERE version = $1", schemaName, schemaMigrationsTableName), migration.Version)
+				return err
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to revert migration %d_%s", migration.Version, migration.Name)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatuses returns every known migration along with the time it was applied, if it was
+func (d *DbClient) MigrationStatuses(ctx context.Context) ([]MigrationStatus, error) {
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		appliedVersions, err := getAppliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range all {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := appliedVersions[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withMigrationLock runs f on a dedicated connection while holding the migration advisory lock
+// It also makes sure that the schema and the schema_migrations table exist
+func (d *DbClient) withMigrationLock(ctx context.Context, f func(conn *pgx.Conn) error) error {
+	conn, err := d.PgxPool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return errors.Wrap(err, "failed to acquire migration lock")
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}()
+
+	_, err = conn.Exec(ctx, fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s;
+CREATE TABLE IF NOT EXISTS %s.%s (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`, schemaName, schemaName, schemaMigrationsTableName))
+	if err != nil {
+		return errors.Wrap(err, "failed to create schema_migrations table")
+	}
+
+	return f(conn.Conn())
+}
+
+// checkAppliedVersions returns an error if the applied migrations are not the first ones of all the migrations
+// The pending migrations are then applied after the last applied one, so a migration added with an older version than
+// an applied one, or a database migrated by a newer build, is refused rather than migrated out of order
+func checkAppliedVersions(all []Migration, appliedVersions map[int]time.Time) error {
+	known := make(map[int]bool, len(all))
+	for _, migration := range all {
+		known[migration.Version] = true
+	}
+	for version := range appliedVersions {
+		if !known[version] {
+			return errors.Errorf("migration %d is applied but unknown to this build, the database was migrated by a newer one", version)
+		}
+	}
+	var pending *Migration
+	for i, migration := range all {
+		_, applied := appliedVersions[migration.Version]
+		if !applied && pending == nil {
+			pending = &all[i]
+		}
+		if applied && pending != nil {
+			return errors.Errorf("migration %d_%s is not applied but the later migration %d_%s is", pending.Version, pending.Name, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func getAppliedVersions(ctx context.Context, conn *pgx.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s.%s", schemaName, schemaMigrationsTableName))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query applied migrations")
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan applied migration")
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
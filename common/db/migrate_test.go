@@ -0,0 +1,102 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/db/migrations"
+)
+
+func TestLoadMigrationsOrdersAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_column.up.sql":       {Data: []byte("ALTER TABLE a ADD COLUMN b text;")},
+		"0002_add_column.down.sql":     {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+		"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE a;")},
+		"migrations.go":                {Data: []byte("package migrations")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(loaded))
+	}
+	if loaded[0].Version != 1 || loaded[0].Name != "initial_schema" || loaded[0].Down != "DROP TABLE a;" {
+		t.Errorf("unexpected first migration: %+v", loaded[0])
+	}
+	if loaded[1].Version != 2 || loaded[1].Up != "ALTER TABLE a ADD COLUMN b text;" {
+		t.Errorf("unexpected second migration: %+v", loaded[1])
+	}
+}
+
+func TestLoadMigrationsRejectsMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_initial_schema.up.sql": {Data: []byte("CREATE TABLE a ();")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("expected an error for a migration without a down file")
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidNames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"initial.sql": {Data: []byte("CREATE TABLE a ();")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("expected an error for an invalid migration file name")
+	}
+}
+
+func TestLoadMigrationsRejectsGaps(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE a;")},
+		"0003_add_column.up.sql":       {Data: []byte("ALTER TABLE a ADD COLUMN b text;")},
+		"0003_add_column.down.sql":     {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("expected an error for a gap in the migration versions")
+	}
+}
+
+func TestCheckAppliedVersions(t *testing.T) {
+	all := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+	appliedAt := time.Now()
+	tests := []struct {
+		name    string
+		applied []int
+		valid   bool
+	}{
+		{name: "new database", applied: nil, valid: true},
+		{name: "pending migrations after the applied ones", applied: []int{1, 2}, valid: true},
+		{name: "up to date", applied: []int{1, 2, 3}, valid: true},
+		{name: "migration added before an applied one", applied: []int{1, 3}, valid: false},
+		{name: "migrated by a newer build", applied: []int{1, 2, 3, 4}, valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appliedVersions := make(map[int]time.Time)
+			for _, version := range test.applied {
+				appliedVersions[version] = appliedAt
+			}
+			if err := checkAppliedVersions(all, appliedVersions); (err == nil) != test.valid {
+				t.Errorf("expected valid to be %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsAreValid(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("embedded migrations are invalid: %v", err)
+	}
+	for i, migration := range loaded {
+		if migration.Version != i+1 {
+			t.Errorf("expected migration versions to be contiguous, got %d at position %d", migration.Version, i)
+		}
+	}
+}
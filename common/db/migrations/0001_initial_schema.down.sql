@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS statusphere.incidents;
+DROP TABLE IF EXISTS statusphere.status_page;
@@ -0,0 +1,34 @@
+-- Baseline schema, equivalent to what GORM AutoMigrate used to create.
+-- IF NOT EXISTS lets deployments that were created by AutoMigrate adopt versioned migrations.
+CREATE SCHEMA IF NOT EXISTS statusphere;
+
+CREATE TABLE IF NOT EXISTS statusphere.status_page
+(
+    name                      text,
+    url                       text NOT NULL,
+    last_historically_scraped timestamptz,
+    last_currently_scraped    timestamptz,
+    is_indexed                boolean,
+    preferred_scraper         text,
+    headers                   jsonb,
+    request_payload           jsonb,
+    method                    text,
+    validation_rules          jsonb,
+    PRIMARY KEY (url)
+);
+
+CREATE TABLE IF NOT EXISTS statusphere.incidents
+(
+    title                     text,
+    components                jsonb,
+    events                    jsonb,
+    start_time                timestamptz,
+    end_time                  timestamptz,
+    description               text,
+    deep_link                 text NOT NULL,
+    impact                    text,
+    status_page_url           text,
+    notification_jobs_started boolean,
+    scraper                   text,
+    PRIMARY KEY (deep_link)
+);
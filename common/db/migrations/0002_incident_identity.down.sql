@@ -0,0 +1,13 @@
+DROP INDEX statusphere.incidents_identity_key;
+
+-- Deep links are no longer unique, e.g. every outage of a REST check shares one
+UPDATE statusphere.incidents a
+SET deep_link = a.deep_link || '/' || a.external_id
+WHERE EXISTS (SELECT 1 FROM statusphere.incidents b WHERE b.deep_link = a.deep_link AND b.id <> a.id);
+
+ALTER TABLE statusphere.incidents DROP COLUMN id;
+ALTER TABLE statusphere.incidents ADD PRIMARY KEY (deep_link);
+ALTER TABLE statusphere.incidents DROP COLUMN external_id;
+ALTER TABLE statusphere.incidents ALTER COLUMN status_page_url DROP NOT NULL;
+ALTER TABLE statusphere.incidents ALTER COLUMN provider DROP NOT NULL;
+ALTER TABLE statusphere.incidents RENAME COLUMN provider TO scraper;
@@ -0,0 +1,43 @@
+-- Incidents used to be identified by their deep link.
+-- They now get a surrogate id and are identified by the id the provider gives them, per status page and provider.
+ALTER TABLE statusphere.incidents RENAME COLUMN scraper TO provider;
+UPDATE statusphere.incidents SET provider = '' WHERE provider IS NULL;
+DELETE FROM statusphere.incidents WHERE status_page_url IS NULL;
+
+ALTER TABLE statusphere.incidents ADD COLUMN external_id text;
+UPDATE statusphere.incidents
+SET external_id = CASE
+    -- https://status.example.com/incidents/<code>
+    WHEN provider = 'Atlassian' AND deep_link LIKE '%/incidents/%'
+        THEN regexp_replace(deep_link, '^.*/incidents/', '')
+    -- <channel link> <guid>
+    WHEN provider = 'CKP_RSS' AND deep_link LIKE '% %'
+        THEN regexp_replace(deep_link, '^.* ', '')
+    -- <url>/<check> or <url>/<check>_<closed at> for closed outages
+    WHEN provider = 'REST'
+        THEN regexp_replace(regexp_replace(deep_link, '^.*/', ''), '_\d{4}(-\d{2}){5}$', '') || '@' ||
+             to_char(start_time AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+    ELSE deep_link
+    END;
+UPDATE statusphere.incidents
+SET deep_link = CASE
+    WHEN provider = 'CKP_RSS' AND deep_link LIKE '% %' THEN regexp_replace(deep_link, ' .*$', '')
+    WHEN provider = 'REST' THEN status_page_url
+    ELSE deep_link
+    END;
+
+-- The same incident could have been stored under several deep links, keep only one of them
+DELETE
+FROM statusphere.incidents a
+    USING statusphere.incidents b
+WHERE a.status_page_url = b.status_page_url
+  AND a.provider = b.provider
+  AND a.external_id = b.external_id
+  AND a.ctid < b.ctid;
+
+ALTER TABLE statusphere.incidents ALTER COLUMN external_id SET NOT NULL;
+ALTER TABLE statusphere.incidents ALTER COLUMN status_page_url SET NOT NULL;
+ALTER TABLE statusphere.incidents ALTER COLUMN provider SET NOT NULL;
+ALTER TABLE statusphere.incidents DROP CONSTRAINT incidents_pkey;
+ALTER TABLE statusphere.incidents ADD COLUMN id bigserial PRIMARY KEY;
+CREATE UNIQUE INDEX incidents_identity_key ON statusphere.incidents (status_page_url, provider, external_id);
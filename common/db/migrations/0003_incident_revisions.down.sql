@@ -0,0 +1,2 @@
+DROP TABLE statusphere.incident_revisions;
+ALTER TABLE statusphere.incidents DROP COLUMN state;
@@ -0,0 +1,14 @@
+ALTER TABLE statusphere.incidents ADD COLUMN state text;
+UPDATE statusphere.incidents
+SET state = CASE WHEN end_time IS NOT NULL AND end_time <= now() THEN 'resolved' ELSE 'investigating' END;
+
+-- Every observed change of an incident, the history of incidents stored before this migration starts here
+CREATE TABLE statusphere.incident_revisions
+(
+    id          bigserial PRIMARY KEY,
+    incident_id bigint      NOT NULL REFERENCES statusphere.incidents (id) ON DELETE CASCADE,
+    observed_at timestamptz NOT NULL,
+    state       text        NOT NULL,
+    changes     jsonb       NOT NULL
+);
+CREATE INDEX incident_revisions_incident_id_idx ON statusphere.incident_revisions (incident_id, observed_at);
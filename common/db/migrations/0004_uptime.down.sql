@@ -0,0 +1,2 @@
+DROP TABLE statusphere.uptime_daily;
+ALTER TABLE statusphere.status_page DROP COLUMN sla_target;
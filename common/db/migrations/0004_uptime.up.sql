@@ -0,0 +1,16 @@
+ALTER TABLE statusphere.status_page ADD COLUMN sla_target double precision;
+
+-- Daily uptime rollups, computed by the jobrunner from the incident windows
+-- component is empty for the uptime of the whole status page
+CREATE TABLE statusphere.uptime_daily
+(
+    status_page_url        text             NOT NULL,
+    component              text             NOT NULL DEFAULT '',
+    day                    date             NOT NULL,
+    period_seconds         double precision NOT NULL,
+    full_outage_seconds    double precision NOT NULL,
+    partial_outage_seconds double precision NOT NULL,
+    uptime_percentage      double precision NOT NULL,
+    computed_at            timestamptz      NOT NULL,
+    PRIMARY KEY (status_page_url, component, day)
+);
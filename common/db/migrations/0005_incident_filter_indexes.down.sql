@@ -0,0 +1,4 @@
+DROP INDEX statusphere.incidents_components_idx;
+DROP INDEX statusphere.incidents_ongoing_idx;
+DROP INDEX statusphere.incidents_status_page_url_end_time_idx;
+DROP INDEX statusphere.incidents_status_page_url_start_time_idx;
@@ -0,0 +1,8 @@
+-- Listing incidents of a status page, newest first, with keyset pagination on (start_time, id)
+CREATE INDEX incidents_status_page_url_start_time_idx ON statusphere.incidents (status_page_url, start_time DESC, id DESC);
+-- Time range filters
+CREATE INDEX incidents_status_page_url_end_time_idx ON statusphere.incidents (status_page_url, end_time);
+-- Ongoing incidents
+CREATE INDEX incidents_ongoing_idx ON statusphere.incidents (status_page_url, start_time) WHERE end_time IS NULL;
+-- Component filters, components @> '["API"]'
+CREATE INDEX incidents_components_idx ON statusphere.incidents USING gin (components jsonb_path_ops);
@@ -0,0 +1,2 @@
+DROP INDEX statusphere.incidents_search_vector_idx;
+ALTER TABLE statusphere.incidents DROP COLUMN search_vector;
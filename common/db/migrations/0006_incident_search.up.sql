@@ -0,0 +1,10 @@
+-- Full text search over incidents, see SearchIncidents
+-- Vendors write in several languages, so the simple configuration is used and words are not stemmed
+ALTER TABLE statusphere.incidents
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(jsonb_path_query_array(events, '$[*].title')::text, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(jsonb_path_query_array(events, '$[*].description')::text, '')), 'C')
+        ) STORED;
+CREATE INDEX incidents_search_vector_idx ON statusphere.incidents USING gin (search_vector);
@@ -0,0 +1 @@
+ALTER TABLE statusphere.status_page ADD COLUMN last_successfully_scraped timestamptz;
@@ -0,0 +1 @@
+ALTER TABLE statusphere.status_page DROP COLUMN scrape_interval_seconds;
@@ -0,0 +1,2 @@
+-- 0 means the default scrape interval
+ALTER TABLE statusphere.status_page ADD COLUMN scrape_interval_seconds integer NOT NULL DEFAULT 0;
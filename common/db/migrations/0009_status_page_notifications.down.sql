@@ -0,0 +1,3 @@
+DROP TRIGGER status_page_truncate ON statusphere.status_page;
+DROP TRIGGER status_page_changes ON statusphere.status_page;
+DROP FUNCTION statusphere.notify_status_page_change();
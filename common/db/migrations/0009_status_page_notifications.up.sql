@@ -0,0 +1,30 @@
+-- Every write to a status page is published on statusphere_status_page_changes, whichever process or person made it
+-- The payload is only the operation and the url, listeners load the status page themselves
+CREATE FUNCTION statusphere.notify_status_page_change() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify('statusphere_status_page_changes', json_build_object('operation', TG_OP)::text);
+        RETURN NULL;
+    END IF;
+    IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND OLD.url <> NEW.url) THEN
+        PERFORM pg_notify('statusphere_status_page_changes', json_build_object('operation', 'DELETE', 'url', OLD.url)::text);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        PERFORM pg_notify('statusphere_status_page_changes', json_build_object('operation', TG_OP, 'url', NEW.url)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER status_page_changes
+    AFTER INSERT OR UPDATE OR DELETE
+    ON statusphere.status_page
+    FOR EACH ROW
+EXECUTE FUNCTION statusphere.notify_status_page_change();
+
+CREATE TRIGGER status_page_truncate
+    AFTER TRUNCATE
+    ON statusphere.status_page
+    FOR EACH STATEMENT
+EXECUTE FUNCTION statusphere.notify_status_page_change();
@@ -0,0 +1,2 @@
+-- Where the status page is defined: code (common/status_pages), admin (the admin api) or file
+ALTER TABLE statusphere.status_page ADD COLUMN source text NOT NULL DEFAULT 'code';
@@ -0,0 +1 @@
+DROP TABLE statusphere.api_keys;
@@ -0,0 +1,13 @@
+-- Only the sha256 hash of a key is stored, the key itself is shown once when it is created
+CREATE TABLE statusphere.api_keys
+(
+    id                    bigserial PRIMARY KEY,
+    name                  text        NOT NULL,
+    key_prefix            text        NOT NULL,
+    key_hash              text        NOT NULL UNIQUE,
+    scopes                jsonb       NOT NULL,
+    -- 0 means the default rate limit of the apiserver
+    rate_limit_per_minute integer     NOT NULL DEFAULT 0,
+    created_at            timestamptz NOT NULL DEFAULT now(),
+    revoked_at            timestamptz
+);
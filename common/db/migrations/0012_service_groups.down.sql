@@ -0,0 +1 @@
+DROP TABLE statusphere.service_groups;
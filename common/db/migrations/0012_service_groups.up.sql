@@ -0,0 +1,13 @@
+-- A service group is a named set of status pages and components, e.g. the vendors a product depends on
+CREATE TABLE statusphere.service_groups
+(
+    id          bigserial PRIMARY KEY,
+    name        text        NOT NULL UNIQUE,
+    description text        NOT NULL DEFAULT '',
+    -- aggregation is how the composite status is computed, worst or weighted
+    aggregation text        NOT NULL DEFAULT 'worst',
+    -- members is an array of {statusPageUrl, component, weight}, a member without component is the whole status page
+    members     jsonb       NOT NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
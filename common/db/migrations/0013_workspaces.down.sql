@@ -0,0 +1,18 @@
+DROP TABLE statusphere.notification_channels;
+
+-- The groups of the other workspaces are dropped, their names could collide with the groups of the default workspace
+DELETE FROM statusphere.service_groups WHERE workspace_id <> 1;
+ALTER TABLE statusphere.service_groups
+    DROP CONSTRAINT service_groups_workspace_id_name_key;
+ALTER TABLE statusphere.service_groups
+    ADD CONSTRAINT service_groups_name_key UNIQUE (name);
+ALTER TABLE statusphere.service_groups
+    DROP COLUMN workspace_id;
+
+-- The keys of the other workspaces would see every status page without their workspace
+DELETE FROM statusphere.api_keys WHERE workspace_id <> 1;
+ALTER TABLE statusphere.api_keys
+    DROP COLUMN workspace_id;
+
+DROP TABLE statusphere.workspace_status_pages;
+DROP TABLE statusphere.workspaces;
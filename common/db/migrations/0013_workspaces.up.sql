@@ -0,0 +1,51 @@
+-- A workspace is a tenant of statusphere with its own status pages, service groups, notification channels and api keys
+-- The status pages are still scraped once, whichever workspaces subscribed to them
+CREATE TABLE statusphere.workspaces
+(
+    id         bigserial PRIMARY KEY,
+    name       text        NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- The default workspace sees every status page, everything that existed before workspaces belongs to it
+INSERT INTO statusphere.workspaces (id, name)
+VALUES (1, 'default');
+SELECT setval('statusphere.workspaces_id_seq', 1);
+
+CREATE TABLE statusphere.workspace_status_pages
+(
+    workspace_id    bigint      NOT NULL REFERENCES statusphere.workspaces (id) ON DELETE CASCADE,
+    status_page_url text        NOT NULL REFERENCES statusphere.status_page (url) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, status_page_url)
+);
+
+CREATE INDEX workspace_status_pages_status_page_url_idx ON statusphere.workspace_status_pages (status_page_url);
+
+ALTER TABLE statusphere.api_keys
+    ADD COLUMN workspace_id bigint NOT NULL DEFAULT 1 REFERENCES statusphere.workspaces (id) ON DELETE CASCADE;
+ALTER TABLE statusphere.api_keys
+    ALTER COLUMN workspace_id DROP DEFAULT;
+
+ALTER TABLE statusphere.service_groups
+    ADD COLUMN workspace_id bigint NOT NULL DEFAULT 1 REFERENCES statusphere.workspaces (id) ON DELETE CASCADE;
+ALTER TABLE statusphere.service_groups
+    ALTER COLUMN workspace_id DROP DEFAULT;
+-- Group names are only unique within a workspace
+ALTER TABLE statusphere.service_groups
+    DROP CONSTRAINT service_groups_name_key;
+ALTER TABLE statusphere.service_groups
+    ADD CONSTRAINT service_groups_workspace_id_name_key UNIQUE (workspace_id, name);
+
+CREATE TABLE statusphere.notification_channels
+(
+    id           bigserial PRIMARY KEY,
+    workspace_id bigint      NOT NULL REFERENCES statusphere.workspaces (id) ON DELETE CASCADE,
+    name         text        NOT NULL,
+    -- type is slack or twitter, both are notified through a webhook
+    type         text        NOT NULL,
+    webhook_url  text        NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notification_channels_workspace_id_idx ON statusphere.notification_channels (workspace_id);
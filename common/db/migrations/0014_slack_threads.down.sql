@@ -0,0 +1,10 @@
+-- The channels notified with a bot token have no webhook to fall back to
+DELETE FROM statusphere.notification_channels WHERE webhook_url = '';
+ALTER TABLE statusphere.notification_channels
+    DROP COLUMN slack_channel;
+ALTER TABLE statusphere.notification_channels
+    DROP COLUMN slack_bot_token;
+ALTER TABLE statusphere.notification_channels
+    ALTER COLUMN webhook_url DROP DEFAULT;
+
+DROP TABLE statusphere.slack_messages;
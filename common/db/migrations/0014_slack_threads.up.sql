@@ -0,0 +1,18 @@
+-- The message each incident was first posted as to a slack channel with a bot token, its updates are posted in its thread
+CREATE TABLE statusphere.slack_messages
+(
+    incident_id bigint      NOT NULL REFERENCES statusphere.incidents (id) ON DELETE CASCADE,
+    -- channel is the id of the slack channel, ts the timestamp slack identifies the message with in the channel
+    channel     text        NOT NULL,
+    ts          text        NOT NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (incident_id, channel)
+);
+
+-- Slack channels are notified either through a webhook or with a bot token, which can post the updates in threads
+ALTER TABLE statusphere.notification_channels
+    ALTER COLUMN webhook_url SET DEFAULT '';
+ALTER TABLE statusphere.notification_channels
+    ADD COLUMN slack_bot_token text NOT NULL DEFAULT '';
+ALTER TABLE statusphere.notification_channels
+    ADD COLUMN slack_channel text NOT NULL DEFAULT '';
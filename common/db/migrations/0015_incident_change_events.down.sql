@@ -0,0 +1,10 @@
+ALTER TABLE statusphere.notification_channels
+    DROP COLUMN events;
+
+-- The existing incidents are not notified again
+ALTER TABLE statusphere.incidents
+    ADD COLUMN notification_jobs_started boolean;
+UPDATE statusphere.incidents
+SET notification_jobs_started = true;
+
+DROP TABLE statusphere.incident_change_events;
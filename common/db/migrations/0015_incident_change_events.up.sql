@@ -0,0 +1,28 @@
+-- The changes of the incidents that notification channels are notified of, the jobrunner queues the notifications of
+-- the events that were not notified yet. They replace the notification_jobs_started flag of the incidents.
+CREATE TABLE statusphere.incident_change_events
+(
+    id              bigserial PRIMARY KEY,
+    incident_id     bigint      NOT NULL REFERENCES statusphere.incidents (id) ON DELETE CASCADE,
+    -- type is created, impact_changed, new_update or resolved
+    type            text        NOT NULL,
+    -- previous_impact is the impact of the incident before an impact_changed event
+    previous_impact text        NOT NULL DEFAULT '',
+    observed_at     timestamptz NOT NULL,
+    notified_at     timestamptz
+);
+CREATE INDEX incident_change_events_pending_idx ON statusphere.incident_change_events (id) WHERE notified_at IS NULL;
+
+-- The recent incidents whose notifications were not queued yet are notified as new incidents
+INSERT INTO statusphere.incident_change_events (incident_id, type, observed_at)
+SELECT id, 'created', now()
+FROM statusphere.incidents
+WHERE notification_jobs_started IS DISTINCT FROM true
+  AND start_time > now() - interval '1 hour';
+
+ALTER TABLE statusphere.incidents
+    DROP COLUMN notification_jobs_started;
+
+-- Each channel opts in to the types of events it is notified of, the existing channels keep being notified of new incidents
+ALTER TABLE statusphere.notification_channels
+    ADD COLUMN events jsonb NOT NULL DEFAULT '["created"]';
@@ -0,0 +1,2 @@
+ALTER TABLE statusphere.slack_messages
+    DROP COLUMN last_event_id;
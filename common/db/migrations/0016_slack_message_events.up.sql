@@ -0,0 +1,4 @@
+-- The change event of the incident the message was last edited for, the jobs of older events are skipped so that the
+-- message never goes back to an older state of the incident
+ALTER TABLE statusphere.slack_messages
+    ADD COLUMN last_event_id bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- The bot tokens are not put back in the jobs, the worker looks them up
+SELECT 1;
@@ -0,0 +1,22 @@
+-- The slack jobs queued before the bot token was looked up by the worker stored the token in their args. They are given
+-- the id of the notification channel with the token instead, the jobs of the bot of the environment keep the id 0.
+-- The job queue tables are created by river after these migrations, they do not exist yet in a new database.
+DO
+$$
+    BEGIN
+        IF to_regclass('river_job') IS NOT NULL THEN
+            UPDATE river_job
+            SET args = river_job.args || jsonb_build_object('notification_channel_id', channels.id)
+            FROM statusphere.notification_channels channels
+            WHERE river_job.kind = 'slack_webhook'
+              AND river_job.args ? 'bot_token'
+              AND channels.slack_bot_token = river_job.args ->> 'bot_token'
+              AND channels.slack_channel = river_job.args ->> 'channel';
+
+            UPDATE river_job
+            SET args = args - 'bot_token'
+            WHERE kind = 'slack_webhook'
+              AND args ? 'bot_token';
+        END IF;
+    END
+$$;
@@ -0,0 +1,3 @@
+DROP INDEX statusphere.incidents_description_trgm_idx;
+DROP INDEX statusphere.incidents_title_trgm_idx;
+-- pg_trgm is kept, other schemas of the database may use it
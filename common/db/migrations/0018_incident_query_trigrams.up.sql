@@ -0,0 +1,6 @@
+-- The query filter of the incidents, see IncidentFilter, matches any part of the title or the description with ILIKE,
+-- which the trigram indexes serve instead of a scan of every incident. pg_trgm is a trusted extension, the owner of the
+-- database can create it.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX incidents_title_trgm_idx ON statusphere.incidents USING gin (title gin_trgm_ops);
+CREATE INDEX incidents_description_trgm_idx ON statusphere.incidents USING gin (description gin_trgm_ops);
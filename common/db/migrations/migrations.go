@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds the versioned SQL migrations for the statusphere schema.
+// Each version is made of a <version>_<name>.up.sql and a <version>_<name>.down.sql file.
+//
+//go:embed *.sql
+var FS embed.FS
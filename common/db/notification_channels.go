@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const notificationChannelsTableName = "notification_channels"
+
+// CreateNotificationChannel stores the notification channel and sets its id
+func (d *DbClient) CreateNotificationChannel(ctx context.Context, channel *api.NotificationChannel) error {
+	channel.CreatedAt = time.Now()
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationChannelsTableName)).Create(channel)
+	return result.Error
+}
+
+// ListNotificationChannels returns the notification channels of the workspace, oldest first
+func (d *DbClient) ListNotificationChannels(ctx context.Context, workspaceID int64) ([]api.NotificationChannel, error) {
+	var channels []api.NotificationChannel
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationChannelsTableName)).
+		Where("workspace_id = ?", workspaceID).Order("id").Find(&channels)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return channels, nil
+}
+
+// ListAllNotificationChannels returns the notification channels of every workspace, oldest first
+func (d *DbClient) ListAllNotificationChannels(ctx context.Context) ([]api.NotificationChannel, error) {
+	var channels []api.NotificationChannel
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationChannelsTableName)).Order("id").Find(&channels)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return channels, nil
+}
+
+// GetNotificationChannel returns the notification channel, it returns nil if it does not exist
+func (d *DbClient) GetNotificationChannel(ctx context.Context, id int64) (*api.NotificationChannel, error) {
+	var channel api.NotificationChannel
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationChannelsTableName)).
+		Where("id = ?", id).First(&channel)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &channel, nil
+}
+
+// DeleteNotificationChannel deletes the notification channel of the workspace, it returns false if it does not exist
+func (d *DbClient) DeleteNotificationChannel(ctx context.Context, workspaceID int64, id int64) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, notificationChannelsTableName)).
+		Where("workspace_id = ? AND id = ?", workspaceID, id).Delete(&api.NotificationChannel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const serviceGroupsTableName = "service_groups"
+
+var ErrServiceGroupExists = errors.New("service group already exists")
+
+// CreateServiceGroup stores the service group and sets its id
+// It returns ErrServiceGroupExists if a group with the name exists in the workspace of the group
+func (d *DbClient) CreateServiceGroup(ctx context.Context, group *api.ServiceGroup) error {
+	now := time.Now()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, serviceGroupsTableName)).Create(group)
+	if result.Error != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(result.Error, &pgErr) && pgErr.Code == "23505" {
+			// This is the code for unique violation
+			return ErrServiceGroupExists
+		}
+		return result.Error
+	}
+	return nil
+}
+
+// GetServiceGroup returns the service group of the workspace with the given name, or nil if it does not exist
+func (d *DbClient) GetServiceGroup(ctx context.Context, workspaceID int64, name string) (*api.ServiceGroup, error) {
+	var group api.ServiceGroup
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, serviceGroupsTableName)).
+		Where("workspace_id = ? AND name = ?", workspaceID, name).First(&group)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &group, nil
+}
+
+// ListServiceGroups returns the service groups of the workspace ordered by name
+func (d *DbClient) ListServiceGroups(ctx context.Context, workspaceID int64) ([]api.ServiceGroup, error) {
+	var groups []api.ServiceGroup
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, serviceGroupsTableName)).
+		Where("workspace_id = ?", workspaceID).Order("name").Find(&groups)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return groups, nil
+}
+
+// UpdateServiceGroup replaces the description, aggregation and members of the service group with the workspace and name of the given group
+// It returns false if the service group does not exist
+func (d *DbClient) UpdateServiceGroup(ctx context.Context, group *api.ServiceGroup) (bool, error) {
+	group.UpdatedAt = time.Now()
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, serviceGroupsTableName)).
+		Where("workspace_id = ? AND name = ?", group.WorkspaceID, group.Name).
+		Select("description", "aggregation", "members", "updated_at").
+		Updates(group)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteServiceGroup deletes the service group of the workspace, it returns false if it does not exist
+func (d *DbClient) DeleteServiceGroup(ctx context.Context, workspaceID int64, name string) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, serviceGroupsTableName)).
+		Where("workspace_id = ? AND name = ?", workspaceID, name).Delete(&api.ServiceGroup{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
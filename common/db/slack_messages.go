@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const slackMessagesTableName = "slack_messages"
+
+// SlackMessage is the message an incident was first posted as to a slack channel with a bot token, its updates are
+// posted in its thread
+type SlackMessage struct {
+	IncidentID int64  `gorm:"column:incident_id"`
+	Channel    string `gorm:"column:channel"`
+	Ts         string `gorm:"column:ts"`
+	// LastEventID is the id of the last change event of the incident the message was posted or edited for
+	// It is 0 for the changes queued before the jobs had their event
+	LastEventID int64     `gorm:"column:last_event_id"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// LockSlackMessage calls post with the message the incident was posted as to the slack channel, nil if it was not posted,
+// and stores the message post returns unless it is nil. The message of an incident in a channel is locked until post
+// returns, so that the notifications of an incident are posted one at a time. Nothing is stored if post fails.
+func (d *DbClient) LockSlackMessage(ctx context.Context, incidentID int64, channel string, post func(message *SlackMessage) (*SlackMessage, error)) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The lock is released with the transaction
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, ?))", channel, incidentID).Error; err != nil {
+			return errors.Wrap(err, "failed to lock the slack message")
+		}
+
+		var stored *SlackMessage
+		var message SlackMessage
+		result := tx.Table(fmt.Sprintf("%s.%s", schemaName, slackMessagesTableName)).
+			Where("incident_id = ? AND channel = ?", incidentID, channel).First(&message)
+		if result.Error == nil {
+			stored = &message
+		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return result.Error
+		}
+
+		posted, err := post(stored)
+		if err != nil || posted == nil {
+			return err
+		}
+		posted.IncidentID = incidentID
+		posted.Channel = channel
+		if posted.CreatedAt.IsZero() {
+			posted.CreatedAt = time.Now()
+		}
+		// The first message of an incident is kept, its updates are posted in its thread
+		return tx.Table(fmt.Sprintf("%s.%s", schemaName, slackMessagesTableName)).
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "incident_id"}, {Name: "channel"}},
+				DoUpdates: clause.AssignmentColumns([]string{"last_event_id"}),
+			}).Create(posted).Error
+	})
+}
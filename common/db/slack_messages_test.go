@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestLockSlackMessagePostsOneMessagePerIncident(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDbClient(t)
+	if _, err := d.MigrateDown(ctx, 1000); err != nil {
+		t.Fatalf("failed to reset the database: %v", err)
+	}
+	if _, err := d.MigrateUp(ctx); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	start := time.Date(2024, 3, 13, 6, 55, 0, 0, time.UTC)
+	incident := api.NewIncident("Elevated errors", nil, nil, start, nil, nil, "https://status.example.com/incidents/1",
+		"1", api.ImpactMajor, "https://status.example.com", "atlassian")
+	if err := d.CreateOrUpdateIncidents(ctx, []api.Incident{incident}, "atlassian", "https://status.example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	incidents, err := d.GetIncidents(ctx, "https://status.example.com")
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("expected the incident to be stored, got %+v: %v", incidents, err)
+	}
+	incidentID := incidents[0].ID
+
+	// The jobs of two changes run at the same time, only the first posts a message
+	var mutex sync.Mutex
+	posted := 0
+	var wait sync.WaitGroup
+	for eventID := int64(1); eventID <= 2; eventID++ {
+		eventID := eventID
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			err := d.LockSlackMessage(ctx, incidentID, "C123", func(message *SlackMessage) (*SlackMessage, error) {
+				if message != nil {
+					return &SlackMessage{Ts: message.Ts, LastEventID: eventID}, nil
+				}
+				mutex.Lock()
+				posted++
+				mutex.Unlock()
+				return &SlackMessage{Ts: "1710312900.000100", LastEventID: eventID}, nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wait.Wait()
+	if posted != 1 {
+		t.Errorf("expected one message to be posted, got %d", posted)
+	}
+
+	err = d.LockSlackMessage(ctx, incidentID, "C123", func(message *SlackMessage) (*SlackMessage, error) {
+		if message == nil || message.Ts != "1710312900.000100" || message.LastEventID == 0 {
+			t.Errorf("expected the stored message, got %+v", message)
+		}
+		return &SlackMessage{Ts: "1710312999.000100", LastEventID: 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = d.LockSlackMessage(ctx, incidentID, "C123", func(message *SlackMessage) (*SlackMessage, error) {
+		// The first message is kept, the last change is the one stored last
+		if message == nil || message.Ts != "1710312900.000100" || message.LastEventID != 3 {
+			t.Errorf("expected the first message with the last change, got %+v", message)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+)
+
+var ErrStatusPageExists = errors.New("status page already exists")
+
+// statusPageDefinitionColumns are the columns describing how a status page is scraped, the rest is scraping state
+var statusPageDefinitionColumns = []string{
+	"name", "preferred_scraper", "method", "headers", "request_payload", "validation_rules", "sla_target", "scrape_interval_seconds", "source",
+}
+
+// CreateStatusPage inserts a new status page, it returns ErrStatusPageExists if a status page with the url exists
+func (d *DbClient) CreateStatusPage(ctx context.Context, statusPage api.StatusPage) error {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).Create(&statusPage)
+	if result.Error != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(result.Error, &pgErr) && pgErr.Code == "23505" {
+			// This is the code for unique violation
+			return ErrStatusPageExists
+		}
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateStatusPageDefinition replaces the definition of the status page, its scraping state is kept
+// Unlike UpdateStatusPage, empty fields of the definition are written too, so headers or rules can be removed
+// It returns false if the status page does not exist
+func (d *DbClient) UpdateStatusPageDefinition(ctx context.Context, statusPage api.StatusPage) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, statusPageTableName)).
+		Where("url = ?", statusPage.URL).
+		Select(statusPageDefinitionColumns).
+		Updates(&statusPage)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// StatusPageReconcileResult counts the status pages written by ReconcileStatusPages
+type StatusPageReconcileResult struct {
+	Created int
+	Updated int
+	Deleted int
+	// TakenOver are the urls of the status pages of another source, e.g. created through the admin api, that the given
+	// status pages replaced
+	TakenOver []string
+}
+
+// ReconcileStatusPages makes the status pages of the source match the given status pages
+// Missing status pages are created, status pages with a different definition are updated and taken over by the source,
+// and the status pages of the source that are not given anymore are deleted
+func (d *DbClient) ReconcileStatusPages(ctx context.Context, source api.StatusPageSource, statusPages []api.StatusPage) (StatusPageReconcileResult, error) {
+	var result StatusPageReconcileResult
+	stored, err := d.GetAllStatusPages(ctx)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to get status pages")
+	}
+	storedByUrl := make(map[string]api.StatusPage, len(stored))
+	for _, statusPage := range stored {
+		storedByUrl[statusPage.URL] = statusPage
+	}
+
+	wanted := make(map[string]bool, len(statusPages))
+	for _, statusPage := range statusPages {
+		statusPage.Source = source
+		wanted[statusPage.URL] = true
+		existing, ok := storedByUrl[statusPage.URL]
+		if !ok {
+			if err := d.CreateStatusPage(ctx, statusPage); err != nil {
+				return result, errors.Wrapf(err, "failed to create status page %s", statusPage.URL)
+			}
+			result.Created++
+			continue
+		}
+		same, err := sameStatusPageDefinition(existing, statusPage)
+		if err != nil {
+			return result, err
+		}
+		if same {
+			continue
+		}
+		if _, err := d.UpdateStatusPageDefinition(ctx, statusPage); err != nil {
+			return result, errors.Wrapf(err, "failed to update status page %s", statusPage.URL)
+		}
+		result.Updated++
+		if existing.Source != source {
+			result.TakenOver = append(result.TakenOver, statusPage.URL)
+		}
+	}
+
+	for _, statusPage := range stored {
+		if statusPage.Source != source || wanted[statusPage.URL] {
+			continue
+		}
+		if err := d.DeleteStatusPage(ctx, statusPage.URL); err != nil {
+			return result, errors.Wrapf(err, "failed to delete status page %s", statusPage.URL)
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// sameStatusPageDefinition compares the definitions as JSON, numbers decoded from jsonb are floats while the given ones may be ints
+func sameStatusPageDefinition(a api.StatusPage, b api.StatusPage) (bool, error) {
+	definition := func(page api.StatusPage) ([]byte, error) {
+		return json.Marshal([]interface{}{
+			page.Name, page.PreferredScraper, page.Method, page.Headers, page.RequestPayload, page.ValidationRules,
+			page.SlaTarget, page.ScrapeIntervalSeconds, page.Source,
+		})
+	}
+	aDefinition, err := definition(a)
+	if err != nil {
+		return false, err
+	}
+	bDefinition, err := definition(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aDefinition, bDefinition), nil
+}
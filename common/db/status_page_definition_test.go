@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestSameStatusPageDefinition(t *testing.T) {
+	stored := api.StatusPage{URL: "https://api.example.com", Name: "Example", RequestPayload: api.JSONStruct{"limit": float64(1)}, Source: api.StatusPageSourceFile}
+	given := api.StatusPage{URL: "https://api.example.com", Name: "Example", RequestPayload: api.JSONStruct{"limit": 1}, Source: api.StatusPageSourceFile}
+	same, err := sameStatusPageDefinition(stored, given)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !same {
+		t.Error("expected the numbers decoded from jsonb to match the given ones")
+	}
+
+	given.Source = api.StatusPageSourceAdmin
+	if same, _ := sameStatusPageDefinition(stored, given); same {
+		t.Error("expected a different source to be a different definition")
+	}
+}
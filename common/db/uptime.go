@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"gorm.io/gorm/clause"
+)
+
+const uptimeDailyTableName = "uptime_daily"
+
+// GetIncidentsBetween returns the incidents of the status page that overlap the window [from, to)
+func (d *DbClient) GetIncidentsBetween(ctx context.Context, statusPageUrl string, from time.Time, to time.Time) ([]api.Incident, error) {
+	var incidents []api.Incident
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Where("status_page_url = ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)", statusPageUrl, to, from).
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// GetEarliestIncidentStartTime returns the start time of the first incident of the status page, or nil if it has none
+func (d *DbClient) GetEarliestIncidentStartTime(ctx context.Context, statusPageUrl string) (*time.Time, error) {
+	var startTime *time.Time
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, incidentsTableName)).
+		Where("status_page_url = ?", statusPageUrl).
+		Select("min(start_time)").Scan(&startTime)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return startTime, nil
+}
+
+// GetLatestUptimeDay returns the most recent day that has a computed uptime for the status page, or nil if there is none
+func (d *DbClient) GetLatestUptimeDay(ctx context.Context, statusPageUrl string) (*time.Time, error) {
+	var day *time.Time
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, uptimeDailyTableName)).
+		Where("status_page_url = ?", statusPageUrl).
+		Select("max(day)").Scan(&day)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return day, nil
+}
+
+// UpsertUptimeDays creates or replaces the given daily uptimes
+func (d *DbClient) UpsertUptimeDays(ctx context.Context, days []api.UptimeDay) error {
+	if len(days) == 0 {
+		return nil
+	}
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, uptimeDailyTableName)).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "status_page_url"}, {Name: "component"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"period_seconds", "full_outage_seconds", "partial_outage_seconds", "uptime_percentage", "computed_at"}),
+		},
+	).Create(&days)
+	return result.Error
+}
+
+// GetUptimeDays returns the daily uptimes of a status page component between from and to (both inclusive), oldest first
+// The component is empty for the uptime of the whole status page
+func (d *DbClient) GetUptimeDays(ctx context.Context, statusPageUrl string, component string, from time.Time, to time.Time) ([]api.UptimeDay, error) {
+	var days []api.UptimeDay
+	result := d.db.Table(fmt.Sprintf("%s.%s", schemaName, uptimeDailyTableName)).
+		Where("status_page_url = ? AND component = ? AND day >= ? AND day <= ?", statusPageUrl, component, from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)).
+		Order("day asc").
+		Find(&days)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return days, nil
+}
+
+// ListUptimeDays returns the daily uptimes of the components of the status pages between from and to (both inclusive), oldest first
+// The component is empty for the uptime of a whole status page
+func (d *DbClient) ListUptimeDays(ctx context.Context, statusPageUrls []string, components []string, from time.Time, to time.Time) ([]api.UptimeDay, error) {
+	var days []api.UptimeDay
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, uptimeDailyTableName)).
+		Where("status_page_url IN ? AND component IN ? AND day >= ? AND day <= ?", statusPageUrls, components, from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)).
+		Order("day asc").
+		Find(&days)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return days, nil
+}
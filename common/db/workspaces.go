@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const workspacesTableName = "workspaces"
+const workspaceStatusPagesTableName = "workspace_status_pages"
+
+var ErrWorkspaceExists = errors.New("workspace already exists")
+
+type workspaceStatusPage struct {
+	WorkspaceID   int64  `gorm:"column:workspace_id"`
+	StatusPageUrl string `gorm:"column:status_page_url"`
+}
+
+// CreateWorkspace stores the workspace and sets its id, it returns ErrWorkspaceExists if a workspace with the name exists
+func (d *DbClient) CreateWorkspace(ctx context.Context, workspace *api.Workspace) error {
+	workspace.CreatedAt = time.Now()
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspacesTableName)).Create(workspace)
+	if result.Error != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(result.Error, &pgErr) && pgErr.Code == "23505" {
+			// This is the code for unique violation
+			return ErrWorkspaceExists
+		}
+		return result.Error
+	}
+	return nil
+}
+
+// GetWorkspaceByName returns the workspace with the given name, or nil if it does not exist
+func (d *DbClient) GetWorkspaceByName(ctx context.Context, name string) (*api.Workspace, error) {
+	var workspace api.Workspace
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspacesTableName)).
+		Where("name = ?", name).First(&workspace)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &workspace, nil
+}
+
+// ListWorkspaces returns every workspace, the default workspace first
+func (d *DbClient) ListWorkspaces(ctx context.Context) ([]api.Workspace, error) {
+	var workspaces []api.Workspace
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspacesTableName)).Order("id").Find(&workspaces)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return workspaces, nil
+}
+
+// DeleteWorkspace deletes the workspace along with its subscriptions, service groups, notification channels and api keys
+// The default workspace cannot be deleted, it returns false if the workspace does not exist
+func (d *DbClient) DeleteWorkspace(ctx context.Context, id int64) (bool, error) {
+	if id == api.DefaultWorkspaceID {
+		return false, errors.New("the default workspace cannot be deleted")
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspacesTableName)).
+		Where("id = ?", id).Delete(&api.Workspace{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetWorkspaceStatusPageUrls returns the urls of the status pages the workspace subscribed to, ordered by url
+func (d *DbClient) GetWorkspaceStatusPageUrls(ctx context.Context, workspaceID int64) ([]string, error) {
+	var urls []string
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspaceStatusPagesTableName)).
+		Where("workspace_id = ?", workspaceID).Order("status_page_url").Pluck("status_page_url", &urls)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return urls, nil
+}
+
+// AddWorkspaceStatusPages subscribes the workspace to the status pages, the subscriptions that exist are kept
+func (d *DbClient) AddWorkspaceStatusPages(ctx context.Context, workspaceID int64, statusPageUrls []string) error {
+	if len(statusPageUrls) == 0 {
+		return nil
+	}
+	rows := make([]workspaceStatusPage, 0, len(statusPageUrls))
+	for _, statusPageUrl := range statusPageUrls {
+		rows = append(rows, workspaceStatusPage{WorkspaceID: workspaceID, StatusPageUrl: statusPageUrl})
+	}
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspaceStatusPagesTableName)).
+		Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+	return result.Error
+}
+
+// RemoveWorkspaceStatusPage unsubscribes the workspace from the status page, it returns false if it was not subscribed
+func (d *DbClient) RemoveWorkspaceStatusPage(ctx context.Context, workspaceID int64, statusPageUrl string) (bool, error) {
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspaceStatusPagesTableName)).
+		Where("workspace_id = ? AND status_page_url = ?", workspaceID, statusPageUrl).Delete(&workspaceStatusPage{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetStatusPageWorkspaceIDs returns the workspaces subscribed to each of the status pages, the default workspace is not included
+func (d *DbClient) GetStatusPageWorkspaceIDs(ctx context.Context, statusPageUrls []string) (map[string][]int64, error) {
+	var rows []workspaceStatusPage
+	result := d.db.WithContext(ctx).Table(fmt.Sprintf("%s.%s", schemaName, workspaceStatusPagesTableName)).
+		Where("status_page_url IN ?", statusPageUrls).Order("workspace_id").Find(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	workspaceIDs := make(map[string][]int64)
+	for _, row := range rows {
+		workspaceIDs[row.StatusPageUrl] = append(workspaceIDs[row.StatusPageUrl], row.WorkspaceID)
+	}
+	return workspaceIDs, nil
+}
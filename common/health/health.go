@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// checkTimeout bounds how long the readiness checks of a request can take together
+const checkTimeout = 5 * time.Second
+
+// Check returns nil if the part of the service it checks is ready
+type Check func(ctx context.Context) error
+
+// Checks are the readiness checks of a service by name
+type Checks map[string]Check
+
+type Response struct {
+	Status string `json:"status"`
+	// Errors has the error of each failing check by name
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Run runs every check and returns whether they all passed along with the errors of those that failed
+func (c Checks) Run(ctx context.Context) (bool, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errors := make(map[string]string)
+	for _, name := range names {
+		if err := c[name](ctx); err != nil {
+			errors[name] = err.Error()
+		}
+	}
+	return len(errors) == 0, errors
+}
+
+// LivenessHandler responds 200 as long as the process can serve requests
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, Response{Status: "ok"})
+	}
+}
+
+// ReadinessHandler responds 200 if every check passes, otherwise 503 with the errors of the failing checks
+func ReadinessHandler(checks Checks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready, errors := checks.Run(r.Context())
+		if !ready {
+			writeResponse(w, http.StatusServiceUnavailable, Response{Status: "unavailable", Errors: errors})
+			return
+		}
+		writeResponse(w, http.StatusOK, Response{Status: "ok"})
+	}
+}
+
+// NewServer returns a server of the /healthz and /readyz endpoints for the services that do not serve http otherwise
+func NewServer(addr string, checks Checks) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", LivenessHandler())
+	mux.HandleFunc("/readyz", ReadinessHandler(checks))
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+}
+
+func writeResponse(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
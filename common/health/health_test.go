@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	failing := false
+	checks := Checks{
+		"database": func(ctx context.Context) error { return nil },
+		"caches": func(ctx context.Context) error {
+			if failing {
+				return errors.New("not warmed yet")
+			}
+			return nil
+		},
+	}
+	server := NewServer(":0", checks)
+
+	get := func(path string) (int, Response) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		var response Response
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return recorder.Code, response
+	}
+
+	if code, response := get("/readyz"); code != http.StatusOK || response.Status != "ok" {
+		t.Errorf("expected to be ready, got %d %+v", code, response)
+	}
+
+	failing = true
+	code, response := get("/readyz")
+	if code != http.StatusServiceUnavailable || len(response.Errors) != 1 || response.Errors["caches"] != "not warmed yet" {
+		t.Errorf("expected the failing check to make the service unavailable, got %d %+v", code, response)
+	}
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Errorf("expected to be alive while not ready, got %d", code)
+	}
+}
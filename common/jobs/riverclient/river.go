@@ -14,21 +14,22 @@ import (
 	"net/http"
 )
 
-func spawnWorkers(logger *zap.Logger, client *http.Client, dbClient *db.DbClient) *river.Workers {
+func spawnWorkers(logger *zap.Logger, client *http.Client, dbClient *db.DbClient, slackBotToken string) *river.Workers {
 	workers := river.NewWorkers()
-	river.AddWorker(workers, slack_webhook.NewSlackWebhookWorker(logger, client))
+	river.AddWorker(workers, slack_webhook.NewSlackWebhookWorker(logger, client, dbClient, slackBotToken))
 	river.AddWorker(workers, twitter_post.NewTwitterPostWorker(logger, client, dbClient))
 	return workers
 }
 
-func NewRiverClient(dbClient *db.DbClient, logger *zap.Logger, client *http.Client, numWorkers int) (*river.Client[pgx.Tx], error) {
+// NewRiverClient returns the client of the job queue, slackBotToken is the token of the slack bot set in the environment
+func NewRiverClient(dbClient *db.DbClient, logger *zap.Logger, client *http.Client, numWorkers int, slackBotToken string) (*river.Client[pgx.Tx], error) {
 	return river.NewClient(riverpgxv5.New(dbClient.PgxPool), &river.Config{
 		Queues: map[string]river.QueueConfig{
 			river.QueueDefault: {
 				MaxWorkers: numWorkers,
 			},
 		},
-		Workers: spawnWorkers(logger, client, dbClient),
+		Workers: spawnWorkers(logger, client, dbClient, slackBotToken),
 	})
 }
 
@@ -0,0 +1,212 @@
+package slack_webhook
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// Limits of the block kit elements, longer texts are rejected by slack
+const maxHeaderLength = 150
+const maxSectionLength = 3000
+
+const resolvedColor = "#2eb67d"
+
+var impactColors = map[api.Impact]string{
+	api.ImpactCritical:    "#d72b3f",
+	api.ImpactMajor:       "#e8772e",
+	api.ImpactMinor:       "#f2c744",
+	api.ImpactMaintenance: "#3b82f6",
+	api.ImpactNone:        "#9ca3af",
+}
+
+// Message is a slack message, posted to an incoming webhook or with chat.postMessage and chat.update
+// The blocks are in an attachment so that the message has the colour of the impact of the incident
+type Message struct {
+	Channel  string `json:"channel,omitempty"`
+	Ts       string `json:"ts,omitempty"`
+	ThreadTs string `json:"thread_ts,omitempty"`
+	// Text is shown in the notifications and by the clients that cannot show blocks
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	Color  string  `json:"color"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Block is a header, section or context block, see https://api.slack.com/reference/block-kit/blocks
+type Block struct {
+	Type     string       `json:"type"`
+	Text     *TextObject  `json:"text,omitempty"`
+	Fields   []TextObject `json:"fields,omitempty"`
+	Elements []TextObject `json:"elements,omitempty"`
+}
+
+// TextObject is plain_text or mrkdwn text
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// newIncidentMessage returns the message of the incident as it currently is
+// The event prefixes the header of the messages posted for the changes of an incident that are not in a thread
+func newIncidentMessage(statusPageName string, incident api.Incident, event api.IncidentChangeEventType) Message {
+	resolved := isResolved(incident, event)
+	prefix := ""
+	switch event {
+	case api.IncidentChangeEventImpactChanged:
+		prefix = "Impact changed: "
+	case api.IncidentChangeEventNewUpdate:
+		prefix = "Update: "
+	case api.IncidentChangeEventResolved:
+		prefix = "Resolved: "
+	}
+
+	fields := []TextObject{
+		mrkdwn(fmt.Sprintf("*Impact*\n%s", capitalize(string(incident.Impact)))),
+	}
+	if resolved {
+		fields = append(fields, mrkdwn("*Status*\nResolved"))
+	} else if incident.State != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Status*\n%s", capitalize(string(incident.State)))))
+	}
+	fields = append(fields, mrkdwn(fmt.Sprintf("*Started*\n%s", formatTime(incident.StartTime))))
+	if incident.EndTime != nil {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Ended*\n%s", formatTime(*incident.EndTime))))
+	}
+	if len(incident.Components) > 0 {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Components*\n%s", escape(strings.Join(incident.Components, ", ")))))
+	}
+
+	blocks := []Block{
+		{Type: "header", Text: &TextObject{Type: "plain_text", Text: truncate(fmt.Sprintf("%s%s: %s", prefix, statusPageName, incident.Title), maxHeaderLength)}},
+		{Type: "section", Fields: fields},
+	}
+	if update := latestUpdate(incident); update != "" {
+		blocks = append(blocks, Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: update}})
+	}
+	blocks = append(blocks, deepLinkBlock(incident))
+
+	return Message{
+		Text:        truncate(fmt.Sprintf("%s%s incident on %s: %s", prefix, capitalize(string(incident.Impact)), statusPageName, incident.Title), maxSectionLength),
+		Attachments: []Attachment{{Color: messageColor(incident, resolved), Blocks: blocks}},
+	}
+}
+
+// newThreadReply returns the reply posted in the thread of the message of the incident for a change of the incident
+// The previous impact is the impact before an impact_changed event
+func newThreadReply(incident api.Incident, event api.IncidentChangeEventType, previousImpact api.Impact) Message {
+	resolved := isResolved(incident, event)
+	var summary string
+	text := fmt.Sprintf("Update: %s", incident.Title)
+	switch {
+	case resolved:
+		summary = "*Resolved*"
+		if incident.EndTime != nil {
+			summary = fmt.Sprintf("*Resolved* after %s", formatDuration(incident.EndTime.Sub(incident.StartTime)))
+		}
+		text = fmt.Sprintf("Resolved: %s", incident.Title)
+	case event == api.IncidentChangeEventImpactChanged && previousImpact != "":
+		summary = fmt.Sprintf("*Impact changed* from %s to %s", previousImpact, incident.Impact)
+		text = fmt.Sprintf("Impact changed: %s", incident.Title)
+	default:
+		summary = fmt.Sprintf("*Update*, the impact is %s", incident.Impact)
+	}
+
+	blocks := []Block{{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: summary}}}
+	if update := latestUpdate(incident); update != "" {
+		blocks = append(blocks, Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: update}})
+	}
+	return Message{
+		Text:        truncate(text, maxSectionLength),
+		Attachments: []Attachment{{Color: messageColor(incident, resolved), Blocks: blocks}},
+	}
+}
+
+func isResolved(incident api.Incident, event api.IncidentChangeEventType) bool {
+	return event == api.IncidentChangeEventResolved || incident.State == api.IncidentStateResolved
+}
+
+func messageColor(incident api.Incident, resolved bool) string {
+	if resolved {
+		return resolvedColor
+	}
+	if color, ok := impactColors[incident.Impact]; ok {
+		return color
+	}
+	return impactColors[api.ImpactNone]
+}
+
+// latestUpdate returns the text of the latest event of the incident, or its description if it has no events
+func latestUpdate(incident api.Incident) string {
+	if len(incident.Events) == 0 {
+		if incident.Description == nil || strings.TrimSpace(*incident.Description) == "" {
+			return ""
+		}
+		return truncate(escape(*incident.Description), maxSectionLength)
+	}
+	latest := incident.Events[0]
+	for _, event := range incident.Events[1:] {
+		if event.Time.After(latest.Time) {
+			latest = event
+		}
+	}
+	text := fmt.Sprintf("*Latest update: %s* (%s)", escape(latest.Title), formatTime(latest.Time))
+	if description := strings.TrimSpace(latest.Description); description != "" {
+		text += "\n" + escape(description)
+	}
+	return truncate(text, maxSectionLength)
+}
+
+func deepLinkBlock(incident api.Incident) Block {
+	return Block{Type: "context", Elements: []TextObject{
+		mrkdwn(fmt.Sprintf("<%s|View on the status page> · via Statusphere", incident.DeepLink)),
+	}}
+}
+
+func mrkdwn(text string) TextObject {
+	return TextObject{Type: "mrkdwn", Text: text}
+}
+
+// escape escapes the characters that slack reads as the start of a link or a mention
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// formatTime formats the time in the time zone of the reader, the fallback is for the clients that cannot
+func formatTime(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", t.Unix(), t.UTC().Format("2006-01-02 15:04 UTC"))
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// truncate shortens the text to at most max characters
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max-1]) + "…"
+}
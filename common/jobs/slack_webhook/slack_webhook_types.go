@@ -6,8 +6,21 @@ import (
 )
 
 type SlackWebhookArgs struct {
-	WebhookUrl string       `json:"webhook_url"`
-	Incident   api.Incident `json:"incident"`
+	// WebhookUrl is the incoming webhook the incident is posted to, it is empty when posting with a bot token
+	WebhookUrl string `json:"webhook_url"`
+	// Channel is posted to with the slack web api and a bot token instead, the updates of an incident are then posted in
+	// the thread of its message. The token is not stored in the job, the worker uses the one of the notification channel
+	// NotificationChannelID, or the bot token of the environment when it is 0.
+	Channel               string `json:"channel,omitempty"`
+	NotificationChannelID int64  `json:"notification_channel_id,omitempty"`
+	// Event is what happened to the incident, the jobs queued before events were sent are created events
+	Event api.IncidentChangeEventType `json:"event,omitempty"`
+	// EventID is the id of the change event, the jobs queued before it was sent have none
+	EventID int64 `json:"event_id,omitempty"`
+	// PreviousImpact is the impact of the incident before an impact_changed event
+	PreviousImpact api.Impact `json:"previous_impact,omitempty"`
+	// Incident is the incident when the change was observed, the worker posts with a bot token the incident as it now is
+	Incident api.Incident `json:"incident"`
 }
 
 func (SlackWebhookArgs) Kind() string {
@@ -19,3 +32,10 @@ func (SlackWebhookArgs) InsertOpts() river.InsertOpts {
 		MaxAttempts: 10,
 	}
 }
+
+func (a SlackWebhookArgs) event() api.IncidentChangeEventType {
+	if a.Event == "" {
+		return api.IncidentChangeEventCreated
+	}
+	return a.Event
+}
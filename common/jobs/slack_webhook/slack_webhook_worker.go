@@ -4,38 +4,100 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
 	"github.com/pkg/errors"
 	"github.com/riverqueue/river"
 	"go.uber.org/zap"
+	"io"
 	"math"
 	"net/http"
 	"time"
 )
 
+const slackApiUrl = "https://slack.com/api"
+
 type SlackWebhookWorker struct {
 	// An embedded WorkerDefaults sets up default methods to fulfill the rest of
 	// the Worker interface:
 	river.WorkerDefaults[SlackWebhookArgs]
 	logger     *zap.Logger
 	httpClient *http.Client
+	db         *db.DbClient
+	// environmentBotToken posts the jobs of the slack bot set in the environment, which is not a notification channel
+	environmentBotToken string
+	// messages stores the ts of the message each incident was posted as, it is the database outside of the tests
+	messages slackMessageStore
+	// apiUrl is the url of the slack web api, the methods are appended to it
+	apiUrl string
+}
+
+// slackMessageStore stores the messages the incidents were posted as with a bot token and loads the incidents as they
+// now are, see db.DbClient
+type slackMessageStore interface {
+	GetIncident(ctx context.Context, id int64) (*api.Incident, error)
+	LockSlackMessage(ctx context.Context, incidentID int64, channel string, post func(message *db.SlackMessage) (*db.SlackMessage, error)) error
 }
 
-func NewSlackWebhookWorker(logger *zap.Logger, httpClient *http.Client) *SlackWebhookWorker {
+func NewSlackWebhookWorker(logger *zap.Logger, httpClient *http.Client, dbClient *db.DbClient, environmentBotToken string) *SlackWebhookWorker {
 	return &SlackWebhookWorker{
-		logger:     logger,
-		httpClient: httpClient,
+		logger:              logger,
+		httpClient:          httpClient,
+		db:                  dbClient,
+		environmentBotToken: environmentBotToken,
+		messages:            dbClient,
+		apiUrl:              slackApiUrl,
 	}
 }
 
 func (w *SlackWebhookWorker) Work(ctx context.Context, job *river.Job[SlackWebhookArgs]) error {
-	w.logger.Info("Sending slack webhook", zap.Any("incident", job.Args.Incident))
-	marshal, err := json.Marshal(job.Args.Incident)
+	w.logger.Info("Sending slack notification", zap.Int64("incident", job.Args.Incident.ID), zap.String("event", string(job.Args.event())))
+	statusPageName := job.Args.Incident.StatusPageUrl
+	statusPage, err := w.db.GetStatusPage(ctx, job.Args.Incident.StatusPageUrl)
+	if err != nil {
+		return errors.Wrap(err, "failed to get status page")
+	}
+	if statusPage != nil {
+		statusPageName = statusPage.Name
+	}
+
+	if job.Args.Channel != "" {
+		botToken, err := w.getBotToken(ctx, job.Args)
+		if err != nil {
+			return err
+		}
+		if botToken == "" {
+			// Retrying would not bring the token back
+			w.logger.Warn("dropping slack notification of a deleted notification channel", zap.Int64("notificationChannel", job.Args.NotificationChannelID))
+			return nil
+		}
+		return w.postWithBotToken(ctx, job.Args, botToken, statusPageName)
+	}
+	return w.postToWebhook(ctx, job.Args.WebhookUrl, newIncidentMessage(statusPageName, job.Args.Incident, job.Args.event()))
+}
+
+// getBotToken returns the bot token the job posts with, it is empty if the notification channel was deleted or the bot
+// of the environment is no longer set
+func (w *SlackWebhookWorker) getBotToken(ctx context.Context, args SlackWebhookArgs) (string, error) {
+	if args.NotificationChannelID == 0 {
+		return w.environmentBotToken, nil
+	}
+	channel, err := w.db.GetNotificationChannel(ctx, args.NotificationChannelID)
 	if err != nil {
-		return errors.Wrap(err, "failed to marshal incident")
+		return "", errors.Wrap(err, "failed to get notification channel")
 	}
-	postBody := fmt.Sprintf(`{'text': '%s'}`, string(marshal))
-	req, err := http.NewRequest("POST", job.Args.WebhookUrl, bytes.NewBuffer([]byte(postBody)))
+	if channel == nil {
+		return "", nil
+	}
+	return channel.SlackBotToken, nil
+}
+
+func (w *SlackWebhookWorker) postToWebhook(ctx context.Context, webhookUrl string, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal message")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookUrl, bytes.NewBuffer(body))
 	if err != nil {
 		return errors.Wrap(err, "failed to create request")
 	}
@@ -44,12 +106,124 @@ func (w *SlackWebhookWorker) Work(ctx context.Context, job *river.Job[SlackWebho
 	if err != nil {
 		return errors.Wrap(err, "failed to send request")
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return errors.Errorf("expected status code 200, got %d", resp.StatusCode)
+		// Slack explains the failure in the body, e.g. invalid_payload or channel_is_archived
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("expected status code 200, got %d: %s", resp.StatusCode, reason)
 	}
 	return nil
 }
 
+// postWithBotToken posts a new incident to the channel and stores the ts of the message
+// The other changes of the incident are replied in the thread of the message, which is updated to show
+// the incident as it now is. An incident that was not posted to the channel yet is posted as a new message.
+// The notifications of an incident in a channel are posted one at a time, and the jobs of changes older than the
+// last one the message was posted or edited for are skipped, so that the thread stays in order.
+func (w *SlackWebhookWorker) postWithBotToken(ctx context.Context, args SlackWebhookArgs, botToken string, statusPageName string) error {
+	// The incident of the job is how it was when the change was observed, the message shows it as it now is
+	incident, err := w.messages.GetIncident(ctx, args.Incident.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get incident")
+	}
+	if incident == nil {
+		w.logger.Warn("dropping slack notification of a deleted incident", zap.Int64("incident", args.Incident.ID))
+		return nil
+	}
+
+	return w.messages.LockSlackMessage(ctx, incident.ID, args.Channel, func(stored *db.SlackMessage) (*db.SlackMessage, error) {
+		if stored == nil {
+			message := newIncidentMessage(statusPageName, *incident, args.event())
+			message.Channel = args.Channel
+			ts, err := w.callApi(ctx, botToken, "chat.postMessage", message)
+			if err != nil {
+				return nil, err
+			}
+			return &db.SlackMessage{Ts: ts, LastEventID: args.EventID}, nil
+		}
+		if args.EventID != 0 && args.EventID <= stored.LastEventID {
+			// A retried job whose message was posted, or a change the message already shows a later change of
+			w.logger.Info("skipping slack notification of an older change of the incident", zap.Int64("incident", incident.ID), zap.Int64("event", args.EventID))
+			return nil, nil
+		}
+		if args.event() == api.IncidentChangeEventCreated {
+			// The job is retried after the message was posted
+			return nil, nil
+		}
+
+		parent := newIncidentMessage(statusPageName, *incident, api.IncidentChangeEventCreated)
+		parent.Channel = args.Channel
+		parent.Ts = stored.Ts
+		if _, err := w.callApi(ctx, botToken, "chat.update", parent); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) || (apiErr.Code != "message_not_found" && apiErr.Code != "cant_update_message") {
+				return nil, err
+			}
+			// The message was deleted, or posted by another app, the reply is still worth posting
+			w.logger.Warn("failed to update slack message", zap.Int64("incident", incident.ID), zap.Error(err))
+		}
+
+		reply := newThreadReply(args.Incident, args.event(), args.PreviousImpact)
+		reply.Channel = args.Channel
+		reply.ThreadTs = stored.Ts
+		if _, err := w.callApi(ctx, botToken, "chat.postMessage", reply); err != nil {
+			return nil, err
+		}
+		if args.EventID == 0 {
+			return nil, nil
+		}
+		return &db.SlackMessage{Ts: stored.Ts, LastEventID: args.EventID}, nil
+	})
+}
+
+type apiResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+	Ts    string `json:"ts"`
+}
+
+// apiError is an error returned by a method of the slack web api, see https://api.slack.com/web#evaluating_responses
+type apiError struct {
+	Method string
+	Code   string
+}
+
+func (e *apiError) Error() string {
+	return "slack " + e.Method + " failed: " + e.Code
+}
+
+// callApi calls the method of the slack web api with the message and returns the ts of the message
+func (w *SlackWebhookWorker) callApi(ctx context.Context, botToken string, method string, message Message) (string, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal message")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.apiUrl+"/"+method, bytes.NewBuffer(body))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+botToken)
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Rate limited requests are retried with the backoff of the job
+		return "", errors.Errorf("expected status code 200 from slack %s, got %d", method, resp.StatusCode)
+	}
+
+	var response apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", errors.Wrapf(err, "failed to decode the response of slack %s", method)
+	}
+	if !response.Ok {
+		return "", &apiError{Method: method, Code: response.Error}
+	}
+	return response.Ts, nil
+}
+
 func (w *SlackWebhookWorker) Timeout(job *river.Job[SlackWebhookArgs]) time.Duration {
 	return time.Minute * 5
 }
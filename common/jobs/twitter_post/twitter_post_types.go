@@ -8,6 +8,10 @@ import (
 type TwitterPostArgs struct {
 	Incident   api.Incident `json:"incident"`
 	WebhookUrl string       `json:"webhook_url"`
+	// Event is what happened to the incident, the jobs queued before events were sent are created events
+	Event api.IncidentChangeEventType `json:"event,omitempty"`
+	// PreviousImpact is the impact of the incident before an impact_changed event
+	PreviousImpact api.Impact `json:"previous_impact,omitempty"`
 }
 
 func (TwitterPostArgs) Kind() string {
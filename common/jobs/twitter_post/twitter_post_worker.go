@@ -38,7 +38,7 @@ func (w *TwitterPostWorker) Work(ctx context.Context, job *river.Job[TwitterPost
 		w.logger.Error("webhook url is empty")
 		return nil
 	}
-	tweet, err := generateTweet(w.db, job.Args.Incident)
+	tweet, err := generateTweet(w.db, job.Args)
 	if err != nil {
 		return errors.Wrap(err, "failed to generate tweet")
 	}
@@ -60,22 +60,40 @@ func (w *TwitterPostWorker) Work(ctx context.Context, job *river.Job[TwitterPost
 	return nil
 }
 
-func generateTweet(db *db.DbClient, incident api.Incident) (string, error) {
+func generateTweet(db *db.DbClient, args TwitterPostArgs) (string, error) {
+	incident := args.Incident
 	// Get the status page of the incident
 	statusPage, err := db.GetStatusPage(context.Background(), incident.StatusPageUrl)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get status page")
 	}
 	// Tweet format
-	// {Status page Name} Incident - Is {Status page Name} down?
+	// {Headline of the event}
 	// {Incident Deep Link}
 	// https://metoro.io/statusphere/status/{statusPageName}
 
-	tweet := fmt.Sprintf(`🔥 %s Incident - Is %s down? 🔥\r\rTitle: %s\r\rIncident Deeplink: %s\r\rStatusphere: https://metoro.io/statusphere/status/%s\r\r#outage #incident`, statusPage.Name, statusPage.Name, incident.Title, incident.DeepLink, statusPage.Name)
+	tweet := fmt.Sprintf(`%s\r\rTitle: %s\r\rIncident Deeplink: %s\r\rStatusphere: https://metoro.io/statusphere/status/%s\r\r#outage #incident`, tweetHeadline(statusPage.Name, args), incident.Title, incident.DeepLink, statusPage.Name)
 
 	return tweet, nil
 }
 
+// tweetHeadline returns the first line of the tweet, which says what happened to the incident
+func tweetHeadline(statusPageName string, args TwitterPostArgs) string {
+	switch args.Event {
+	case api.IncidentChangeEventImpactChanged:
+		if args.PreviousImpact != "" {
+			return fmt.Sprintf("⚠️ %s Incident - Impact changed from %s to %s ⚠️", statusPageName, args.PreviousImpact, args.Incident.Impact)
+		}
+		return fmt.Sprintf("⚠️ %s Incident - Impact is now %s ⚠️", statusPageName, args.Incident.Impact)
+	case api.IncidentChangeEventNewUpdate:
+		return fmt.Sprintf("📣 %s Incident - New update 📣", statusPageName)
+	case api.IncidentChangeEventResolved:
+		return fmt.Sprintf("✅ %s Incident - Resolved ✅", statusPageName)
+	default:
+		return fmt.Sprintf("🔥 %s Incident - Is %s down? 🔥", statusPageName, statusPageName)
+	}
+}
+
 func (w *TwitterPostWorker) Timeout(job *river.Job[TwitterPostArgs]) time.Duration {
 	return time.Second * 30
 }
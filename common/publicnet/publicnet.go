@@ -0,0 +1,66 @@
+package publicnet
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NewHttpClient returns an http client that refuses to connect to a private address unless private addresses are
+// allowed, which also covers the redirects and the host names that resolve to another address once checked
+func NewHttpClient(allowPrivateAddresses bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivateAddresses {
+		dialer.Control = RefusePrivateAddresses
+		// A proxy is usually on a private address itself
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// RefusePrivateAddresses is the control function of a dialer that refuses to connect to a private address
+func RefusePrivateAddresses(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || IsPrivateAddress(ip) {
+		return errors.Errorf("connections to the private address %s are not allowed", host)
+	}
+	return nil
+}
+
+// CheckUrl returns an error if the host of the url is or resolves to a private address
+// A host that does not resolve is left to the request to report
+func CheckUrl(ctx context.Context, rawUrl string) error {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return errors.New("url must be a valid url")
+	}
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsedUrl.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, address := range addresses {
+		if IsPrivateAddress(address.IP) {
+			return errors.Errorf("%s resolves to the private address %s", parsedUrl.Hostname(), address.IP)
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, some clouds serve their metadata from it
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPrivateAddress returns whether the address is not on the internet, e.g. the database or the metadata of the cloud
+func IsPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
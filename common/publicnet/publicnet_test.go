@@ -0,0 +1,31 @@
+package publicnet
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestRefusePrivateAddresses(t *testing.T) {
+	// The dialer refuses the private addresses that a check of the url did not see, e.g. after a redirect
+	if err := RefusePrivateAddresses("tcp", "192.168.1.1:80", nil); err == nil {
+		t.Error("expected the dialer to refuse a private address")
+	}
+	if err := RefusePrivateAddresses("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("expected a public address to be allowed, got %v", err)
+	}
+	if !IsPrivateAddress(net.ParseIP("100.100.100.200")) || IsPrivateAddress(net.ParseIP("2606:4700::1111")) {
+		t.Error("unexpected private address classification")
+	}
+}
+
+func TestCheckUrl(t *testing.T) {
+	for _, target := range []string{"https://127.0.0.1:8888/health", "http://169.254.169.254/latest/meta-data", "https://10.0.0.5", "https://[::1]:5432"} {
+		if err := CheckUrl(context.Background(), target); err == nil {
+			t.Errorf("expected %s to be refused", target)
+		}
+	}
+	if err := CheckUrl(context.Background(), "https://93.184.216.34/hooks"); err != nil {
+		t.Errorf("expected a public address to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/uptime"
+)
+
+// maxTopComponents is how many of the most affected components a report has
+const maxTopComponents = 10
+
+type ImpactCounts struct {
+	Critical    int `json:"critical"`
+	Major       int `json:"major"`
+	Minor       int `json:"minor"`
+	None        int `json:"none"`
+	Maintenance int `json:"maintenance"`
+}
+
+func (c *ImpactCounts) add(impact api.Impact) {
+	switch impact {
+	case api.ImpactCritical:
+		c.Critical++
+	case api.ImpactMajor:
+		c.Major++
+	case api.ImpactMinor:
+		c.Minor++
+	case api.ImpactMaintenance:
+		c.Maintenance++
+	default:
+		c.None++
+	}
+}
+
+// Outage is the longest outage of a period
+type Outage struct {
+	IncidentID int64      `json:"incidentId"`
+	Title      string     `json:"title"`
+	Impact     api.Impact `json:"impact"`
+	StartTime  time.Time  `json:"startTime"`
+	// DurationSeconds is up to now for an ongoing outage
+	DurationSeconds float64 `json:"durationSeconds"`
+	Ongoing         bool    `json:"ongoing"`
+}
+
+type ComponentStats struct {
+	Component     string `json:"component"`
+	IncidentCount int    `json:"incidentCount"`
+	// DowntimeSeconds is weighted like the uptime, a partial outage counts for 30% of its time
+	DowntimeSeconds float64 `json:"downtimeSeconds"`
+}
+
+type MonthStats struct {
+	Month                    time.Time `json:"month"`
+	IncidentCount            int       `json:"incidentCount"`
+	MeanTimeToResolveSeconds *float64  `json:"meanTimeToResolveSeconds"`
+	DowntimeSeconds          float64   `json:"downtimeSeconds"`
+	// IncidentCountChange and DowntimeSecondsChange are the changes from the previous month, nil for the first month
+	IncidentCountChange   *int     `json:"incidentCountChange"`
+	DowntimeSecondsChange *float64 `json:"downtimeSecondsChange"`
+}
+
+// Report is the reliability of a status page over a period
+// The incidents are attributed to the period, and month, they started in, the downtime is the part of the outages within them
+type Report struct {
+	IncidentCount int          `json:"incidentCount"`
+	ByImpact      ImpactCounts `json:"byImpact"`
+	// ResolvedCount and OngoingCount split the incidents that are not maintenance
+	ResolvedCount int `json:"resolvedCount"`
+	OngoingCount  int `json:"ongoingCount"`
+	// MeanTimeToResolveSeconds and MedianTimeToResolveSeconds are nil if no incident was resolved
+	MeanTimeToResolveSeconds   *float64 `json:"meanTimeToResolveSeconds"`
+	MedianTimeToResolveSeconds *float64 `json:"medianTimeToResolveSeconds"`
+	// LongestOutage is nil if there was no outage
+	LongestOutage        *Outage          `json:"longestOutage"`
+	FullOutageSeconds    float64          `json:"fullOutageSeconds"`
+	PartialOutageSeconds float64          `json:"partialOutageSeconds"`
+	DowntimeSeconds      float64          `json:"downtimeSeconds"`
+	UptimePercentage     float64          `json:"uptimePercentage"`
+	TopComponents        []ComponentStats `json:"topComponents"`
+	Months               []MonthStats     `json:"months"`
+}
+
+// Compute returns the report of the incidents of a status page between from and to, only the part of it before now is taken into account
+// The incidents must overlap the period, those that started before it only count towards the downtime
+//
+// Incidents without an end time are resolved with their last update if it says so, otherwise they are ongoing:
+// they have no time to resolve, and their outage lasts until now
+func Compute(incidents []api.Incident, from time.Time, to time.Time, now time.Time) Report {
+	if to.After(now) {
+		to = now
+	}
+	report := Report{
+		TopComponents: []ComponentStats{},
+		Months:        []MonthStats{},
+	}
+
+	started := startedBetween(incidents, from, to)
+	report.IncidentCount = len(started)
+	for _, incident := range started {
+		report.ByImpact.add(incident.Impact)
+	}
+	resolveSeconds, ongoing := timesToResolve(started, now)
+	report.ResolvedCount = len(resolveSeconds)
+	report.OngoingCount = ongoing
+	report.MeanTimeToResolveSeconds = mean(resolveSeconds)
+	report.MedianTimeToResolveSeconds = median(resolveSeconds)
+	report.LongestOutage = longestOutage(started, now)
+
+	report.FullOutageSeconds, report.PartialOutageSeconds = uptime.OutageSeconds(incidents, from, to, now)
+	report.DowntimeSeconds = downtimeSeconds(report.FullOutageSeconds, report.PartialOutageSeconds)
+	report.UptimePercentage = uptime.Percentage(to.Sub(from).Seconds(), report.FullOutageSeconds, report.PartialOutageSeconds)
+
+	report.TopComponents = topComponents(started, incidents, from, to, now)
+	report.Months = months(incidents, from, to, now)
+	return report
+}
+
+// Summary is the part of a report that ranks status pages against each other
+type Summary struct {
+	IncidentCount            int      `json:"incidentCount"`
+	OutageCount              int      `json:"outageCount"`
+	MeanTimeToResolveSeconds *float64 `json:"meanTimeToResolveSeconds"`
+	DowntimeSeconds          float64  `json:"downtimeSeconds"`
+	UptimePercentage         float64  `json:"uptimePercentage"`
+}
+
+// Summarize returns the summary of the incidents of a status page between from and to, see Compute
+func Summarize(incidents []api.Incident, from time.Time, to time.Time, now time.Time) Summary {
+	if to.After(now) {
+		to = now
+	}
+	started := startedBetween(incidents, from, to)
+	summary := Summary{IncidentCount: len(started)}
+	for _, incident := range started {
+		if isOutage(incident) {
+			summary.OutageCount++
+		}
+	}
+	resolveSeconds, _ := timesToResolve(started, now)
+	summary.MeanTimeToResolveSeconds = mean(resolveSeconds)
+	full, partial := uptime.OutageSeconds(incidents, from, to, now)
+	summary.DowntimeSeconds = downtimeSeconds(full, partial)
+	summary.UptimePercentage = uptime.Percentage(to.Sub(from).Seconds(), full, partial)
+	return summary
+}
+
+// startedBetween returns the incidents that started between from and to
+func startedBetween(incidents []api.Incident, from time.Time, to time.Time) []api.Incident {
+	var started []api.Incident
+	for _, incident := range incidents {
+		if !incident.StartTime.Before(from) && incident.StartTime.Before(to) {
+			started = append(started, incident)
+		}
+	}
+	return started
+}
+
+// timesToResolve returns the times to resolve of the resolved incidents and the number of ongoing ones, maintenance is left out
+func timesToResolve(incidents []api.Incident, now time.Time) ([]float64, int) {
+	var resolveSeconds []float64
+	ongoing := 0
+	for _, incident := range incidents {
+		if incident.Impact == api.ImpactMaintenance {
+			continue
+		}
+		seconds, resolved := timeToResolve(incident, now)
+		if !resolved {
+			ongoing++
+			continue
+		}
+		resolveSeconds = append(resolveSeconds, seconds)
+	}
+	return resolveSeconds, ongoing
+}
+
+func isOutage(incident api.Incident) bool {
+	return incident.Impact == api.ImpactCritical || incident.Impact == api.ImpactMajor || incident.Impact == api.ImpactMinor
+}
+
+// timeToResolve returns how long the incident took to resolve, and false if it is still ongoing
+// An end time before the start time is taken as resolved immediately
+func timeToResolve(incident api.Incident, now time.Time) (float64, bool) {
+	if incident.EndTime == nil && api.DeriveIncidentState(incident, now) != api.IncidentStateResolved {
+		return 0, false
+	}
+	start, end := uptime.IncidentWindow(incident, now)
+	if end.After(now) {
+		// The end time is announced but has not passed yet
+		return 0, false
+	}
+	return math.Max(end.Sub(start).Seconds(), 0), true
+}
+
+// longestOutage returns the longest of the incidents that are critical, major or minor, nil if there is none
+func longestOutage(incidents []api.Incident, now time.Time) *Outage {
+	var longest *Outage
+	for _, incident := range incidents {
+		if !isOutage(incident) {
+			continue
+		}
+		start, end := uptime.IncidentWindow(incident, now)
+		_, resolved := timeToResolve(incident, now)
+		if end.After(now) {
+			end = now
+		}
+		duration := math.Max(end.Sub(start).Seconds(), 0)
+		if longest != nil && duration <= longest.DurationSeconds {
+			continue
+		}
+		longest = &Outage{
+			IncidentID:      incident.ID,
+			Title:           incident.Title,
+			Impact:          incident.Impact,
+			StartTime:       incident.StartTime,
+			DurationSeconds: duration,
+			Ongoing:         !resolved,
+		}
+	}
+	return longest
+}
+
+// topComponents returns the components affected by the most incidents started in the period, with their downtime in the period
+func topComponents(started []api.Incident, incidents []api.Incident, from time.Time, to time.Time, now time.Time) []ComponentStats {
+	counts := make(map[string]int)
+	for _, incident := range started {
+		for _, component := range incident.Components {
+			counts[component]++
+		}
+	}
+
+	components := make([]ComponentStats, 0, len(counts))
+	for component, count := range counts {
+		var componentIncidents []api.Incident
+		for _, incident := range incidents {
+			for _, c := range incident.Components {
+				if c == component {
+					componentIncidents = append(componentIncidents, incident)
+					break
+				}
+			}
+		}
+		full, partial := uptime.OutageSeconds(componentIncidents, from, to, now)
+		components = append(components, ComponentStats{Component: component, IncidentCount: count, DowntimeSeconds: downtimeSeconds(full, partial)})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].IncidentCount != components[j].IncidentCount {
+			return components[i].IncidentCount > components[j].IncidentCount
+		}
+		if components[i].DowntimeSeconds != components[j].DowntimeSeconds {
+			return components[i].DowntimeSeconds > components[j].DowntimeSeconds
+		}
+		return components[i].Component < components[j].Component
+	})
+	if len(components) > maxTopComponents {
+		components = components[:maxTopComponents]
+	}
+	return components
+}
+
+// months returns the stats of each UTC month of the period, the first and last months only cover their part of the period
+func months(incidents []api.Incident, from time.Time, to time.Time, now time.Time) []MonthStats {
+	result := []MonthStats{}
+	for monthStart := uptime.StartOfMonth(from); monthStart.Before(to); monthStart = monthStart.AddDate(0, 1, 0) {
+		start, end := monthStart, monthStart.AddDate(0, 1, 0)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		started := startedBetween(incidents, start, end)
+		resolveSeconds, _ := timesToResolve(started, now)
+		month := MonthStats{Month: monthStart, IncidentCount: len(started), MeanTimeToResolveSeconds: mean(resolveSeconds)}
+		month.DowntimeSeconds = downtimeSeconds(uptime.OutageSeconds(incidents, start, end, now))
+
+		if len(result) > 0 {
+			previous := result[len(result)-1]
+			incidentCountChange := month.IncidentCount - previous.IncidentCount
+			downtimeSecondsChange := month.DowntimeSeconds - previous.DowntimeSeconds
+			month.IncidentCountChange = &incidentCountChange
+			month.DowntimeSecondsChange = &downtimeSecondsChange
+		}
+		result = append(result, month)
+	}
+	return result
+}
+
+// downtimeSeconds weights the outage seconds like the uptime percentage does
+func downtimeSeconds(fullOutageSeconds float64, partialOutageSeconds float64) float64 {
+	return fullOutageSeconds + partialOutageSeconds*uptime.PartialOutageWeight
+}
+
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	result := sum / float64(len(values))
+	return &result
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	result := sorted[middle]
+	if len(sorted)%2 == 0 {
+		result = (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return &result
+}
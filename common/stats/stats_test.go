@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+var from = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func at(hours int) time.Time {
+	return from.Add(time.Duration(hours) * time.Hour)
+}
+
+func endingAt(hours int) *time.Time {
+	t := at(hours)
+	return &t
+}
+
+func TestComputeTimeToResolve(t *testing.T) {
+	now := at(24 * 60)
+	incidents := []api.Incident{
+		{ID: 1, StartTime: at(1), EndTime: endingAt(2), Impact: api.ImpactMajor},
+		{ID: 2, StartTime: at(10), EndTime: endingAt(14), Impact: api.ImpactCritical},
+		{ID: 3, StartTime: at(20), EndTime: endingAt(22), Impact: api.ImpactMinor},
+		// Resolved by its last update without an end time
+		{ID: 4, StartTime: at(30), Impact: api.ImpactMinor, Events: api.IncidentEventArray{
+			api.NewIncidentEvent("Investigating", "", at(30)),
+			api.NewIncidentEvent("Resolved", "", at(33)),
+		}},
+		// Ongoing, it has no time to resolve
+		{ID: 5, StartTime: at(24 * 30), Impact: api.ImpactMajor},
+		// Maintenance is not resolved like an outage
+		{ID: 6, StartTime: at(40), EndTime: endingAt(50), Impact: api.ImpactMaintenance},
+		// Started before the period
+		{ID: 7, StartTime: at(-5), EndTime: endingAt(1), Impact: api.ImpactCritical},
+	}
+
+	report := Compute(incidents, from, at(24*31), now)
+	if report.IncidentCount != 6 || report.ResolvedCount != 4 || report.OngoingCount != 1 {
+		t.Errorf("unexpected counts: %d incidents, %d resolved, %d ongoing", report.IncidentCount, report.ResolvedCount, report.OngoingCount)
+	}
+	if report.ByImpact != (ImpactCounts{Critical: 1, Major: 2, Minor: 2, Maintenance: 1}) {
+		t.Errorf("unexpected counts by impact %+v", report.ByImpact)
+	}
+	if report.MeanTimeToResolveSeconds == nil || *report.MeanTimeToResolveSeconds != 2.5*3600 {
+		t.Errorf("expected a mean time to resolve of 2.5 hours, got %v", report.MeanTimeToResolveSeconds)
+	}
+	if report.MedianTimeToResolveSeconds == nil || *report.MedianTimeToResolveSeconds != 2.5*3600 {
+		t.Errorf("expected a median time to resolve of 2.5 hours, got %v", report.MedianTimeToResolveSeconds)
+	}
+}
+
+func TestComputeOngoingOutage(t *testing.T) {
+	now := at(48)
+	incidents := []api.Incident{
+		{ID: 1, StartTime: at(1), EndTime: endingAt(3), Impact: api.ImpactCritical},
+		{ID: 2, StartTime: at(40), Impact: api.ImpactCritical, Title: "Down"},
+	}
+
+	report := Compute(incidents, from, at(24*31), now)
+	if report.LongestOutage == nil || report.LongestOutage.IncidentID != 2 || !report.LongestOutage.Ongoing || report.LongestOutage.DurationSeconds != 8*3600 {
+		t.Errorf("expected the ongoing outage to be the longest so far, got %+v", report.LongestOutage)
+	}
+	// The period ends now, the ongoing outage counts until then
+	if report.FullOutageSeconds != 10*3600 {
+		t.Errorf("expected 10 hours of full outage, got %v", report.FullOutageSeconds)
+	}
+	expected := 100 * (1 - 10.0/48)
+	if math.Abs(report.UptimePercentage-expected) > 1e-9 {
+		t.Errorf("expected uptime %v, got %v", expected, report.UptimePercentage)
+	}
+	if report.MeanTimeToResolveSeconds == nil || *report.MeanTimeToResolveSeconds != 2*3600 {
+		t.Errorf("expected only the resolved outage in the time to resolve, got %v", report.MeanTimeToResolveSeconds)
+	}
+}
+
+func TestComputeWithoutIncidents(t *testing.T) {
+	report := Compute(nil, from, at(24), at(48))
+	if report.MeanTimeToResolveSeconds != nil || report.MedianTimeToResolveSeconds != nil || report.LongestOutage != nil {
+		t.Errorf("expected no time to resolve nor outage, got %+v", report)
+	}
+	if report.UptimePercentage != 100 || len(report.TopComponents) != 0 || len(report.Months) != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestComputeComponentsAndMonths(t *testing.T) {
+	april := 24 * 31
+	now := at(april + 24*30)
+	incidents := []api.Incident{
+		{StartTime: at(1), EndTime: endingAt(2), Impact: api.ImpactCritical, Components: []string{"API", "Dashboard"}},
+		{StartTime: at(10), EndTime: endingAt(11), Impact: api.ImpactMinor, Components: []string{"API"}},
+		// Spans the end of march, its downtime is split between the months
+		{StartTime: at(april - 1), EndTime: endingAt(april + 1), Impact: api.ImpactCritical, Components: []string{"API"}},
+	}
+
+	report := Compute(incidents, from, now, now)
+	if len(report.TopComponents) != 2 || report.TopComponents[0].Component != "API" || report.TopComponents[0].IncidentCount != 3 {
+		t.Fatalf("expected API to be the most affected component, got %+v", report.TopComponents)
+	}
+	if expected := 3*3600 + 3600*0.3; report.TopComponents[0].DowntimeSeconds != expected {
+		t.Errorf("expected %v seconds of downtime for API, got %v", expected, report.TopComponents[0].DowntimeSeconds)
+	}
+
+	if len(report.Months) != 2 {
+		t.Fatalf("expected march and april, got %+v", report.Months)
+	}
+	march, aprilStats := report.Months[0], report.Months[1]
+	if march.IncidentCount != 3 || march.IncidentCountChange != nil || march.DowntimeSeconds != 2*3600+3600*0.3 {
+		t.Errorf("unexpected march %+v", march)
+	}
+	if aprilStats.IncidentCount != 0 || aprilStats.IncidentCountChange == nil || *aprilStats.IncidentCountChange != -3 || aprilStats.DowntimeSeconds != 3600 {
+		t.Errorf("unexpected april %+v", aprilStats)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	incidents := []api.Incident{
+		{StartTime: at(1), EndTime: endingAt(3), Impact: api.ImpactCritical},
+		{StartTime: at(5), EndTime: endingAt(6), Impact: api.ImpactNone},
+		{StartTime: at(8), EndTime: endingAt(9), Impact: api.ImpactMaintenance},
+	}
+
+	summary := Summarize(incidents, from, at(24), at(48))
+	if summary.IncidentCount != 3 || summary.OutageCount != 1 || summary.DowntimeSeconds != 2*3600 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.MeanTimeToResolveSeconds == nil || *summary.MeanTimeToResolveSeconds != 1.5*3600 {
+		t.Errorf("expected the incidents that are not maintenance in the time to resolve, got %v", summary.MeanTimeToResolveSeconds)
+	}
+}
@@ -0,0 +1,94 @@
+package status_pages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the content of a status pages file
+type File struct {
+	StatusPages []FileStatusPage `yaml:"statusPages" json:"statusPages"`
+}
+
+// FileStatusPage is the definition of a status page in a status pages file, the fields are the ones of the admin api
+type FileStatusPage struct {
+	URL                   string                 `yaml:"url" json:"url"`
+	Name                  string                 `yaml:"name" json:"name"`
+	PreferredScraper      string                 `yaml:"preferredScraper" json:"preferredScraper"`
+	Method                api.HttpMethod         `yaml:"httpMethod" json:"httpMethod"`
+	Headers               map[string]string      `yaml:"headers" json:"headers"`
+	RequestPayload        map[string]interface{} `yaml:"payload" json:"payload"`
+	ValidationRules       map[string]interface{} `yaml:"rules" json:"rules"`
+	SlaTarget             *float64               `yaml:"slaTarget" json:"slaTarget"`
+	ScrapeIntervalSeconds int                    `yaml:"scrapeIntervalSeconds" json:"scrapeIntervalSeconds"`
+}
+
+func (p FileStatusPage) toStatusPage() api.StatusPage {
+	return api.StatusPage{
+		URL:                   strings.TrimSpace(p.URL),
+		Name:                  strings.TrimSpace(p.Name),
+		PreferredScraper:      p.PreferredScraper,
+		Method:                p.Method,
+		Headers:               p.Headers,
+		RequestPayload:        p.RequestPayload,
+		ValidationRules:       p.ValidationRules,
+		SlaTarget:             p.SlaTarget,
+		ScrapeIntervalSeconds: p.ScrapeIntervalSeconds,
+		Source:                api.StatusPageSourceFile,
+	}
+}
+
+// LoadFile reads the status pages of a YAML (.yaml, .yml) or JSON (.json) file
+// The whole file is rejected if it has unknown fields, the same url twice or a status page that does not pass Validate
+func LoadFile(path string, providerNames []string) ([]api.StatusPage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read status pages file")
+	}
+	return ParseFile(data, filepath.Ext(path), providerNames)
+}
+
+// ParseFile parses the content of a status pages file with the given extension, see LoadFile
+func ParseFile(data []byte, extension string, providerNames []string) ([]api.StatusPage, error) {
+	var file File
+	switch strings.ToLower(extension) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, errors.Wrap(err, "invalid status pages file")
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return nil, errors.Wrap(err, "invalid status pages file")
+		}
+	default:
+		return nil, fmt.Errorf("status pages file must be .yaml, .yml or .json, not %q", extension)
+	}
+
+	statusPages := make([]api.StatusPage, 0, len(file.StatusPages))
+	urls := make(map[string]bool)
+	for i, filePage := range file.StatusPages {
+		statusPage := filePage.toStatusPage()
+		if err := Validate(statusPage, providerNames); err != nil {
+			return nil, fmt.Errorf("status page %d (%s): %v", i+1, statusPage.URL, err)
+		}
+		if urls[statusPage.URL] {
+			return nil, fmt.Errorf("status page %d: %s is defined more than once", i+1, statusPage.URL)
+		}
+		urls[statusPage.URL] = true
+		statusPages = append(statusPages, statusPage)
+	}
+	return statusPages, nil
+}
@@ -0,0 +1,75 @@
+package status_pages
+
+import (
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+func TestParseFileYaml(t *testing.T) {
+	data := []byte(`
+statusPages:
+  - url: https://www.githubstatus.com
+    name: GitHub
+    preferredScraper: Atlassian
+    slaTarget: 99.9
+  - url: https://api.example.com/health
+    name: Example API
+    preferredScraper: REST
+    httpMethod: POST
+    headers:
+      Authorization: Bearer {env.EXAMPLE_TOKEN}
+    payload:
+      query:
+        limit: 1
+    rules:
+      status: OK
+    scrapeIntervalSeconds: 60
+`)
+	statusPages, err := ParseFile(data, ".yaml", testProviderNames)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statusPages) != 2 {
+		t.Fatalf("expected 2 status pages, got %d", len(statusPages))
+	}
+	if statusPages[0].Source != api.StatusPageSourceFile || *statusPages[0].SlaTarget != 99.9 {
+		t.Errorf("unexpected status page: %+v", statusPages[0])
+	}
+	rest := statusPages[1]
+	if rest.Method != api.MethodPost || rest.Headers["Authorization"] != "Bearer {env.EXAMPLE_TOKEN}" || rest.ValidationRules["status"] != "OK" {
+		t.Errorf("unexpected status page: %+v", rest)
+	}
+	if _, ok := rest.RequestPayload["query"].(map[string]interface{}); !ok {
+		t.Errorf("expected a nested payload, got %T", rest.RequestPayload["query"])
+	}
+}
+
+func TestParseFileJson(t *testing.T) {
+	statusPages, err := ParseFile([]byte(`{"statusPages": [{"url": "https://www.githubstatus.com", "name": "GitHub"}]}`), ".json", testProviderNames)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statusPages) != 1 || statusPages[0].Name != "GitHub" {
+		t.Errorf("unexpected status pages: %+v", statusPages)
+	}
+}
+
+func TestParseFileRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		extension string
+		data      string
+	}{
+		{name: "unknown field", extension: ".yaml", data: "statusPages:\n  - url: https://www.githubstatus.com\n    name: GitHub\n    scraper: Atlassian\n"},
+		{name: "unknown json field", extension: ".json", data: `{"pages": []}`},
+		{name: "duplicate url", extension: ".yml", data: "statusPages:\n  - url: https://www.githubstatus.com\n    name: GitHub\n  - url: https://www.githubstatus.com\n    name: GitHub again\n"},
+		{name: "invalid status page", extension: ".yaml", data: "statusPages:\n  - url: https://www.githubstatus.com\n"},
+		{name: "unknown extension", extension: ".toml", data: ""},
+	}
+	for _, test := range tests {
+		if _, err := ParseFile([]byte(test.data), test.extension, testProviderNames); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package status_pages
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxNameLength         = 200
+	minScrapeIntervalSecs = 60
+	maxScrapeIntervalSecs = 24 * 60 * 60
+	restScraper           = "REST"
+)
+
+// Validate checks that the definition of the status page can be scraped
+// providerNames are the names of the providers the preferred scraper can be one of
+func Validate(page api.StatusPage, providerNames []string) error {
+	parsed, err := url.Parse(page.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	name := strings.TrimSpace(page.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+
+	if page.PreferredScraper != "" {
+		known := false
+		for _, providerName := range providerNames {
+			if providerName == page.PreferredScraper {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("preferredScraper must be one of %s", strings.Join(providerNames, ", "))
+		}
+	}
+
+	switch page.Method {
+	case "", api.MethodGet, api.MethodPost, api.MethodHead:
+	default:
+		return fmt.Errorf("httpMethod must be one of %s, %s or %s", api.MethodGet, api.MethodPost, api.MethodHead)
+	}
+	if len(page.RequestPayload) > 0 && page.Method != api.MethodPost {
+		return fmt.Errorf("payload can only be sent with the %s method", api.MethodPost)
+	}
+	for key := range page.Headers {
+		if strings.TrimSpace(key) == "" || strings.ContainsAny(key, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", key)
+		}
+	}
+	// Only the REST provider sends the request defined by the status page and checks its response
+	if page.PreferredScraper != restScraper && (page.Method != "" || len(page.Headers) > 0 || len(page.RequestPayload) > 0 || len(page.ValidationRules) > 0) {
+		return fmt.Errorf("httpMethod, headers, payload and rules can only be set for the %s scraper", restScraper)
+	}
+	// The response of a HEAD request has no body to check
+	if len(page.ValidationRules) > 0 && (page.Method == "" || page.Method == api.MethodHead) {
+		return errors.New("rules need a GET or POST httpMethod")
+	}
+
+	if page.SlaTarget != nil && (*page.SlaTarget <= 0 || *page.SlaTarget > 100) {
+		return errors.New("slaTarget must be a percentage greater than 0 and at most 100")
+	}
+	if page.ScrapeIntervalSeconds != 0 && (page.ScrapeIntervalSeconds < minScrapeIntervalSecs || page.ScrapeIntervalSeconds > maxScrapeIntervalSecs) {
+		return fmt.Errorf("scrapeIntervalSeconds must be 0 for the default or between %d and %d", minScrapeIntervalSecs, maxScrapeIntervalSecs)
+	}
+	return nil
+}
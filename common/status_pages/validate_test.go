@@ -0,0 +1,40 @@
+package status_pages
+
+import (
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+var testProviderNames = []string{"Atlassian", "RSS", "CKP_RSS", "REST"}
+
+func TestStatusPagesAreValid(t *testing.T) {
+	for _, page := range StatusPages {
+		if err := Validate(page, testProviderNames); err != nil {
+			t.Errorf("%s: %v", page.URL, err)
+		}
+	}
+}
+
+func TestValidateRejectsInvalidStatusPages(t *testing.T) {
+	sla := 100.5
+	tests := []struct {
+		name string
+		page api.StatusPage
+	}{
+		{name: "relative url", page: api.StatusPage{URL: "status.example.com", Name: "Example"}},
+		{name: "missing name", page: api.StatusPage{URL: "https://status.example.com", Name: " "}},
+		{name: "unknown scraper", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", PreferredScraper: "HTML"}},
+		{name: "unknown method", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", PreferredScraper: "REST", Method: "PUT"}},
+		{name: "payload without post", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", PreferredScraper: "REST", Method: api.MethodGet, RequestPayload: api.JSONStruct{"a": 1}}},
+		{name: "headers without rest", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", PreferredScraper: "Atlassian", Headers: api.JSONMap{"X-Test": "1"}}},
+		{name: "rules with head", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", PreferredScraper: "REST", Method: api.MethodHead, ValidationRules: api.JSONStruct{"status": "OK"}}},
+		{name: "sla above 100", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", SlaTarget: &sla}},
+		{name: "interval too short", page: api.StatusPage{URL: "https://status.example.com", Name: "Example", ScrapeIntervalSeconds: 10}},
+	}
+	for _, test := range tests {
+		if err := Validate(test.page, testProviderNames); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
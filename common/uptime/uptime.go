@@ -0,0 +1,201 @@
+package uptime
+
+import (
+	"sort"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+// PartialOutageWeight is the share of a partial outage (major or minor impact) that counts as downtime
+// This follows the convention of atlassian statuspage, where a partial outage counts as 30% of a full outage
+const PartialOutageWeight = 0.3
+
+// PageComponent is the component used for the uptime of the whole status page
+const PageComponent = ""
+
+// outageWeight returns how much of the time covered by an incident with the given impact counts as downtime
+// Maintenance is planned so it does not count against the uptime
+func outageWeight(impact api.Impact) float64 {
+	switch impact {
+	case api.ImpactCritical:
+		return 1
+	case api.ImpactMajor, api.ImpactMinor:
+		return PartialOutageWeight
+	default:
+		return 0
+	}
+}
+
+type window struct {
+	start  time.Time
+	end    time.Time
+	weight float64
+}
+
+// IncidentWindow returns the time the incident covered up to now
+// Incidents without an end time are ongoing, unless their updates say they were resolved, in which case they end with the last update
+func IncidentWindow(incident api.Incident, now time.Time) (time.Time, time.Time) {
+	if incident.EndTime != nil {
+		return incident.StartTime, *incident.EndTime
+	}
+	if api.DeriveIncidentState(incident, now) == api.IncidentStateResolved {
+		end := incident.StartTime
+		for _, event := range incident.Events {
+			if event.Time.After(end) {
+				end = event.Time
+			}
+		}
+		return incident.StartTime, end
+	}
+	return incident.StartTime, now
+}
+
+// ComputeDay computes the uptime of a status page and each of its components for the day starting at dayStart
+// The day is the 24 hours following dayStart, only the part of it before now is taken into account
+// It returns nothing if the day has not started yet
+func ComputeDay(statusPageUrl string, incidents []api.Incident, dayStart time.Time, now time.Time) []api.UptimeDay {
+	dayEnd := dayStart.Add(24 * time.Hour)
+	if dayEnd.After(now) {
+		dayEnd = now
+	}
+	if !dayEnd.After(dayStart) {
+		return nil
+	}
+
+	windowsByComponent := map[string][]window{PageComponent: nil}
+	for _, incident := range incidents {
+		w, ok := outageWindow(incident, dayStart, dayEnd, now)
+		if !ok {
+			continue
+		}
+		windowsByComponent[PageComponent] = append(windowsByComponent[PageComponent], w)
+		for _, component := range incident.Components {
+			windowsByComponent[component] = append(windowsByComponent[component], w)
+		}
+	}
+
+	var days []api.UptimeDay
+	for component, windows := range windowsByComponent {
+		full, partial := outageSeconds(windows)
+		periodSeconds := dayEnd.Sub(dayStart).Seconds()
+		days = append(days, api.UptimeDay{
+			StatusPageUrl:        statusPageUrl,
+			Component:            component,
+			Day:                  dayStart,
+			PeriodSeconds:        periodSeconds,
+			FullOutageSeconds:    full,
+			PartialOutageSeconds: partial,
+			UptimePercentage:     Percentage(periodSeconds, full, partial),
+		})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Component < days[j].Component
+	})
+	return days
+}
+
+// OutageSeconds returns the seconds of full and partial outage of the incidents between from and to, before now
+func OutageSeconds(incidents []api.Incident, from time.Time, to time.Time, now time.Time) (float64, float64) {
+	var windows []window
+	for _, incident := range incidents {
+		if w, ok := outageWindow(incident, from, to, now); ok {
+			windows = append(windows, w)
+		}
+	}
+	return outageSeconds(windows)
+}
+
+// outageWindow returns the part of the outage of the incident between from and to, before now
+// It returns false if the incident is not an outage or does not overlap the period
+func outageWindow(incident api.Incident, from time.Time, to time.Time, now time.Time) (window, bool) {
+	weight := outageWeight(incident.Impact)
+	if weight == 0 {
+		return window{}, false
+	}
+	start, end := IncidentWindow(incident, now)
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if end.After(now) {
+		end = now
+	}
+	if !end.After(start) {
+		return window{}, false
+	}
+	return window{start: start, end: end, weight: weight}, true
+}
+
+// Percentage returns the uptime percentage of a period given its full and partial outage seconds
+func Percentage(periodSeconds float64, fullOutageSeconds float64, partialOutageSeconds float64) float64 {
+	if periodSeconds <= 0 {
+		return 100
+	}
+	downtime := fullOutageSeconds + partialOutageSeconds*PartialOutageWeight
+	return 100 * (1 - downtime/periodSeconds)
+}
+
+// outageSeconds returns the seconds of full and partial outage covered by the windows
+// Where windows overlap, the worst one counts
+func outageSeconds(windows []window) (float64, float64) {
+	type boundary struct {
+		at     time.Time
+		weight float64
+		open   bool
+	}
+	var boundaries []boundary
+	for _, w := range windows {
+		boundaries = append(boundaries, boundary{at: w.start, weight: w.weight, open: true}, boundary{at: w.end, weight: w.weight, open: false})
+	}
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i].at.Before(boundaries[j].at)
+	})
+
+	var full, partial float64
+	open := map[float64]int{}
+	for i, b := range boundaries {
+		if b.open {
+			open[b.weight]++
+		} else {
+			open[b.weight]--
+		}
+		if i+1 == len(boundaries) {
+			break
+		}
+		seconds := boundaries[i+1].at.Sub(b.at).Seconds()
+		if seconds <= 0 {
+			continue
+		}
+		if open[1] > 0 {
+			full += seconds
+		} else if open[PartialOutageWeight] > 0 {
+			partial += seconds
+		}
+	}
+	return full, partial
+}
+
+// Aggregate combines consecutive days into a single period, weighting each day by its length
+func Aggregate(days []api.UptimeDay) (periodSeconds float64, fullOutageSeconds float64, partialOutageSeconds float64, percentage float64) {
+	for _, day := range days {
+		periodSeconds += day.PeriodSeconds
+		fullOutageSeconds += day.FullOutageSeconds
+		partialOutageSeconds += day.PartialOutageSeconds
+	}
+	return periodSeconds, fullOutageSeconds, partialOutageSeconds, Percentage(periodSeconds, fullOutageSeconds, partialOutageSeconds)
+}
+
+// StartOfDay returns the start of the UTC day containing t
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// StartOfMonth returns the start of the UTC month containing t
+func StartOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,109 @@
+package uptime
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+)
+
+var day = time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)
+
+func at(hour int) time.Time {
+	return day.Add(time.Duration(hour) * time.Hour)
+}
+
+func endingAt(hour int) *time.Time {
+	t := at(hour)
+	return &t
+}
+
+func findComponent(days []api.UptimeDay, component string) *api.UptimeDay {
+	for i := range days {
+		if days[i].Component == component {
+			return &days[i]
+		}
+	}
+	return nil
+}
+
+func TestComputeDayWeightsImpact(t *testing.T) {
+	incidents := []api.Incident{
+		{StartTime: at(1), EndTime: endingAt(3), Impact: api.ImpactCritical},
+		{StartTime: at(10), EndTime: endingAt(20), Impact: api.ImpactMinor},
+		{StartTime: at(4), EndTime: endingAt(8), Impact: api.ImpactMaintenance},
+		{StartTime: at(4), EndTime: endingAt(8), Impact: api.ImpactNone},
+	}
+
+	days := ComputeDay("https://status.example.com", incidents, day, day.AddDate(0, 0, 2))
+	page := findComponent(days, PageComponent)
+	if page == nil {
+		t.Fatal("expected the uptime of the whole page")
+	}
+	if page.FullOutageSeconds != 2*3600 || page.PartialOutageSeconds != 10*3600 {
+		t.Errorf("unexpected outage seconds: full %v, partial %v", page.FullOutageSeconds, page.PartialOutageSeconds)
+	}
+	expected := 100 * (1 - (2*3600+10*3600*PartialOutageWeight)/(24*3600))
+	if math.Abs(page.UptimePercentage-expected) > 1e-9 {
+		t.Errorf("expected uptime %v, got %v", expected, page.UptimePercentage)
+	}
+}
+
+func TestComputeDayOverlapCountsWorstImpact(t *testing.T) {
+	incidents := []api.Incident{
+		{StartTime: at(0), EndTime: endingAt(6), Impact: api.ImpactMajor},
+		{StartTime: at(2), EndTime: endingAt(4), Impact: api.ImpactCritical},
+		{StartTime: at(3), EndTime: endingAt(5), Impact: api.ImpactMinor},
+	}
+
+	page := findComponent(ComputeDay("url", incidents, day, day.AddDate(0, 0, 2)), PageComponent)
+	if page.FullOutageSeconds != 2*3600 || page.PartialOutageSeconds != 4*3600 {
+		t.Errorf("unexpected outage seconds: full %v, partial %v", page.FullOutageSeconds, page.PartialOutageSeconds)
+	}
+}
+
+func TestComputeDayClipsToDayAndNow(t *testing.T) {
+	now := at(12)
+	incidents := []api.Incident{
+		// Started the day before
+		{StartTime: day.Add(-5 * time.Hour), EndTime: endingAt(1), Impact: api.ImpactCritical},
+		// Ongoing
+		{StartTime: at(11), Impact: api.ImpactCritical, Components: []string{"API"}},
+	}
+
+	days := ComputeDay("url", incidents, day, now)
+	page := findComponent(days, PageComponent)
+	if page.PeriodSeconds != 12*3600 {
+		t.Errorf("expected the period to end now, got %v", page.PeriodSeconds)
+	}
+	if page.FullOutageSeconds != 2*3600 {
+		t.Errorf("expected 2 hours of full outage, got %v", page.FullOutageSeconds)
+	}
+	component := findComponent(days, "API")
+	if component == nil || component.FullOutageSeconds != 3600 {
+		t.Errorf("expected 1 hour of outage for the API component, got %+v", component)
+	}
+
+	if len(ComputeDay("url", incidents, day.AddDate(0, 0, 1), now)) != 0 {
+		t.Errorf("expected no uptime for a day that has not started")
+	}
+}
+
+func TestComputeDayResolvedIncidentWithoutEndTime(t *testing.T) {
+	incidents := []api.Incident{
+		{
+			StartTime: at(1),
+			Impact:    api.ImpactCritical,
+			Events: []api.IncidentEvent{
+				{Title: "Investigating", Time: at(1)},
+				{Title: "Resolved", Time: at(2)},
+			},
+		},
+	}
+
+	page := findComponent(ComputeDay("url", incidents, day, day.AddDate(0, 0, 2)), PageComponent)
+	if page.FullOutageSeconds != 3600 {
+		t.Errorf("expected the incident to end with its last update, got %v seconds of outage", page.FullOutageSeconds)
+	}
+}
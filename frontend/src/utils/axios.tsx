@@ -5,6 +5,10 @@ import MockAdapter from "axios-mock-adapter";
 const axiosServices = axios.create({
   baseURL:
     process.env.NEXT_PUBLIC_REACT_APP_API_URL || "http://127.0.0.1:8888/",
+  // Server side rendering shares one ip for every visitor, an api key gives it its own rate limit
+  headers: process.env.STATUSPHERE_API_KEY
+    ? { "X-API-Key": process.env.STATUSPHERE_API_KEY }
+    : {},
 });
 
 axiosServices.interceptors.request.use(
@@ -5,6 +5,22 @@ import "github.com/kelseyhightower/envconfig"
 type Config struct {
 	SlackWebhookUrl   string `envconfig:"SLACK_WEBHOOK_URL"`
 	TwitterWebhookUrl string `envconfig:"TWITTER_WEBHOOK_URL"`
+
+	// SlackBotToken posts to the SlackChannel (its id) with the slack web api, the updates of an incident are then posted
+	// in the thread of its message. It can be used along with SlackWebhookUrl.
+	SlackBotToken string `envconfig:"SLACK_BOT_TOKEN"`
+	SlackChannel  string `envconfig:"SLACK_CHANNEL"`
+
+	// NotificationEvents are the types of incident changes the webhooks and the slack bot above are notified of,
+	// a comma separated list of created, impact_changed, new_update and resolved
+	NotificationEvents []string `envconfig:"NOTIFICATION_EVENTS" default:"created"`
+
+	// NotificationAllowPrivateTargets lets the webhooks be private, loopback and link-local addresses, which are refused
+	// by default since the admins of every workspace can set the webhooks of their notification channels
+	NotificationAllowPrivateTargets bool `envconfig:"NOTIFICATION_ALLOW_PRIVATE_TARGETS"`
+
+	// HealthListenAddress is the address of the /healthz and /readyz endpoints of the jobrunner
+	HealthListenAddress string `envconfig:"HEALTH_LISTEN_ADDRESS" default:":8889"`
 }
 
 func GetConfigFromEnvironment() (Config, error) {
@@ -13,23 +13,22 @@ import (
 	"time"
 )
 
-// IncidentPoller is a poller that polls incidents from the database, if the jobs have not been started for them, then it starts them
-
+// IncidentPoller is a poller that polls the incident change events from the database, if their notifications have not
+// been queued yet, then it queues them for the channels that opted in to them
 type IncidentPoller struct {
-	db                *db.DbClient
-	logger            *zap.Logger
-	riverClient       *river.Client[pgx.Tx]
-	slackWebhookUrl   string
-	twitterWebhookUrl string
+	db          *db.DbClient
+	logger      *zap.Logger
+	riverClient *river.Client[pgx.Tx]
+	// environmentChannels are the webhooks and the slack bot set in the environment, they notify of every status page
+	environmentChannels []api.NotificationChannel
 }
 
-func NewIncidentPoller(db *db.DbClient, logger *zap.Logger, client *river.Client[pgx.Tx], slackWebhookUrl string, twitterWebhookUrl string) *IncidentPoller {
+func NewIncidentPoller(db *db.DbClient, logger *zap.Logger, client *river.Client[pgx.Tx], environmentChannels []api.NotificationChannel) *IncidentPoller {
 	return &IncidentPoller{
-		db:                db,
-		logger:            logger,
-		riverClient:       client,
-		slackWebhookUrl:   slackWebhookUrl,
-		twitterWebhookUrl: twitterWebhookUrl,
+		db:                  db,
+		logger:              logger,
+		riverClient:         client,
+		environmentChannels: environmentChannels,
 	}
 }
 
@@ -55,67 +54,193 @@ func (p *IncidentPoller) Poll() error {
 }
 
 func (p *IncidentPoller) pollInner() error {
-	// Get the incidents from the database without jobs started
-	p.logger.Info("polling incidents without jobs started")
-	incidents, err := p.db.GetIncidentsWithoutJobsStarted(context.Background(), 1000)
+	// Get the change events from the database whose notifications were not queued
+	p.logger.Info("polling pending incident change events")
+	events, err := p.db.ListPendingIncidentChangeEvents(context.Background(), 1000)
 	if err != nil {
-		return errors.Wrap(err, "failed to get incidents without jobs started")
+		return errors.Wrap(err, "failed to get pending incident change events")
 	}
-	if len(incidents) == 0 {
-		p.logger.Info("no incidents without jobs started")
+	if len(events) == 0 {
+		p.logger.Info("no pending incident change events")
 		return nil
 	}
-	p.logger.Info("found incidents without jobs started", zap.Int("count", len(incidents)))
-
-	jobArgs := make([]river.InsertManyParams, 0)
+	p.logger.Info("found pending incident change events", zap.Int("count", len(events)))
 
-	var incidentsToProcess = make([]api.Incident, 0)
-	for _, incident := range incidents {
-		// We only want to notify about incidents that have started in the last hour
-		// Otherwise, we will be sending notifications for incidents that have already been resolved
-		if incident.StartTime.Before(time.Now().Add(-1 * time.Hour)) {
-			continue
+	var incidentIDs []int64
+	eventIDs := make([]int64, 0, len(events))
+	seen := make(map[int64]bool)
+	for _, event := range events {
+		if !seen[event.IncidentID] {
+			seen[event.IncidentID] = true
+			incidentIDs = append(incidentIDs, event.IncidentID)
 		}
+		eventIDs = append(eventIDs, event.ID)
+	}
+	incidents, err := p.db.GetIncidentsByIDs(context.Background(), incidentIDs)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the incidents of the change events")
+	}
 
-		if incident.Impact == "maintenance" {
+	// The incidents left out of the map are not notified
+	var incidentsToProcess = make([]api.Incident, 0, len(incidents))
+	for _, id := range incidentIDs {
+		incident, ok := incidents[id]
+		if !ok || incident.Impact == api.ImpactMaintenance {
+			delete(incidents, id)
 			continue
 		}
-
 		incidentsToProcess = append(incidentsToProcess, incident)
 	}
 
-	// Slack webhook notifications
-	for _, incident := range incidentsToProcess {
-		if p.slackWebhookUrl == "" {
-			continue
-		}
-		jobArgs = append(jobArgs, river.InsertManyParams{Args: slack_webhook.SlackWebhookArgs{
-			Incident:   incident,
-			WebhookUrl: p.slackWebhookUrl,
-		}})
+	channelsByStatusPage, err := p.getNotificationChannels(incidentsToProcess)
+	if err != nil {
+		return err
 	}
+	jobArgs := p.getNotificationJobs(events, incidents, channelsByStatusPage)
 
-	// Twitter post notifications
-	for _, incident := range incidentsToProcess {
-		if p.twitterWebhookUrl == "" {
-			continue
-		}
-		jobArgs = append(jobArgs, river.InsertManyParams{Args: twitter_post.TwitterPostArgs{
-			WebhookUrl: p.twitterWebhookUrl,
-			Incident:   incident,
-		}})
+	// The jobs are inserted in the transaction that marks the events notified, so a failed poll queues nothing twice
+	ctx := context.Background()
+	tx, err := p.db.PgxPool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
 	}
+	defer tx.Rollback(ctx)
 
 	p.logger.Info("starting to insert jobs", zap.Int("count", len(jobArgs)))
-	// Start the jobs for each incident and update the database
 	if len(jobArgs) != 0 {
-		_, err = p.riverClient.InsertMany(context.Background(), jobArgs)
+		_, err = p.riverClient.InsertManyTx(ctx, tx, jobArgs)
 		if err != nil {
 			return errors.Wrap(err, "failed to insert many")
 		}
 	}
+	// The events that no channel opted in to are done as well
+	if err := p.db.SetIncidentChangeEventsNotified(ctx, tx, eventIDs, time.Now()); err != nil {
+		return errors.Wrap(err, "failed to set the incident change events notified")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
 	p.logger.Info("finished inserting jobs")
+	return nil
+}
+
+// getNotificationJobs returns the jobs that notify the channels of the events they opted in to, one job per event and
+// channel in the order of the events. The events of the incidents that are not notified are left out.
+func (p *IncidentPoller) getNotificationJobs(events []api.IncidentChangeEvent, incidents map[int64]api.Incident, channelsByStatusPage map[string][]api.NotificationChannel) []river.InsertManyParams {
+	jobArgs := make([]river.InsertManyParams, 0)
+	impactsAfter := getImpactsAfterEvents(events, incidents)
+	for _, event := range events {
+		incident, ok := incidents[event.IncidentID]
+		if !ok {
+			continue
+		}
+		if impact, ok := impactsAfter[event.ID]; ok {
+			incident.Impact = impact
+		}
+		channels := append(append([]api.NotificationChannel{}, p.environmentChannels...), channelsByStatusPage[incident.StatusPageUrl]...)
+		for _, channel := range channels {
+			if !channel.Events.Contains(event.Type) {
+				continue
+			}
+			if args := getJobArgs(channel, incident, event); args != nil {
+				jobArgs = append(jobArgs, river.InsertManyParams{Args: args})
+			}
+		}
+	}
+	return jobArgs
+}
 
-	// Start the jobs for each incident and update the database
-	return p.db.SetIncidentNotificationStartedToTrue(context.Background(), incidents)
+// getImpactsAfterEvents returns the impact of the incident of each event right after it, by event id
+// The jobs show the incidents as they now are, an incident whose impact changed more than once since the last poll
+// would otherwise be notified of its current impact for each change. The impact after an event is the impact the next
+// impact change of the incident changed from, or the current impact after its last change.
+func getImpactsAfterEvents(events []api.IncidentChangeEvent, incidents map[int64]api.Incident) map[int64]api.Impact {
+	impactsAfter := make(map[int64]api.Impact, len(events))
+	impacts := make(map[int64]api.Impact, len(incidents))
+	for id, incident := range incidents {
+		impacts[id] = incident.Impact
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		impact, ok := impacts[event.IncidentID]
+		if !ok {
+			continue
+		}
+		impactsAfter[event.ID] = impact
+		if event.Type == api.IncidentChangeEventImpactChanged {
+			impacts[event.IncidentID] = event.PreviousImpact
+		}
+	}
+	return impactsAfter
+}
+
+// getJobArgs returns the job that notifies the channel of the event, or nil for an unknown channel type
+func getJobArgs(channel api.NotificationChannel, incident api.Incident, event api.IncidentChangeEvent) river.JobArgs {
+	switch channel.Type {
+	case api.NotificationChannelTypeSlack:
+		// The bot token is looked up by the worker rather than stored in the job
+		return slack_webhook.SlackWebhookArgs{
+			Incident:              incident,
+			WebhookUrl:            channel.WebhookUrl,
+			Channel:               channel.SlackChannel,
+			NotificationChannelID: channel.ID,
+			Event:                 event.Type,
+			EventID:               event.ID,
+			PreviousImpact:        event.PreviousImpact,
+		}
+	case api.NotificationChannelTypeTwitter:
+		return twitter_post.TwitterPostArgs{
+			WebhookUrl:     channel.WebhookUrl,
+			Incident:       incident,
+			Event:          event.Type,
+			PreviousImpact: event.PreviousImpact,
+		}
+	default:
+		return nil
+	}
+}
+
+// getNotificationChannels returns the notification channels to notify of the incidents of each status page
+// The channels of the default workspace are notified of every status page, the channels of the other workspaces
+// only of the status pages they subscribed to
+func (p *IncidentPoller) getNotificationChannels(incidents []api.Incident) (map[string][]api.NotificationChannel, error) {
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	channels, err := p.db.ListAllNotificationChannels(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list notification channels")
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	channelsByWorkspace := make(map[int64][]api.NotificationChannel)
+	for _, channel := range channels {
+		channelsByWorkspace[channel.WorkspaceID] = append(channelsByWorkspace[channel.WorkspaceID], channel)
+	}
+
+	var statusPageUrls []string
+	seen := make(map[string]bool)
+	for _, incident := range incidents {
+		if !seen[incident.StatusPageUrl] {
+			seen[incident.StatusPageUrl] = true
+			statusPageUrls = append(statusPageUrls, incident.StatusPageUrl)
+		}
+	}
+	workspaceIDs, err := p.db.GetStatusPageWorkspaceIDs(context.Background(), statusPageUrls)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the workspaces of the status pages")
+	}
+
+	channelsByStatusPage := make(map[string][]api.NotificationChannel, len(statusPageUrls))
+	for _, statusPageUrl := range statusPageUrls {
+		statusPageChannels := append([]api.NotificationChannel{}, channelsByWorkspace[api.DefaultWorkspaceID]...)
+		for _, workspaceID := range workspaceIDs[statusPageUrl] {
+			if workspaceID != api.DefaultWorkspaceID {
+				statusPageChannels = append(statusPageChannels, channelsByWorkspace[workspaceID]...)
+			}
+		}
+		channelsByStatusPage[statusPageUrl] = statusPageChannels
+	}
+	return channelsByStatusPage, nil
 }
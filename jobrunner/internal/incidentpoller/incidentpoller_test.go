@@ -0,0 +1,70 @@
+package incidentpoller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/jobs/slack_webhook"
+)
+
+func TestGetNotificationJobs(t *testing.T) {
+	incidents := map[int64]api.Incident{
+		1: {ID: 1, Impact: api.ImpactMajor, StatusPageUrl: "https://status.example.com"},
+		2: {ID: 2, Impact: api.ImpactMinor, StatusPageUrl: "https://status.other.com"},
+	}
+	events := []api.IncidentChangeEvent{
+		{ID: 1, IncidentID: 1, Type: api.IncidentChangeEventCreated},
+		{ID: 2, IncidentID: 1, Type: api.IncidentChangeEventImpactChanged, PreviousImpact: api.ImpactMinor},
+		{ID: 3, IncidentID: 2, Type: api.IncidentChangeEventNewUpdate},
+		{ID: 4, IncidentID: 1, Type: api.IncidentChangeEventNewUpdate},
+		{ID: 5, IncidentID: 1, Type: api.IncidentChangeEventImpactChanged, PreviousImpact: api.ImpactCritical},
+		{ID: 6, IncidentID: 1, Type: api.IncidentChangeEventResolved},
+		// The incident is not notified, e.g. a maintenance
+		{ID: 7, IncidentID: 3, Type: api.IncidentChangeEventCreated},
+	}
+	every := api.IncidentChangeEventTypeArray(api.IncidentChangeEventTypes)
+	p := &IncidentPoller{environmentChannels: []api.NotificationChannel{
+		{Type: api.NotificationChannelTypeSlack, WebhookUrl: "https://hooks.slack.com/every", Events: every},
+	}}
+	channelsByStatusPage := map[string][]api.NotificationChannel{
+		"https://status.example.com": {{ID: 7, Type: api.NotificationChannelTypeSlack, WebhookUrl: "https://hooks.slack.com/resolved", Events: api.IncidentChangeEventTypeArray{api.IncidentChangeEventResolved}}},
+	}
+
+	var got []string
+	for _, job := range p.getNotificationJobs(events, incidents, channelsByStatusPage) {
+		args := job.Args.(slack_webhook.SlackWebhookArgs)
+		got = append(got, fmt.Sprintf("%s %d %s %s", args.WebhookUrl, args.EventID, args.Event, args.Incident.Impact))
+	}
+	// Every event is notified in order, the impact of an incident is the one it had right after the event
+	expected := []string{
+		"https://hooks.slack.com/every 1 created minor",
+		"https://hooks.slack.com/every 2 impact_changed critical",
+		"https://hooks.slack.com/every 3 new_update minor",
+		"https://hooks.slack.com/every 4 new_update critical",
+		"https://hooks.slack.com/every 5 impact_changed major",
+		"https://hooks.slack.com/every 6 resolved major",
+		"https://hooks.slack.com/resolved 6 resolved major",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the jobs\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestGetJobArgs(t *testing.T) {
+	channel := api.NotificationChannel{ID: 7, Type: api.NotificationChannelTypeSlack, SlackBotToken: "xoxb-1", SlackChannel: "C123"}
+	event := api.IncidentChangeEvent{Type: api.IncidentChangeEventImpactChanged, PreviousImpact: api.ImpactMinor}
+
+	args, ok := getJobArgs(channel, api.Incident{ID: 1}, event).(slack_webhook.SlackWebhookArgs)
+	if !ok || args.NotificationChannelID != 7 || args.Channel != "C123" || args.Event != event.Type || args.PreviousImpact != api.ImpactMinor {
+		t.Errorf("unexpected slack job %+v", args)
+	}
+	if encoded, _ := json.Marshal(args); strings.Contains(string(encoded), "xoxb-1") {
+		t.Errorf("expected the bot token to be left out of the job, got %s", encoded)
+	}
+	if args := getJobArgs(api.NotificationChannel{Type: "email"}, api.Incident{ID: 1}, event); args != nil {
+		t.Errorf("expected no job for an unknown channel type, got %+v", args)
+	}
+}
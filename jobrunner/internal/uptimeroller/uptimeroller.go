@@ -0,0 +1,101 @@
+package uptimeroller
+
+import (
+	"context"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/uptime"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// maxBackfillDays limits how far back the uptime is computed for status pages that have never been rolled up
+const maxBackfillDays = 365
+
+const rollupInterval = 15 * time.Minute
+
+// UptimeRoller periodically computes the daily uptime of every status page from its incidents
+type UptimeRoller struct {
+	db     *db.DbClient
+	logger *zap.Logger
+}
+
+func NewUptimeRoller(db *db.DbClient, logger *zap.Logger) *UptimeRoller {
+	return &UptimeRoller{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *UptimeRoller) Start() {
+	go r.Poll()
+}
+
+func (r *UptimeRoller) Poll() {
+	r.pollInner()
+	ticker := time.NewTicker(rollupInterval)
+	for {
+		select {
+		case <-ticker.C:
+			r.pollInner()
+		}
+	}
+}
+
+func (r *UptimeRoller) pollInner() {
+	r.logger.Info("rolling up uptime")
+	statusPages, err := r.db.GetAllStatusPages(context.Background())
+	if err != nil {
+		r.logger.Error("failed to get status pages", zap.Error(err))
+		return
+	}
+	for _, statusPage := range statusPages {
+		err := r.rollup(context.Background(), statusPage, time.Now())
+		if err != nil {
+			r.logger.Error("failed to roll up uptime", zap.String("url", statusPage.URL), zap.Error(err))
+		}
+	}
+	r.logger.Info("finished rolling up uptime")
+}
+
+// rollup recomputes the uptime of the status page since the last day that was rolled up
+// The previous day is always recomputed as incidents can be resolved or updated after midnight
+func (r *UptimeRoller) rollup(ctx context.Context, statusPage api.StatusPage, now time.Time) error {
+	today := uptime.StartOfDay(now)
+	from := today.AddDate(0, 0, -1)
+
+	latest, err := r.db.GetLatestUptimeDay(ctx, statusPage.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the latest uptime day")
+	}
+	if latest == nil {
+		earliest, err := r.db.GetEarliestIncidentStartTime(ctx, statusPage.URL)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the earliest incident")
+		}
+		if earliest != nil && earliest.Before(from) {
+			from = uptime.StartOfDay(*earliest)
+		}
+	} else if latest.Before(from) {
+		from = uptime.StartOfDay(*latest)
+	}
+	if oldest := today.AddDate(0, 0, -maxBackfillDays); from.Before(oldest) {
+		from = oldest
+	}
+
+	incidents, err := r.db.GetIncidentsBetween(ctx, statusPage.URL, from, now)
+	if err != nil {
+		return errors.Wrap(err, "failed to get incidents")
+	}
+
+	var days []api.UptimeDay
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		days = append(days, uptime.ComputeDay(statusPage.URL, incidents, day, now)...)
+	}
+	for i := range days {
+		days[i].ComputedAt = now
+	}
+	return r.db.UpsertUptimeDays(ctx, days)
+}
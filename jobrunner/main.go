@@ -2,16 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/metoro-io/statusphere/common/api"
 	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/health"
 	"github.com/metoro-io/statusphere/common/jobs/riverclient"
+	"github.com/metoro-io/statusphere/common/publicnet"
 	config2 "github.com/metoro-io/statusphere/jobrunner/internal/config"
 	"github.com/metoro-io/statusphere/jobrunner/internal/incidentpoller"
+	"github.com/metoro-io/statusphere/jobrunner/internal/uptimeroller"
 	"github.com/riverqueue/river"
 	"go.uber.org/zap"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
+// healthShutdownTimeout is how long the health endpoints have to answer the probes in flight when the jobrunner stops
+const healthShutdownTimeout = 5 * time.Second
+
 func main() {
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	defer cancelCtx()
@@ -26,9 +41,15 @@ func main() {
 		panic(err)
 	}
 
-	err = riverclient.RunMigration(db.PgxPool)
+	config, err := config2.GetConfigFromEnvironment()
+	if err != nil {
+		panic(err)
+	}
+	if (config.SlackBotToken == "") != (config.SlackChannel == "") {
+		panic(errors.New("STATUSPHERE_SLACK_BOT_TOKEN and STATUSPHERE_SLACK_CHANNEL must be set together"))
+	}
 
-	client, err := riverclient.NewRiverClient(db, logger, http.DefaultClient, 100)
+	client, err := riverclient.NewRiverClient(db, logger, publicnet.NewHttpClient(config.NotificationAllowPrivateTargets), 100, config.SlackBotToken)
 	if err != nil {
 		panic(err)
 	}
@@ -40,14 +61,66 @@ func main() {
 		_ = client.Stop(ctx)
 	}(client, ctx)
 
-	config, err := config2.GetConfigFromEnvironment()
+	// The jobrunner is ready once the pollers are started and as long as it can reach the database
+	var started atomic.Bool
+	healthServer := health.NewServer(config.HealthListenAddress, health.Checks{
+		"database": db.Ping,
+		"pollers": func(ctx context.Context) error {
+			if !started.Load() {
+				return errors.New("the pollers have not been started yet")
+			}
+			return nil
+		},
+	})
+	go func() {
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed to serve the health endpoints", zap.Error(err))
+		}
+	}()
+
+	environmentChannels, err := getEnvironmentNotificationChannels(config)
 	if err != nil {
 		panic(err)
 	}
 
-	incidentPoller := incidentpoller.NewIncidentPoller(db, logger, client, config.SlackWebhookUrl, config.TwitterWebhookUrl)
+	incidentPoller := incidentpoller.NewIncidentPoller(db, logger, client, environmentChannels)
 	incidentPoller.Start()
 
-	// Work forever
-	<-ctx.Done()
+	uptimeRoller := uptimeroller.NewUptimeRoller(db, logger)
+	uptimeRoller.Start()
+	started.Store(true)
+
+	// Work until asked to stop
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	logger.Info("Shutting down", zap.String("signal", sig.String()))
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), healthShutdownTimeout)
+	defer cancelShutdown()
+	_ = healthServer.Shutdown(shutdownCtx)
+}
+
+// getEnvironmentNotificationChannels returns the notification channels of the webhooks and the slack bot set in the environment
+func getEnvironmentNotificationChannels(config config2.Config) ([]api.NotificationChannel, error) {
+	events := make(api.IncidentChangeEventTypeArray, 0, len(config.NotificationEvents))
+	for _, eventType := range config.NotificationEvents {
+		event, err := api.ParseIncidentChangeEventType(strings.TrimSpace(eventType))
+		if err != nil {
+			return nil, fmt.Errorf("invalid STATUSPHERE_NOTIFICATION_EVENTS: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	var channels []api.NotificationChannel
+	if config.SlackWebhookUrl != "" {
+		channels = append(channels, api.NotificationChannel{Name: "slack webhook", Type: api.NotificationChannelTypeSlack, WebhookUrl: config.SlackWebhookUrl, Events: events})
+	}
+	if config.SlackBotToken != "" {
+		channels = append(channels, api.NotificationChannel{Name: "slack bot", Type: api.NotificationChannelTypeSlack, SlackBotToken: config.SlackBotToken, SlackChannel: config.SlackChannel, Events: events})
+	}
+	if config.TwitterWebhookUrl != "" {
+		channels = append(channels, api.NotificationChannel{Name: "twitter webhook", Type: api.NotificationChannelTypeTwitter, WebhookUrl: config.TwitterWebhookUrl, Events: events})
+	}
+	return channels, nil
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/jobs/riverclient"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [steps]  revert the last applied migration, or the last <steps> migrations
+  status        list migrations and whether they have been applied
+`
+
+// migrate is the only component allowed to change the database schema.
+// It is run to completion before the apiserver, scraper and jobrunner are started so that they never race on schema changes.
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), logger, os.Args[1], os.Args[2:]); err != nil {
+		logger.Error("migration failed", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, logger *zap.Logger, command string, args []string) error {
+	switch command {
+	case "up":
+		err := db.CreateDatabaseFromEnvironmentIfNotExists(ctx)
+		if err != nil {
+			return err
+		}
+		dbClient, err := db.NewDbClientFromEnvironment(logger)
+		if err != nil {
+			return err
+		}
+		applied, err := dbClient.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("applied migrations", zap.Int("count", len(applied)))
+		// The job queue tables are owned by river, which versions them itself
+		return riverclient.RunMigration(dbClient.PgxPool)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[0])
+			}
+			steps = parsed
+		}
+		dbClient, err := db.NewDbClientFromEnvironment(logger)
+		if err != nil {
+			return err
+		}
+		reverted, err := dbClient.MigrateDown(ctx, steps)
+		if err != nil {
+			return err
+		}
+		logger.Info("reverted migrations", zap.Int("count", len(reverted)))
+		return nil
+	case "status":
+		dbClient, err := db.NewDbClientFromEnvironment(logger)
+		if err != nil {
+			return err
+		}
+		statuses, err := dbClient.MigrationStatuses(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Migration.Version, status.Migration.Name, appliedAt)
+		}
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import "github.com/kelseyhightower/envconfig"
+
+type Config struct {
+	// HealthListenAddress is the address of the /healthz and /readyz endpoints of the scraper
+	HealthListenAddress string `envconfig:"HEALTH_LISTEN_ADDRESS" default:":8890"`
+	// StatusPagesFile is a YAML or JSON file defining the status pages, it replaces the status pages defined in code
+	StatusPagesFile string `envconfig:"STATUS_PAGES_FILE"`
+}
+
+func GetConfigFromEnvironment() (Config, error) {
+	var config Config
+	err := envconfig.Process("STATUSPHERE", &config)
+	return config, err
+}
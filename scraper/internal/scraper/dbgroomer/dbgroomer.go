@@ -2,30 +2,33 @@ package dbgroomer
 
 import (
 	"context"
+	"github.com/metoro-io/statusphere/common/api"
 	"github.com/metoro-io/statusphere/common/db"
-	"github.com/metoro-io/statusphere/common/status_pages"
 	"go.uber.org/zap"
 )
 
 type DbGroomer struct {
 	dbClient *db.DbClient
 	logger   *zap.Logger
+	// statusPages are the status pages defined in code
+	statusPages []api.StatusPage
 }
 
-func NewDbGroomer(logger *zap.Logger, dbClient *db.DbClient) *DbGroomer {
+func NewDbGroomer(logger *zap.Logger, dbClient *db.DbClient, statusPages []api.StatusPage) *DbGroomer {
 	return &DbGroomer{
-		logger:   logger,
-		dbClient: dbClient,
+		logger:      logger,
+		dbClient:    dbClient,
+		statusPages: statusPages,
 	}
 }
 
 func (d *DbGroomer) Groom() {
 	go func() {
 		d.logger.Info("grooming status pages")
-		// Delete any status page where it isn't in our local list
-		statusPages := status_pages.StatusPages
+		// Delete any status page defined in code where it isn't in our local list
+		// Status pages managed through the admin api or a status pages file are left alone
 		urls := make(map[string]bool)
-		for _, statusPage := range statusPages {
+		for _, statusPage := range d.statusPages {
 			urls[statusPage.URL] = true
 		}
 
@@ -34,6 +37,9 @@ func (d *DbGroomer) Groom() {
 			d.logger.Error("failed to get all status pages", zap.Error(err))
 		}
 		for _, statusPage := range statusPages {
+			if statusPage.Source != api.StatusPageSourceCode {
+				continue
+			}
 			if _, ok := urls[statusPage.URL]; !ok {
 				d.logger.Info("deleting status page", zap.String("url", statusPage.URL))
 				err := d.dbClient.DeleteStatusPage(context.Background(), statusPage.URL)
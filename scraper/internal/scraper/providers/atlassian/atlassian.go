@@ -61,7 +61,7 @@ func (s *AtlassianProvider) scrapeAtlassianPageCurrent(ctx context.Context, page
 	}
 
 	// Get the most recent historical incidentsHistoricalRecent
-	incidentsHistoricalRecent, err := s.getHistoricalPageOfIncidents(page.URL, 1, false)
+	incidentsHistoricalRecent, err := s.getHistoricalPageOfIncidents(page.URL, 1)
 	if err != nil {
 		return nil, s.Name(), errors.Wrap(err, "failed to get the most recent historical incidentsHistoricalRecent")
 	}
@@ -70,7 +70,7 @@ func (s *AtlassianProvider) scrapeAtlassianPageCurrent(ctx context.Context, page
 	incidents := append(incidentsOngoing, incidentsHistoricalRecent...)
 	incidentsDeDuped := make(map[string]api.Incident)
 	for _, incident := range incidents {
-		incidentsDeDuped[incident.DeepLink] = incident
+		incidentsDeDuped[incident.ExternalID] = incident
 	}
 
 	incidents = []api.Incident{}
@@ -98,7 +98,7 @@ func (s *AtlassianProvider) scrapeAtlassianPageHistorical(ctx context.Context, u
 	i := 40
 	for page := 2; page <= i; page++ {
 		// Get the html of the status page
-		incidentPage, err := s.getHistoricalPageOfIncidents(url, page, true)
+		incidentPage, err := s.getHistoricalPageOfIncidents(url, page)
 		if err != nil {
 			return nil, s.Name(), errors.Wrap(err, "failed to get the historical incidents")
 		}
@@ -124,21 +124,21 @@ func (s *AtlassianProvider) scrapeStatusIoHistoryPage(url string, page int) (str
 	return string(historyHtml), nil
 }
 
-func (s *AtlassianProvider) getHistoricalPageOfIncidents(url string, page int, shouldSkipJobProcessing bool) ([]api.Incident, error) {
+func (s *AtlassianProvider) getHistoricalPageOfIncidents(url string, page int) ([]api.Incident, error) {
 	historyPageHtml, err := s.scrapeStatusIoHistoryPage(url, page)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to scrape the status page history")
 	}
 
 	// Parse the incidents from the history page
-	incidentPage, err := s.parseIncidents(url, historyPageHtml, shouldSkipJobProcessing)
+	incidentPage, err := s.parseIncidents(url, historyPageHtml)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse the incidents from the history page")
 	}
 	return incidentPage, nil
 }
 
-func (s *AtlassianProvider) parseIncidents(url string, html string, shouldSkipJobProcessing bool) ([]api.Incident, error) {
+func (s *AtlassianProvider) parseIncidents(url string, html string) ([]api.Incident, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse the history page html")
@@ -183,10 +183,9 @@ func (s *AtlassianProvider) parseIncidents(url string, html string, shouldSkipJo
 					EndTime:       endTime,
 					Impact:        api.Impact(inc.Impact),
 					DeepLink:      link,
+					ExternalID:    inc.Code,
 					StatusPageUrl: url,
-					// For historical jobs we don't want to send notifications
-					NotificationJobsStarted: shouldSkipJobProcessing,
-					Scraper:                 s.Name(),
+					Provider:      s.Name(),
 				}
 				incidents = append(incidents, incident)
 			}
@@ -241,7 +240,14 @@ func (s *AtlassianProvider) parseCurrentIncidents(url string, html string) ([]ap
 		incident.Title = selection.Find(".actual-title").Text()
 		deepLink := selection.Find(".incident-title a").First().AttrOr("href", "")
 		deepLink = url + deepLink
+		code, ok := incidentCodeFromDeepLink(deepLink)
+		if !ok {
+			// Without its code the incident would take the identity of every other incident without a link
+			s.logger.Warn("skipping an incident without a link to it", zap.String("url", url), zap.String("title", incident.Title))
+			return
+		}
 		incident.DeepLink = deepLink
+		incident.ExternalID = code
 		incident.StatusPageUrl = url
 		var minTime *time.Time = nil
 
@@ -268,7 +274,6 @@ func (s *AtlassianProvider) parseCurrentIncidents(url string, html string) ([]ap
 			incident.Events = append(incident.Events, event)
 		})
 		incident.StartTime = *minTime
-		incident.NotificationJobsStarted = false
 		// Append the extracted incident to the slice
 		incidents = append(incidents, incident)
 	})
@@ -382,3 +387,13 @@ type IncidentRaw struct {
 	Code      string `json:"code"`
 	Impact    string `json:"impact"`
 }
+
+// incidentCodeFromDeepLink extracts the atlassian incident code from links like https://status.example.com/incidents/<code>
+// It returns false if the link is not the link of an incident
+func incidentCodeFromDeepLink(deepLink string) (string, bool) {
+	_, code, found := strings.Cut(strings.TrimSuffix(deepLink, "/"), "/incidents/")
+	if !found || code == "" || strings.Contains(code, "/") {
+		return "", false
+	}
+	return code, true
+}
@@ -0,0 +1,23 @@
+package atlassian
+
+import "testing"
+
+func TestIncidentCodeFromDeepLink(t *testing.T) {
+	tests := []struct {
+		deepLink string
+		code     string
+		ok       bool
+	}{
+		{deepLink: "https://status.example.com/incidents/abc123", code: "abc123", ok: true},
+		{deepLink: "https://status.example.com/incidents/abc123/", code: "abc123", ok: true},
+		// An incident without a link has the url of the status page
+		{deepLink: "https://status.example.com", ok: false},
+		{deepLink: "https://status.example.com/incidents/", ok: false},
+	}
+	for _, test := range tests {
+		code, ok := incidentCodeFromDeepLink(test.deepLink)
+		if code != test.code || ok != test.ok {
+			t.Errorf("%s: expected %q %t, got %q %t", test.deepLink, test.code, test.ok, code, ok)
+		}
+	}
+}
@@ -19,6 +19,8 @@ import (
 type RestProvider struct {
 	logger     *zap.Logger
 	httpClient *http.Client
+	// expandEnvVariables replaces the {env.<ENV_VAR>} placeholders of the headers with the environment of the process
+	expandEnvVariables bool
 }
 
 func (s *RestProvider) Name() string {
@@ -26,6 +28,16 @@ func (s *RestProvider) Name() string {
 }
 
 func NewRestProvider(logger *zap.Logger, httpClient *http.Client) *RestProvider {
+	return &RestProvider{
+		logger:             logger,
+		httpClient:         httpClient,
+		expandEnvVariables: true,
+	}
+}
+
+// NewRequestRestProvider returns a provider for the status page definitions that come from a request
+// The environment placeholders of the headers are sent as they are, the environment of the process must not leak
+func NewRequestRestProvider(logger *zap.Logger, httpClient *http.Client) *RestProvider {
 	return &RestProvider{
 		logger:     logger,
 		httpClient: httpClient,
@@ -57,7 +69,7 @@ func (s *RestProvider) ScrapeStatusPageCurrent(ctx context.Context, page api.Sta
 	// Deserializace (unmarshalování) JSON odpovědi do mapy
 	err = json.Unmarshal(body, &jsonResponse)
 	if err != nil {
-		errorMessage := fmt.Sprintf("Error unmarshaling JSON, Body: %s, Error: %w", string(body), err)
+		errorMessage := fmt.Sprintf("Error unmarshaling JSON, Body: %s, Error: %v", string(body), err)
 		s.logger.Error(errorMessage)
 		incident := s.createIncident("Error unmarshaling JSON", errorMessage, "unmarshaling", page.URL, err)
 		return []api.Incident{incident}, s.Name(), fmt.Errorf("Non-200 response: %d, Body: %s", resp.StatusCode, string(body))
@@ -66,7 +78,7 @@ func (s *RestProvider) ScrapeStatusPageCurrent(ctx context.Context, page api.Sta
 	if page.ValidationRules != nil {
 		err = s.ValidateJSONResponse(jsonResponse, page.ValidationRules)
 		if err != nil {
-			errorMessage := fmt.Sprintf("Invalid JSON response, Body: %s, Error: %w", string(body), err)
+			errorMessage := fmt.Sprintf("Invalid JSON response, Body: %s, Error: %v", string(body), err)
 			s.logger.Error(errorMessage)
 			incident := s.createIncident("Invalid JSON response", errorMessage, "unmarshaling", page.URL, err)
 			return []api.Incident{incident}, s.Name(), fmt.Errorf("Non-200 response: %d, Body: %s", resp.StatusCode, string(body))
@@ -101,7 +113,10 @@ func (s *RestProvider) DoRequest(ctx context.Context, page api.StatusPage) (*htt
 
 	// set headers, replace environment variable placeholders in headers
 	for key, value := range page.Headers {
-		req.Header.Set(key, s.replaceEnvVariables(value))
+		if s.expandEnvVariables {
+			value = s.replaceEnvVariables(value)
+		}
+		req.Header.Set(key, value)
 	}
 
 	resp, err := s.httpClient.Do(req)
@@ -169,9 +184,11 @@ func (s *RestProvider) createIncident(title string, desc string, code string, ur
 		Description:   &description,
 		StartTime:     time.Now(),
 		StatusPageUrl: url,
-		DeepLink:      fmt.Sprintf("%s/%s", url, code),
-		Impact:        api.ImpactCritical,
-		Scraper:       s.Name(),
+		DeepLink:      url,
+		// The database makes the check code unique per outage
+		ExternalID: code,
+		Impact:     api.ImpactCritical,
+		Provider:   s.Name(),
 	}
 	return incident
 }
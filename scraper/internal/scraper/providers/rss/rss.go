@@ -125,6 +125,10 @@ func (s *RssProvider) getIncidentsFromRssPage(url string, statusPageUrl string)
 			description = stripHTML(item.Description)
 		}
 		deepLink := item.Link
+		externalID := item.GUID
+		if externalID == "" {
+			externalID = item.Link
+		}
 
 		incidents = append(incidents, api.Incident{
 			Title:       title,
@@ -132,10 +136,11 @@ func (s *RssProvider) getIncidentsFromRssPage(url string, statusPageUrl string)
 			StartTime:   parsedTime,
 			EndTime:     &parsedTime,
 			DeepLink:    deepLink,
+			ExternalID:  externalID,
 			// Not all RSS feeds have an impact field, so we default to none
 			Impact:        api.ImpactNone,
 			StatusPageUrl: statusPageUrl,
-			Scraper:       s.Name(),
+			Provider:      s.Name(),
 		})
 	}
 
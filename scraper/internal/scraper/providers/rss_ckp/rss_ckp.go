@@ -66,7 +66,7 @@ func (s *CkpRssProvider) isRssPage(url string) (string, bool, error) {
 		return "", false, errors.Wrap(err, "failed to make the get request to the history page")
 	}
 	if response.StatusCode != http.StatusOK {
-		return "", false, fmt.Errorf("Invalid response StatusCode: %w", response.StatusCode)
+		return "", false, fmt.Errorf("Invalid response StatusCode: %d", response.StatusCode)
 	}
 
 	// Is the body well formed xml?
@@ -194,10 +194,11 @@ func (s *CkpRssProvider) getIncidentsFromRssPage(url string, statusPageUrl strin
 			Description:   &description,
 			StartTime:     startTime,
 			EndTime:       &endTime,
-			DeepLink:      fmt.Sprintf("%s %s", rssFeed.Channel.Link, item.Guid),
+			DeepLink:      rssFeed.Channel.Link,
+			ExternalID:    item.Guid,
 			Impact:        api.ImpactMaintenance, // Adjust this based on the content if necessary
 			StatusPageUrl: statusPageUrl,
-			Scraper:       s.Name(),
+			Provider:      s.Name(),
 		})
 	}
 
@@ -0,0 +1,99 @@
+package statuspagefile
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/metoro-io/statusphere/common/api"
+	"github.com/metoro-io/statusphere/common/db"
+	"github.com/metoro-io/statusphere/common/status_pages"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// checkInterval is how often the file is checked for changes
+const checkInterval = 10 * time.Second
+
+// Watcher keeps the status pages of the database in sync with a status pages file
+type Watcher struct {
+	logger        *zap.Logger
+	dbClient      *db.DbClient
+	path          string
+	providerNames []string
+	modTime       time.Time
+	size          int64
+}
+
+func NewWatcher(logger *zap.Logger, dbClient *db.DbClient, path string, providerNames []string) *Watcher {
+	return &Watcher{
+		logger:        logger,
+		dbClient:      dbClient,
+		path:          path,
+		providerNames: providerNames,
+	}
+}
+
+// Start reconciles the file with the database, then reconciles it again every time it changes on disk
+// It fails if the file cannot be loaded at start, later an invalid file is logged and the status pages are left as they are
+func (w *Watcher) Start() error {
+	if err := w.reconcile(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		for {
+			select {
+			case <-ticker.C:
+				if !w.changed() {
+					continue
+				}
+				if err := w.reconcile(); err != nil {
+					w.logger.Error("failed to reload the status pages file", zap.Error(err), zap.String("path", w.path))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// changed returns whether the file was modified since it was last loaded
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.logger.Error("failed to stat the status pages file", zap.Error(err), zap.String("path", w.path))
+		return false
+	}
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+func (w *Watcher) reconcile() error {
+	// Stat before reading, so a write racing with the read is picked up on the next check
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat the status pages file")
+	}
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+
+	statusPages, err := status_pages.LoadFile(w.path, w.providerNames)
+	if err != nil {
+		return err
+	}
+	result, err := w.dbClient.ReconcileStatusPages(context.Background(), api.StatusPageSourceFile, statusPages)
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile the status pages file")
+	}
+	for _, url := range result.TakenOver {
+		// The definition of the file replaced the one made through the admin api or in code
+		w.logger.Warn("the status pages file took over a status page defined elsewhere", zap.String("path", w.path), zap.String("url", url))
+	}
+	w.logger.Info("reconciled the status pages file",
+		zap.String("path", w.path),
+		zap.Int("statusPages", len(statusPages)),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("deleted", result.Deleted),
+	)
+	return nil
+}
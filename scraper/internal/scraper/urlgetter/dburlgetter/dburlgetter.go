@@ -26,37 +26,56 @@ func NewDBURLGetter(logger *zap.Logger, client *db.DbClient) *DBURLGetter {
 }
 
 func (s *DBURLGetter) UpdateLastScrapedTimeHistorical(url string, time time.Time) error {
-	statusPage, err := s.dbClient.GetStatusPage(context.Background(), url)
-	if err != nil {
-		return errors.Wrap(err, "failed to get status page")
-	}
-	statusPage.LastHistoricallyScraped = time
-	err = s.dbClient.UpdateStatusPage(context.Background(), *statusPage)
+	found, err := s.dbClient.UpdateStatusPageHistoricallyScraped(context.Background(), url, time)
 	if err != nil {
 		return errors.Wrap(err, "failed to update status page")
 	}
-	s.StatusPageCache.Set(url, *statusPage, cache.DefaultExpiration)
+	if !found {
+		// The status page was deleted while it was scraped
+		s.StatusPageCache.Delete(url)
+		return nil
+	}
+	s.updateCachedStatusPage(url, func(statusPage *api.StatusPage) {
+		statusPage.LastHistoricallyScraped = time
+	})
 	return nil
 }
 
 func (s *DBURLGetter) UpdateLastScrapedTime(page api.StatusPage, time time.Time, scraped bool) error {
-	statusPage, err := s.dbClient.GetStatusPage(context.Background(), page.URL)
-	if err != nil {
-		return errors.Wrap(err, "failed to get status page")
-	}
-	statusPage.LastCurrentlyScraped = time
-	if !statusPage.IsIndexed && scraped {
-		statusPage.IsIndexed = true
-	}
-	err = s.dbClient.UpdateStatusPage(context.Background(), *statusPage)
+	found, err := s.dbClient.UpdateStatusPageScraped(context.Background(), page.URL, time, scraped)
 	if err != nil {
 		return errors.Wrap(err, "failed to update status page")
 	}
-	s.StatusPageCache.Set(page.URL, *statusPage, cache.DefaultExpiration)
+	if !found {
+		// The status page was deleted while it was scraped
+		s.StatusPageCache.Delete(page.URL)
+		return nil
+	}
+	s.updateCachedStatusPage(page.URL, func(statusPage *api.StatusPage) {
+		statusPage.LastCurrentlyScraped = time
+		if scraped {
+			statusPage.LastSuccessfullyScraped = &time
+			statusPage.IsIndexed = true
+		}
+	})
 	return nil
 }
 
-const timeToRescrape = 5 * time.Minute
+// updateCachedStatusPage applies the update to the cached status page, which keeps the definition it was cached with
+// until the cache is refreshed
+func (s *DBURLGetter) updateCachedStatusPage(url string, update func(statusPage *api.StatusPage)) {
+	cached, found := s.StatusPageCache.Get(url)
+	if !found {
+		return
+	}
+	statusPage, ok := cached.(api.StatusPage)
+	if !ok {
+		s.logger.Error("failed to cast status page")
+		return
+	}
+	update(&statusPage)
+	s.StatusPageCache.Set(url, statusPage, cache.DefaultExpiration)
+}
 
 func (s *DBURLGetter) GetUrlsToScrapeOrig() ([]string, error) {
 	urlsToUse := []string{}
@@ -67,7 +86,7 @@ func (s *DBURLGetter) GetUrlsToScrapeOrig() ([]string, error) {
 			s.logger.Error("failed to cast status page")
 			continue
 		}
-		if time.Since(statusPage.LastCurrentlyScraped) > timeToRescrape {
+		if time.Since(statusPage.LastCurrentlyScraped) > statusPage.ScrapeInterval() {
 			urlsToUse = append(urlsToUse, k)
 		}
 	}
@@ -85,7 +104,7 @@ func (s *DBURLGetter) GetPagesToScrape() ([]api.StatusPage, error) {
 			s.logger.Error("failed to cast status page")
 			continue
 		}
-		if time.Since(statusPage.LastCurrentlyScraped) > timeToRescrape {
+		if time.Since(statusPage.LastCurrentlyScraped) > statusPage.ScrapeInterval() {
 			// urlsToUse = append(urlsToUse, k)
 			pagesToUse = append(pagesToUse, statusPage)
 		}
@@ -136,7 +155,15 @@ func (s *DBURLGetter) updateStatusPageCacheInner() {
 		s.logger.Error("failed to get status pages", zap.Error(err))
 		return
 	}
+	known := make(map[string]bool, len(statusPages))
 	for _, statusPage := range statusPages {
+		known[statusPage.URL] = true
 		s.StatusPageCache.Set(statusPage.URL, statusPage, cache.DefaultExpiration)
 	}
+	// Stop scraping the status pages that were deleted
+	for url := range s.StatusPageCache.Items() {
+		if !known[url] {
+			s.StatusPageCache.Delete(url)
+		}
+	}
 }
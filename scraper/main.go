@@ -2,20 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/metoro-io/statusphere/common/db"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/common/health"
+	"github.com/metoro-io/statusphere/common/status_pages"
+	"github.com/metoro-io/statusphere/scraper/internal/config"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/consumers/dbconsumer"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/dbgroomer"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/poller"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/atlassian"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rest"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rss"
-	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rss_ckp"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/statuspagefile"
 	"github.com/metoro-io/statusphere/scraper/internal/scraper/urlgetter/dburlgetter"
+	"github.com/metoro-io/statusphere/scraper/providerset"
 	"go.uber.org/zap"
 )
 
@@ -25,12 +26,7 @@ func main() {
 		panic(err)
 	}
 
-	scraper := scraper.NewScraper(logger, http.DefaultClient, []providers.Provider{
-		atlassian.NewAtlassianProvider(logger, http.DefaultClient),
-		rss.NewRssProvider(logger, http.DefaultClient),
-		rss_ckp.NewCkpRssProvider(logger, http.DefaultClient),
-		rest.NewRestProvider(logger, http.DefaultClient),
-	})
+	scraper := providerset.NewScraper(logger, http.DefaultClient)
 
 	dbClient, err := db.NewDbClientFromEnvironment(logger)
 	if err != nil {
@@ -38,19 +34,56 @@ func main() {
 		return
 	}
 
-	err = dbClient.AutoMigrate(context.Background())
+	config, err := config.GetConfigFromEnvironment()
+	if err != nil {
+		logger.Error("failed to get config", zap.Error(err))
+		return
+	}
+
+	// The scraper is ready once it polls the status pages and as long as it can reach the database
+	var polling atomic.Bool
+	healthServer := health.NewServer(config.HealthListenAddress, health.Checks{
+		"database": dbClient.Ping,
+		"poller": func(ctx context.Context) error {
+			if !polling.Load() {
+				return errors.New("the status pages are not polled yet")
+			}
+			return nil
+		},
+	})
+	go func() {
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed to serve the health endpoints", zap.Error(err))
+		}
+	}()
+
+	codeStatusPages := status_pages.StatusPages
+	if config.StatusPagesFile != "" {
+		// The status pages file replaces the status pages defined in code
+		codeStatusPages = nil
+		watcher := statuspagefile.NewWatcher(logger, dbClient, config.StatusPagesFile, providerset.ProviderNames())
+		err = watcher.Start()
+		if err != nil {
+			logger.Error("failed to load the status pages file", zap.Error(err))
+			return
+		}
+	}
+
+	// The schema is owned by the migrate command, here we only make sure the known status pages exist
+	err = dbClient.SeedStatusPages(codeStatusPages)
 	if err != nil {
-		logger.Error("failed to auto migrate", zap.Error(err))
+		logger.Error("failed to seed status pages", zap.Error(err))
 		return
 	}
 
 	getter := dburlgetter.NewDBURLGetter(logger, dbClient)
 	getter.Start()
-	dbGroomer := dbgroomer.NewDbGroomer(logger, dbClient)
+	dbGroomer := dbgroomer.NewDbGroomer(logger, dbClient, codeStatusPages)
 	dbGroomer.Groom()
 	poller := poller.NewPoller(getter, scraper, []consumers.Consumer{
 		dbconsumer.NewDbConsumer(logger, dbClient),
 	}, logger)
+	polling.Store(true)
 	err = poller.Poll()
 	if err != nil {
 		logger.Error("failed to poll", zap.Error(err))
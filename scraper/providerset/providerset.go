@@ -0,0 +1,50 @@
+// Package providerset builds the scraper with every provider, for the scraper itself and for the processes that
+// need to scrape a status page on demand, like the apiserver testing a status page definition.
+package providerset
+
+import (
+	"net/http"
+
+	"github.com/metoro-io/statusphere/scraper/internal/scraper"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/atlassian"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rest"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rss"
+	"github.com/metoro-io/statusphere/scraper/internal/scraper/providers/rss_ckp"
+	"go.uber.org/zap"
+)
+
+// NewProviders returns every provider, in the order they are tried when a status page has no preferred scraper
+func NewProviders(logger *zap.Logger, httpClient *http.Client) []providers.Provider {
+	return newProviders(logger, httpClient, rest.NewRestProvider(logger, httpClient))
+}
+
+func newProviders(logger *zap.Logger, httpClient *http.Client, restProvider *rest.RestProvider) []providers.Provider {
+	return []providers.Provider{
+		atlassian.NewAtlassianProvider(logger, httpClient),
+		rss.NewRssProvider(logger, httpClient),
+		rss_ckp.NewCkpRssProvider(logger, httpClient),
+		restProvider,
+	}
+}
+
+// NewScraper returns a scraper trying every provider
+func NewScraper(logger *zap.Logger, httpClient *http.Client) scraper.Scraper {
+	return scraper.NewScraper(logger, httpClient, NewProviders(logger, httpClient))
+}
+
+// NewRequestScraper returns a scraper trying every provider, for the status page definitions that come from a request
+// The environment placeholders of the headers are not replaced, see rest.NewRequestRestProvider
+func NewRequestScraper(logger *zap.Logger, httpClient *http.Client) scraper.Scraper {
+	return scraper.NewScraper(logger, httpClient, newProviders(logger, httpClient, rest.NewRequestRestProvider(logger, httpClient)))
+}
+
+// ProviderNames returns the names of every provider, the values the preferred scraper of a status page can take
+func ProviderNames() []string {
+	return []string{
+		string(providers.ProviderAtlassian),
+		string(providers.ProviderRSS),
+		string(providers.ProviderCKP),
+		string(providers.ProviderRest),
+	}
+}